@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"troisdizaines.com/rcalc/rcalc"
+)
+
+// Exit codes of the evaluations without user interface
+const (
+	exitRuntimeError = 1
+	exitParseError   = 2
+)
+
+var evalOptions rcalc.EvalOptions
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <command line>",
+	Short: "Runs a command line and prints the resulting stack",
+	Long: `Runs a command line and prints the resulting stack, like rcalc eval "2 3 ^"
+The exit code is 1 when an action fails and 2 when the command line cannot be parsed`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(rcalc.Eval(getConfigFolder(), *debugMode, evalOptions, args[0], os.Stdout))
+	},
+}
+
+// isStdinPiped is true when the commands are read from a pipe or a file instead of a terminal
+func isStdinPiped() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice == 0
+}
+
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	_, _ = fmt.Fprintln(os.Stderr, err)
+	var parseError *rcalc.ParseError
+	if errors.As(err, &parseError) {
+		os.Exit(exitParseError)
+	}
+	os.Exit(exitRuntimeError)
+}
+
+func addEvalFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&evalOptions.Ephemeral, "ephemeral", false, "Starts with an empty stack and saves nothing")
+	cmd.Flags().BoolVar(&evalOptions.TopOnly, "top", false, "Prints only the level 1 of the stack")
+}
+
+func init() {
+	addEvalFlags(evalCmd)
+	rootCmd.AddCommand(evalCmd)
+}
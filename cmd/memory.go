@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"troisdizaines.com/rcalc/rcalc"
+)
+
+var memoryCmd = &cobra.Command{
+	Use:   "memory",
+	Short: "Exports and imports memory folders as text files",
+}
+
+var memoryExportCmd = &cobra.Command{
+	Use:   "export <folder> <file>",
+	Short: "Writes a memory folder and its content to a text file",
+	Long: `Writes a memory folder and its content to a text file
+The folder is given by its path from HOME, like LIB/CONSTANTS, HOME being the whole memory`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return rcalc.ExportSavedFolder(getConfigFolder(), args[0], args[1])
+	},
+}
+
+var memoryImportCmd = &cobra.Command{
+	Use:   "import <file> [<folder>]",
+	Short: "Reads the folders of a text file into a memory folder, HOME by default",
+	Long: `Reads the folders of a text file into a memory folder, HOME by default
+Existing folders are merged and existing variables are replaced`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folderPath := ""
+		if len(args) == 2 {
+			folderPath = args[1]
+		}
+		return rcalc.ImportIntoSavedFolder(getConfigFolder(), args[0], folderPath)
+	},
+}
+
+func init() {
+	memoryCmd.AddCommand(memoryExportCmd)
+	memoryCmd.AddCommand(memoryImportCmd)
+	rootCmd.AddCommand(memoryCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"troisdizaines.com/rcalc/rcalc"
+)
+
+var opsCmd = &cobra.Command{
+	Use:   "ops [operation]",
+	Short: "Lists the operations of the calculator by package or describes one of them",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			printOperationHelp(args[0])
+			return
+		}
+		for _, packageDoc := range rcalc.Registry.GetPackageDocs() {
+			fmt.Println(packageDoc.Name)
+			for _, opCode := range packageDoc.OpCodes {
+				doc, _, _ := rcalc.Registry.GetDoc(opCode)
+				fmt.Println("  " + doc.Line(opCode))
+			}
+		}
+	},
+}
+
+var aproposCmd = &cobra.Command{
+	Use:   "apropos <keyword>",
+	Short: "Lists the operations whose name, summary or package contains a keyword",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, opCode := range rcalc.Registry.Apropos(args[0]) {
+			doc, _, _ := rcalc.Registry.GetDoc(opCode)
+			fmt.Println(doc.Line(opCode))
+		}
+	},
+}
+
+// helpCmd replaces the help command of cobra to describe the operations too, like rcalc help dup
+var helpCmd = &cobra.Command{
+	Use:   "help [command or operation]",
+	Short: "Help about any command or operation",
+	Run: func(cmd *cobra.Command, args []string) {
+		target, remainingArgs, err := rootCmd.Find(args)
+		if len(args) == 1 && (err != nil || target == rootCmd || len(remainingArgs) > 0) {
+			printOperationHelp(args[0])
+			return
+		}
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitRuntimeError)
+		}
+		_ = target.Help()
+	},
+}
+
+func printOperationHelp(opCode string) {
+	help, err := rcalc.Registry.Help(opCode)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitRuntimeError)
+	}
+	fmt.Println(help)
+}
+
+func init() {
+	rootCmd.AddCommand(opsCmd)
+	rootCmd.AddCommand(aproposCmd)
+	rootCmd.SetHelpCommand(helpCmd)
+}
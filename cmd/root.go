@@ -11,32 +11,46 @@ import (
 
 var debugMode *bool
 var configFolder *string
+var noTui *bool
 
 var rootCmd = &cobra.Command{
 	Use:   "rcalc",
 	Short: "Rcalc is a RPN command line calculator",
 	Long: `Rcalc is a RPN command line calculator
-It includes a programming language`,
+It includes a programming language
+When the standard input is not a terminal, its lines are run and the resulting stack is printed,
+--ephemeral and --top only apply in that case
+The modes, the stack levels shown, the aliases and a startup program can be set in rcalc.yaml in the config folder`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var rCalcDir string
-		if *configFolder == "" {
-			dir, err := homedir.Dir()
-			if err != nil {
-				fmt.Println("Cannot get home directory")
-				os.Exit(-1)
-			}
-			rCalcDir = path.Join(dir, ".rcalc")
-		} else {
-			rCalcDir = *configFolder
+		if isStdinPiped() {
+			exitOnError(rcalc.EvalInput(getConfigFolder(), *debugMode, evalOptions, os.Stdin, os.Stdout))
+			return
 		}
-
-		rcalc.Run(rCalcDir, true, *debugMode)
+		if cmd.Flags().Changed("ephemeral") || cmd.Flags().Changed("top") {
+			exitOnError(fmt.Errorf("--ephemeral and --top only apply when the standard input is piped"))
+		}
+		exitOnError(rcalc.Run(getConfigFolder(), true, *debugMode, !*noTui))
 	},
 }
 
+// getConfigFolder returns the folder holding the stack, the memory, the modes and rcalc.yaml
+func getConfigFolder() string {
+	if *configFolder != "" {
+		return *configFolder
+	}
+	dir, err := homedir.Dir()
+	if err != nil {
+		fmt.Println("Cannot get home directory")
+		os.Exit(-1)
+	}
+	return path.Join(dir, ".rcalc")
+}
+
 func init() {
 	debugMode = rootCmd.PersistentFlags().BoolP("debugMode", "d", false, "Sets logs verbosity to debug")
 	configFolder = rootCmd.PersistentFlags().StringP("configFolder", "c", "", "Sets the config folder")
+	noTui = rootCmd.Flags().Bool("noTui", false, "Uses a line based interface instead of the full screen one")
+	addEvalFlags(rootCmd)
 }
 
 func Execute() {
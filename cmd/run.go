@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"troisdizaines.com/rcalc/rcalc"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <file>",
+	Short: "Runs a script file and prints the resulting stack",
+	Long: `Runs a script file and prints the resulting stack, like rcalc run circle.rcalc
+A script holds commands on several lines, programs can span lines and comments run from @ to the end of the line.
+The exit code is 1 when an action fails and 2 when the script cannot be parsed`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitOnError(rcalc.EvalFile(getConfigFolder(), *debugMode, evalOptions, args[0], os.Stdout))
+	},
+}
+
+func init() {
+	addEvalFlags(runCmd)
+	rootCmd.AddCommand(runCmd)
+}
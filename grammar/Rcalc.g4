@@ -15,6 +15,15 @@ NUMBER
     | SCIENTIFIC_NUMBER
     ;
 
+// Numbers with a unit of measure like 9.81_m/s^2
+fragment UNIT_NAME: [a-zA-Zµ]+ ;
+fragment UNIT_FACTOR: UNIT_NAME ('^' '-'? INT_NUMBER)? ;
+QUANTITY: (INT_NUMBER | DECIMAL_NUMBER | SCIENTIFIC_NUMBER) '_' UNIT_FACTOR (('*' | '/') UNIT_FACTOR)* ;
+
+// Binary integers: # followed by digits and an optional base suffix (h, d, o or b), #FFh or #1011b.
+// Hex digits are uppercase so that they cannot be read as a suffix: #1Bh is hex and #1b is binary.
+BINARY_INTEGER: '#' [0-9A-F]+ [hdob]? ;
+
 OP_ADD: '+';
 OP_SUB: '-';
 OP_MUL: '*';
@@ -27,6 +36,9 @@ OP_TEST_GT: '>';
 OP_TEST_LET: '<=';
 OP_TEST_GET: '>=';
 
+// Double quoted strings, \" \\ \n and \t escapes are allowed inside
+STRING: '"' ( '\\' . | ~["\\] )* '"' ;
+
 DQUOTE: '"';
 QUOTE: '\'';
 COMMA: ',';
@@ -43,16 +55,39 @@ BRACKET_CLOSE: ']';
 PROG_OPEN: '<<';
 PROG_CLOSE: '>>';
 
-KW_START: 'start';
-KW_FOR: 'for';
-KW_NEXT: 'next';
+// Keywords are matched ignoring case like the op codes: IF, If and if are the same keyword
+KW_START options { caseInsensitive = true; }: 'start';
+KW_FOR options { caseInsensitive = true; }: 'for';
+KW_NEXT options { caseInsensitive = true; }: 'next';
+KW_STEP options { caseInsensitive = true; }: 'step';
+
+KW_WHILE options { caseInsensitive = true; }: 'while';
+KW_REPEAT options { caseInsensitive = true; }: 'repeat';
+KW_DO options { caseInsensitive = true; }: 'do';
+KW_UNTIL options { caseInsensitive = true; }: 'until';
+
+KW_IF options { caseInsensitive = true; }: 'if';
+KW_THEN options { caseInsensitive = true; }: 'then';
+KW_ELSE options { caseInsensitive = true; }: 'else';
+KW_END options { caseInsensitive = true; }: 'end';
+KW_CASE options { caseInsensitive = true; }: 'case';
+KW_IFERR options { caseInsensitive = true; }: 'iferr';
+
+// Names can contain an arrow to allow conversion commands like ->str, str-> or r->c. They can
+// contain letters of any alphabet and the root symbol, for aliases like √ for sqrt
+fragment NAME_START: [\p{Alpha}_√];
+fragment NAME_CHAR: [\p{Alpha}0-9_√];
+NAME
+    : NAME_START NAME_CHAR* ('->' NAME_CHAR*)?
+    | '->' NAME_START NAME_CHAR*
+    ;
 
-KW_IF: 'if';
-KW_THEN: 'then';
-KW_ELSE: 'else';
-KW_END: 'end';
+// Commands whose name holds an operator, like sto+ or load-script. They are not NAMEs so that X+1
+// is still a sum in algebraic expressions
+SYMBOL_NAME: 'sto+' | 'sto-' | 'load-script' ;
 
-NAME: [a-zA-Z_][a-zA-Z0-9_]*;
+// Comments run from @ to the end of the line, mostly useful in script files
+COMMENT: '@' ~[\r\n]* -> skip;
 
 // We define whitespaces but we cannot skip them since in RPN mode
 // 2-3 must not parse and 2 - 3 and 2 -3 are not the same thing
@@ -60,7 +95,8 @@ NAME: [a-zA-Z_][a-zA-Z0-9_]*;
 WHITESPACE: [ \r\n\t]+;
 
 // Rules
-start : instr_seq EOF;
+// A script file can hold only comments and blank lines
+start : (instr_seq | WHITESPACE*) EOF;
 
 instr_seq: WHITESPACE* instr (WHITESPACE+ instr)* WHITESPACE* # InstructionSequence;
 
@@ -69,8 +105,14 @@ instr
     | op                         # InstrOp
     | variable                   # InstrVariable
     | if_then_else               # InstrIfThenElse
+    | case_then_end              # InstrCase
+    | iferr_then_else            # InstrIfErr
     | start_next_loop            # InstrStartNextLoop
     | for_next_loop              # InstrForNextLoop
+    | start_step_loop            # InstrStartStepLoop
+    | for_step_loop              # InstrForStepLoop
+    | while_repeat_loop          # InstrWhileRepeatLoop
+    | do_until_loop              # InstrDoUntilLoop
     | local_var_creation         # InstrLocalVarCreation
     ;
 
@@ -82,9 +124,24 @@ op
 if_then_else
     : KW_IF instr_seq KW_THEN instr_seq (KW_ELSE instr_seq)* KW_END ;
 
+// case cond1 then body1 end cond2 then body2 end default_body end, the default is optional
+case_then_end: KW_CASE WHITESPACE* (case_clause WHITESPACE*)* instr_seq? KW_END ;
+case_clause: instr_seq KW_THEN instr_seq KW_END ;
+
+// The then actions are run when an action of the trap actions fails, the else actions otherwise
+iferr_then_else: KW_IFERR instr_seq KW_THEN instr_seq (KW_ELSE instr_seq)? KW_END ;
+
 start_next_loop: KW_START instr_seq KW_NEXT ;
 for_next_loop: KW_FOR WHITESPACE* variableDeclaration instr_seq KW_NEXT ;
 
+// The increment is taken from the stack before step
+start_step_loop: KW_START instr_seq KW_STEP ;
+for_step_loop: KW_FOR WHITESPACE* variableDeclaration instr_seq KW_STEP ;
+
+// Indefinite loops, conditions are evaluated from the stack like in if then else
+while_repeat_loop: KW_WHILE instr_seq KW_REPEAT instr_seq KW_END ;
+do_until_loop: KW_DO instr_seq KW_UNTIL instr_seq KW_END ;
+
 program_declaration: PROG_OPEN instr_seq PROG_CLOSE ;
 
 local_var_creation
@@ -100,14 +157,27 @@ statement_for_local_var_creation
 
 variable
     : number                      # VariableNumber
+    | STRING                      # VariableString
     | quoted_algebraic_expression # VariableAlgebraicExpression
     | program_declaration         # VariableProgramDeclaration
     | list                        # VariableList
     | vector                      # VariableVector
+    | complex                     # VariableComplex
+    | rational                    # VariableRational
+    | quantity                    # VariableQuantity
+    | BINARY_INTEGER              # VariableBinaryInteger
     ;
 
 number: (OP_ADD|OP_SUB)?NUMBER ;
 
+// Exact fractions, no whitespace is allowed to make the difference with the division in RPN mode
+rational: (OP_ADD|OP_SUB)? NUMBER OP_DIV NUMBER ;
+
+quantity: (OP_ADD|OP_SUB)? QUANTITY ;
+
+// Complex numbers in rectangular form: (re, im)
+complex: PAREN_OPEN WHITESPACE* number WHITESPACE* COMMA WHITESPACE* number WHITESPACE* PAREN_CLOSE ;
+
 quoted_algebraic_expression: QUOTE WHITESPACE* alg_expression WHITESPACE* QUOTE ;
 
 alg_expression
@@ -147,6 +217,7 @@ list : CURLY_OPEN WHITESPACE* (list_item WHITESPACE*)* CURLY_CLOSE;
 
 list_item : variable # ListItem;
 
-vector : BRACKET_OPEN (vector+|number+) BRACKET_CLOSE ;
+// A vector of numbers, or a matrix given as a vector of row vectors
+vector : BRACKET_OPEN WHITESPACE* ((vector WHITESPACE*)+ | (number WHITESPACE*)+) BRACKET_CLOSE ;
 
-action_or_var_call: NAME;
+action_or_var_call: NAME | SYMBOL_NAME;
@@ -2,6 +2,10 @@ package rcalc
 
 import (
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/shopspring/decimal"
 	"troisdizaines.com/rcalc/rcalc/protostack"
@@ -53,23 +57,13 @@ func (op *ActionCommonDesc) UnMarshallFunc() ActionUnMarshallFunc {
 
 type ActionApplyFn func(system System, stack *Stack) error
 
-// ActionDesc implementation of Action interface
+// ActionDesc implementation of Action interface, marshalled with its opcode like operations
 type ActionDesc struct {
 	ActionCommonDesc
 	nbArgs        int
 	actionApplyFn ActionApplyFn
 }
 
-func (a *ActionDesc) MarshallFunc() ActionMarshallFunc {
-	//TODO implement me
-	panic("implement me ActionDesc MarshallFunc")
-}
-
-func (a *ActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
-	//TODO implement me
-	panic("implement me ActionDesc UnMarshallFunc")
-}
-
 var _ Action = (*ActionDesc)(nil)
 
 func NewActionDesc(opCode string, nbArgs int, checkTypeFn CheckTypeFn, applyFn ActionApplyFn) ActionDesc {
@@ -233,7 +227,7 @@ func (op *OperationDesc) Apply(runtimeContext *RuntimeContext) error {
 			}
 			for _, result := range results {
 				resultAsList := CreateListVariable(result)
-				fmt.Printf("Result: %s\n", resultAsList.display())
+				GetLogger().Debugf("Result: %s", resultAsList.display(runtimeContext.system.Modes()))
 				runtimeContext.stack.Push(resultAsList)
 			}
 		} else {
@@ -264,33 +258,45 @@ func OpToActionFn(opFn PureOperationApplyFn) OperationApplyFn {
 	}
 }
 
-type AlgebraicFn func(args ...decimal.Decimal) decimal.Decimal
+type AlgebraicFn func(modes *Modes, args ...decimal.Decimal) decimal.Decimal
+
+// AlgebraicDerivativeFn derivative of a function of one argument, as an expression of this
+// argument. deriv multiplies it by the derivative of the argument.
+type AlgebraicDerivativeFn func(reg *ActionRegistry, arg AlgebraicExpressionNode) (AlgebraicExpressionNode, error)
 
 type AlgebraicFunctionDesc struct {
 	name      string
 	argsCount int
 	fn        AlgebraicFn
+	// derivative is nil for the functions which cannot be derived
+	derivative AlgebraicDerivativeFn
+	// angleArgument the argument is an angle in the angle mode, derivative is the one in radians
+	angleArgument bool
 }
 
 /* Registry stuff */
 
 type ActionRegistry struct {
+	packages       []*ActionPackage
 	actionDescs    map[string]Action
 	dynamicActions map[string]struct {
 		marshalFunc   ActionMarshallFunc
 		unMarshalFunc ActionUnMarshallFunc
 	}
 	algebraicFunctionsByName map[string]AlgebraicFunctionDesc
-}
-
-func (reg *ActionRegistry) Register(aDesc Action) {
-	reg.actionDescs[aDesc.OpCode()] = aDesc
+	// aliases op codes of the actions called by another name, by alias
+	aliases map[string]string
 }
 
 type ActionPackage struct {
+	name                string
 	staticActions       []Action
 	dynamicActions      []Action
 	algrebraicFunctions []AlgebraicFunctionDesc
+	// docs documentation of the static actions by op code
+	docs map[string]ActionDoc
+	// aliases other names of the static actions, op codes by alias
+	aliases map[string]string
 }
 
 func (ap *ActionPackage) AddStatic(action Action) {
@@ -305,10 +311,30 @@ func (ap *ActionPackage) AddAlgebraicFunction(desc AlgebraicFunctionDesc) {
 	ap.algrebraicFunctions = append(ap.algrebraicFunctions, desc)
 }
 
-func (reg *ActionRegistry) RegisterActions(aPackage *ActionPackage) {
+// normalizeOpCode op codes and aliases are matched ignoring case: DUP calls dup
+func normalizeOpCode(name string) string {
+	return strings.ToLower(name)
+}
+
+// RegisterActions adds the actions and the aliases of a package, an op code or an alias already
+// registered is an error
+func (reg *ActionRegistry) RegisterActions(aPackage *ActionPackage) error {
 	for _, aDesc := range aPackage.staticActions {
-		reg.actionDescs[aDesc.OpCode()] = aDesc
+		opCode := normalizeOpCode(aDesc.OpCode())
+		if existing, ok := reg.actionDescs[opCode]; ok {
+			return fmt.Errorf("package %s: op code %s is already registered by %s", aPackage.name, aDesc.OpCode(), existing.OpCode())
+		}
+		if _, ok := reg.aliases[opCode]; ok {
+			return fmt.Errorf("package %s: op code %s is already an alias", aPackage.name, aDesc.OpCode())
+		}
+		reg.actionDescs[opCode] = aDesc
 	}
+	for _, alias := range slices.Sorted(maps.Keys(aPackage.aliases)) {
+		if err := reg.RegisterAlias(alias, aPackage.aliases[alias]); err != nil {
+			return fmt.Errorf("package %s: %w", aPackage.name, err)
+		}
+	}
+	reg.packages = append(reg.packages, aPackage)
 	for _, dynAction := range aPackage.dynamicActions {
 		reg.dynamicActions[dynAction.OpCode()] = struct {
 			marshalFunc   ActionMarshallFunc
@@ -321,6 +347,7 @@ func (reg *ActionRegistry) RegisterActions(aPackage *ActionPackage) {
 	for _, algFnDesc := range aPackage.algrebraicFunctions {
 		reg.algebraicFunctionsByName[algFnDesc.name] = algFnDesc
 	}
+	return nil
 }
 
 func initRegistry() *ActionRegistry {
@@ -331,28 +358,76 @@ func initRegistry() *ActionRegistry {
 			unMarshalFunc ActionUnMarshallFunc
 		}{},
 		algebraicFunctionsByName: map[string]AlgebraicFunctionDesc{},
+		aliases:                  map[string]string{},
+	}
+	packages := []*ActionPackage{
+		&ArithmeticPackage,
+		&TrigonometricPackage,
+		&AlgebraPackage,
+		&BooleanLogicPackage,
+		&StatPackage,
+		&StackPackage,
+		&MemoryPackage,
+		&StructOpsPackage,
+		&ListPackage,
+		&StringPackage,
+		&LinearAlgebraPackage,
+		&ComplexPackage,
+		&UnitsPackage,
+		&ModesPackage,
+		&BinaryPackage,
+		&UndoPackage,
+		&ErrorPackage,
+		&ScriptPackage,
+		&MiscPackage,
+	}
+	for _, aPackage := range packages {
+		// a collision between the built-in op codes is a programming error
+		if err := reg.RegisterActions(aPackage); err != nil {
+			panic(err)
+		}
 	}
-	reg.RegisterActions(&ArithmeticPackage)
-	reg.RegisterActions(&TrigonometricPackage)
-	reg.RegisterActions(&BooleanLogicPackage)
-	reg.RegisterActions(&StatPackage)
-	reg.RegisterActions(&StackPackage)
-	reg.RegisterActions(&MemoryPackage)
-	reg.RegisterActions(&StructOpsPackage)
-	reg.RegisterActions(&ListPackage)
-	reg.Register(&DebugOp)
-	reg.Register(&VersionOp)
-	reg.Register(&EXIT_ACTION)
 	return &reg
 }
 
+// aliasSyntax mirrors the NAME token of the grammar, other aliases could not be typed
+var aliasSyntax = regexp.MustCompile(`^(?:[\p{L}_√][\p{L}0-9_√]*(?:->[\p{L}0-9_√]*)?|->[\p{L}_√][\p{L}0-9_√]*)$`)
+
+// grammarKeywords are read as keywords of the structured programming, whatever their case
+var grammarKeywords = []string{"start", "for", "next", "step", "while", "repeat", "do", "until",
+	"if", "then", "else", "end", "case", "iferr"}
+
+// RegisterAlias makes an action callable by another name, the alias cannot hide an op code or
+// another alias. The target can be given by one of its aliases.
+func (reg *ActionRegistry) RegisterAlias(alias string, opCode string) error {
+	if !aliasSyntax.MatchString(alias) {
+		return fmt.Errorf("invalid alias '%s', an alias is a name like sq or ->deg", alias)
+	}
+	if slices.Contains(grammarKeywords, strings.ToLower(alias)) {
+		return fmt.Errorf("invalid alias '%s', %s is a keyword", alias, alias)
+	}
+	normalizedAlias := normalizeOpCode(alias)
+	if _, ok := reg.actionDescs[normalizedAlias]; ok {
+		return fmt.Errorf("alias %s is already an operation", alias)
+	}
+	if target, ok := reg.aliases[normalizedAlias]; ok {
+		return fmt.Errorf("alias %s is already an alias of %s", alias, target)
+	}
+	target := reg.resolveAlias(opCode)
+	if _, ok := reg.actionDescs[target]; !ok {
+		return fmt.Errorf("alias %s of unknown operation %s", alias, opCode)
+	}
+	reg.aliases[normalizedAlias] = target
+	return nil
+}
+
 func (reg *ActionRegistry) ContainsOpCode(opCode string) bool {
-	_, ok := reg.actionDescs[opCode]
+	_, ok := reg.actionDescs[reg.resolveAlias(opCode)]
 	return ok
 }
 
 func (reg *ActionRegistry) GetAction(opCode string) Action {
-	actionDesc, ok := reg.actionDescs[opCode]
+	actionDesc, ok := reg.actionDescs[reg.resolveAlias(opCode)]
 	if !ok {
 		return nil
 	} else {
@@ -360,6 +435,28 @@ func (reg *ActionRegistry) GetAction(opCode string) Action {
 	}
 }
 
+// resolveAlias normalized op code of the action called by name, whatever its case
+func (reg *ActionRegistry) resolveAlias(name string) string {
+	normalizedName := normalizeOpCode(name)
+	if opCode, ok := reg.aliases[normalizedName]; ok {
+		return opCode
+	}
+	return normalizedName
+}
+
+// GetAliases sorted aliases of an action
+func (reg *ActionRegistry) GetAliases(opCode string) []string {
+	target := reg.resolveAlias(opCode)
+	var aliases []string
+	for alias, aliasTarget := range reg.aliases {
+		if aliasTarget == target {
+			aliases = append(aliases, alias)
+		}
+	}
+	slices.Sort(aliases)
+	return aliases
+}
+
 func (reg *ActionRegistry) GetDynamicActionMarshallFunc(opCode string) ActionMarshallFunc {
 	if dynAction, ok := reg.dynamicActions[opCode]; ok {
 		return dynAction.marshalFunc
@@ -376,6 +473,11 @@ func (reg *ActionRegistry) GetDynamicActionUnMarshallFunc(opCode string) ActionU
 	}
 }
 
+func (reg *ActionRegistry) getAlgebraicFunctionDesc(fnName string) (AlgebraicFunctionDesc, bool) {
+	algebraicFunctionDesc, ok := reg.algebraicFunctionsByName[fnName]
+	return algebraicFunctionDesc, ok
+}
+
 func (reg *ActionRegistry) GetAlgebraicFunction(fnName string) AlgebraicFn {
 	algebraicFunctionDesc, ok := reg.algebraicFunctionsByName[fnName]
 	if !ok {
@@ -401,6 +503,18 @@ func (reg *ActionRegistry) CreateActionFromProto(protoAction *protostack.Action)
 	return nil, fmt.Errorf("no unMarshallFunction found for type %d / OpCode %s", protoAction.Type, protoAction.OpCode)
 }
 
+// GetOpCodes sorted op codes of the actions which can be called by their name
+func (reg *ActionRegistry) GetOpCodes() []string {
+	var opCodes []string
+	for opCode := range reg.actionDescs {
+		if !strings.HasPrefix(opCode, "__hidden__") {
+			opCodes = append(opCodes, opCode)
+		}
+	}
+	slices.Sort(opCodes)
+	return opCodes
+}
+
 func (reg *ActionRegistry) GetDynamicActionOpCodes() []string {
 	result := make([]string, len(reg.dynamicActions))
 	for k := range reg.dynamicActions {
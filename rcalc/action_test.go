@@ -0,0 +1,62 @@
+package rcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryIgnoresCase(t *testing.T) {
+	for _, name := range []string{"swap", "SWAP", "Swap"} {
+		assert.Same(t, &swapOp, Registry.GetAction(name), name)
+		assert.True(t, Registry.ContainsOpCode(name), name)
+	}
+	assert.Same(t, &VersionOp, Registry.GetAction("VERSION"))
+	assert.Nil(t, Registry.GetAction("swapp"))
+}
+
+func TestRegistryHasNoCollisions(t *testing.T) {
+	assert.Same(t, &sinOp, Registry.GetAction("sin"))
+	assert.Same(t, &tanOp, Registry.GetAction("tan"))
+	assert.Same(t, &ltNumOp, Registry.GetAction("<"))
+	assert.Same(t, &gtNumOp, Registry.GetAction(">"))
+}
+
+func TestRegistryAliases(t *testing.T) {
+	assert.Same(t, &sqrtOp, Registry.GetAction("√"))
+	assert.Same(t, &toListOp, Registry.GetAction("->list"))
+	assert.Same(t, &toListOp, Registry.GetAction("->LIST"))
+	assert.Equal(t, []string{"√"}, Registry.GetAliases("SQRT"))
+	assert.Same(t, &recallAct, Registry.GetAction("load"))
+
+	reg := initRegistry()
+	assert.NoError(t, reg.RegisterAlias("Root", "√"), "an alias can target another alias")
+	assert.Same(t, &sqrtOp, reg.GetAction("root"))
+	assert.Equal(t, []string{"root", "√"}, reg.GetAliases("sqrt"))
+
+	assert.EqualError(t, reg.RegisterAlias("SQRT", "dup"), "alias SQRT is already an operation")
+	assert.EqualError(t, reg.RegisterAlias("ROOT", "dup"), "alias ROOT is already an alias of sqrt")
+	assert.EqualError(t, reg.RegisterAlias("sq", "square"), "alias sq of unknown operation square")
+	assert.EqualError(t, reg.RegisterAlias("", "dup"), "invalid alias '', an alias is a name like sq or ->deg")
+	for _, alias := range []string{"s q", "sq+", "2sq", "sq(", "'sq'", "#sq"} {
+		assert.EqualError(t, reg.RegisterAlias(alias, "dup"), "invalid alias '"+alias+"', an alias is a name like sq or ->deg")
+	}
+	assert.EqualError(t, reg.RegisterAlias("Next", "dup"), "invalid alias 'Next', Next is a keyword")
+	assert.NoError(t, reg.RegisterAlias("dup2x", "dup"))
+	assert.NoError(t, reg.RegisterAlias("x->y", "dup"))
+	assert.NoError(t, reg.RegisterAlias("_carré", "dup"))
+}
+
+func TestRegisterActionsRejectsDuplicates(t *testing.T) {
+	reg := initRegistry()
+	clash := NewStackOp("SIN", 1, 1, func(elts ...Variable) []Variable { return elts })
+	err := reg.RegisterActions(&ActionPackage{name: "clash", staticActions: []Action{&clash}})
+	assert.EqualError(t, err, "package clash: op code SIN is already registered by sin")
+
+	aliasClash := NewStackOp("->list", 1, 1, func(elts ...Variable) []Variable { return elts })
+	err = reg.RegisterActions(&ActionPackage{name: "clash", staticActions: []Action{&aliasClash}})
+	assert.EqualError(t, err, "package clash: op code ->list is already an alias")
+
+	err = reg.RegisterActions(&ActionPackage{name: "clash", aliases: map[string]string{"DUP": "drop"}})
+	assert.EqualError(t, err, "package clash: alias DUP is already an operation")
+}
@@ -0,0 +1,147 @@
+package rcalc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName file of the config folder holding the user settings
+const configFileName = "rcalc.yaml"
+
+// defaultStackLevels number of stack levels shown by the frontends, even when empty
+const defaultStackLevels = 3
+
+// Config user settings read from rcalc.yaml in the config folder, like:
+//
+//	display: fix
+//	digits: 4
+//	angle: deg
+//	stackLevels: 5
+//	clearScreen: true
+//	aliases:
+//	  sq: sqrt
+//	startup: |
+//	  << dup * >> 'square' sto
+type Config struct {
+	// Display display mode set at launch: std, fix, sci or eng. The saved modes are kept when empty.
+	Display string `yaml:"display"`
+	// Digits digits count of the fix, sci and eng display modes, it needs a display mode
+	Digits int `yaml:"digits"`
+	// Angle angle mode set at launch: rad, deg or grad. The saved mode is kept when empty.
+	Angle string `yaml:"angle"`
+	// StackLevels number of stack levels shown by the frontends, even when empty
+	StackLevels int `yaml:"stackLevels"`
+	// ClearScreen clears the terminal before the console frontend prints the stack, the full
+	// screen frontend always redraws the whole terminal
+	ClearScreen bool `yaml:"clearScreen"`
+	// Aliases other names of the operations, by alias. An alias is written like a variable name.
+	Aliases map[string]string `yaml:"aliases"`
+	// Startup program run at launch of the interactive calculator, it can span lines
+	Startup string `yaml:"startup"`
+	// source path of the file, used in the errors
+	source string
+}
+
+var displayModesByName = map[string]DisplayMode{
+	"std": DISPLAY_STD,
+	"fix": DISPLAY_FIX,
+	"sci": DISPLAY_SCI,
+	"eng": DISPLAY_ENG,
+}
+
+var angleModesByName = map[string]AngleMode{
+	"rad":  ANGLE_RAD,
+	"deg":  ANGLE_DEG,
+	"grad": ANGLE_GRAD,
+}
+
+func defaultConfig(source string) *Config {
+	return &Config{StackLevels: defaultStackLevels, source: source}
+}
+
+// LoadConfig reads rcalc.yaml from the config folder, the default settings are used when it does
+// not exist. Unknown keys and invalid values are errors naming the file.
+func LoadConfig(configFolder string) (*Config, error) {
+	configPath := path.Join(configFolder, configFileName)
+	content, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultConfig(configPath), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config: %w", err)
+	}
+	return parseConfig(content, configPath)
+}
+
+func parseConfig(content []byte, source string) (*Config, error) {
+	config := defaultConfig(source)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	if _, ok := displayModesByName[c.Display]; c.Display != "" && !ok {
+		return fmt.Errorf("display: unknown display mode %s, expected std, fix, sci or eng", c.Display)
+	}
+	if c.Digits < 0 || c.Digits > maxDisplayDigits {
+		return fmt.Errorf("digits: %d is not a valid digits count, expected 0..%d", c.Digits, maxDisplayDigits)
+	}
+	if c.Digits != 0 && c.Display == "" {
+		return fmt.Errorf("digits: %d needs a display mode, set display to fix, sci or eng", c.Digits)
+	}
+	if _, ok := angleModesByName[c.Angle]; c.Angle != "" && !ok {
+		return fmt.Errorf("angle: unknown angle mode %s, expected rad, deg or grad", c.Angle)
+	}
+	if c.StackLevels < 0 {
+		return fmt.Errorf("stackLevels: %d is not a valid levels count", c.StackLevels)
+	}
+	return nil
+}
+
+// apply sets the modes of the config and registers its aliases
+func (c *Config) apply(system System) error {
+	if c.Display != "" {
+		system.Modes().SetDisplay(displayModesByName[c.Display], c.Digits)
+	}
+	if c.Angle != "" {
+		system.Modes().SetAngle(angleModesByName[c.Angle])
+	}
+	for _, alias := range slices.Sorted(maps.Keys(c.Aliases)) {
+		if err := system.Registry().RegisterAlias(alias, c.Aliases[alias]); err != nil {
+			return fmt.Errorf("%s: aliases: %w", c.source, err)
+		}
+	}
+	return nil
+}
+
+// runStartup runs the startup program as a single command line, its errors are located in the
+// program like the ones of the scripts
+func (c *Config) runStartup(system *SystemInstance, stack *Stack) error {
+	if strings.TrimSpace(c.Startup) == "" {
+		return nil
+	}
+	sourceName := c.source + " startup"
+	actions, err := parseToLocatedActions(c.Startup, sourceName, system.Registry(), system.Modes())
+	if err != nil {
+		return err
+	}
+	return runInTransaction(system, stack, func(runtimeContext *RuntimeContext) error {
+		return runScriptActions(runtimeContext, sourceName, actions)
+	})
+}
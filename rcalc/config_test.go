@@ -0,0 +1,131 @@
+package rcalc
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	folder := t.TempDir()
+	assert.NoError(t, os.WriteFile(path.Join(folder, configFileName), []byte(content), 0644))
+	return folder
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig(t.TempDir())
+	assert.NoError(t, err, "a missing file gives the defaults")
+	assert.Equal(t, defaultStackLevels, config.StackLevels)
+	assert.False(t, config.ClearScreen)
+	assert.Empty(t, config.Aliases)
+
+	folder := writeConfig(t, `
+display: fix
+digits: 4
+angle: deg
+stackLevels: 5
+clearScreen: true
+aliases:
+  sq: sqrt
+startup: |
+  1 2
+  +
+`)
+	config, err = LoadConfig(folder)
+	assert.NoError(t, err)
+	assert.Equal(t, "fix", config.Display)
+	assert.Equal(t, 4, config.Digits)
+	assert.Equal(t, "deg", config.Angle)
+	assert.Equal(t, 5, config.StackLevels)
+	assert.True(t, config.ClearScreen)
+	assert.Equal(t, map[string]string{"sq": "sqrt"}, config.Aliases)
+	assert.Equal(t, "1 2\n+\n", config.Startup)
+
+	config, err = LoadConfig(writeConfig(t, ""))
+	assert.NoError(t, err, "an empty file gives the defaults")
+	assert.Equal(t, defaultStackLevels, config.StackLevels)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"display: fixed", "display: unknown display mode fixed, expected std, fix, sci or eng"},
+		{"digits: 33", "digits: 33 is not a valid digits count, expected 0..32"},
+		{"angle: degrees", "angle: unknown angle mode degrees, expected rad, deg or grad"},
+		{"stackLevels: -1", "stackLevels: -1 is not a valid levels count"},
+		{"digits: 4", "digits: 4 needs a display mode, set display to fix, sci or eng"},
+		{"stackLevel: 4", "yaml: unmarshal errors:\n  line 1: field stackLevel not found in type rcalc.Config"},
+		{"clearScreen: maybe", "yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `maybe` into bool"},
+		{"aliases: [sq]", "yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into map[string]string"},
+	}
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			folder := writeConfig(t, test.content)
+			_, err := LoadConfig(folder)
+			assert.EqualError(t, err, path.Join(folder, configFileName)+": "+test.expected)
+		})
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	system := CreateSystemInstance()
+	system.registry = initRegistry()
+
+	config, err := parseConfig([]byte("display: sci\ndigits: 2\nangle: grad\naliases:\n  sq: sqrt\n"), configFileName)
+	assert.NoError(t, err)
+	assert.NoError(t, config.apply(system))
+	assert.Equal(t, DISPLAY_SCI, system.Modes().display)
+	assert.Equal(t, 2, system.Modes().digits)
+	assert.Equal(t, ANGLE_GRAD, system.Modes().angle)
+	assert.True(t, system.Registry().ContainsOpCode("sq"))
+	assert.Equal(t, "sqrt", system.Registry().GetAction("sq").OpCode())
+
+	system = CreateSystemInstance()
+	system.registry = initRegistry()
+	config, err = parseConfig([]byte("stackLevels: 4\n"), configFileName)
+	assert.NoError(t, err)
+	assert.NoError(t, config.apply(system))
+	assert.Equal(t, DISPLAY_STD, system.Modes().display, "the modes are kept when not configured")
+	assert.Equal(t, ANGLE_RAD, system.Modes().angle)
+}
+
+func TestConfigApplyAliasErrors(t *testing.T) {
+	tests := []struct {
+		aliases  map[string]string
+		expected string
+	}{
+		{map[string]string{"sq": "square"}, "rcalc.yaml: aliases: alias sq of unknown operation square"},
+		{map[string]string{"dup": "drop"}, "rcalc.yaml: aliases: alias dup is already an operation"},
+		{map[string]string{"s q": "sqrt"}, "rcalc.yaml: aliases: invalid alias 's q', an alias is a name like sq or ->deg"},
+		{map[string]string{"if": "sqrt"}, "rcalc.yaml: aliases: invalid alias 'if', if is a keyword"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			system := CreateSystemInstance()
+			system.registry = initRegistry()
+			config := defaultConfig(configFileName)
+			config.Aliases = test.aliases
+			assert.EqualError(t, config.apply(system), test.expected)
+		})
+	}
+}
+
+func TestConfigStartup(t *testing.T) {
+	system := CreateSystemInstance()
+	stack := CreateStack()
+	config := defaultConfig(configFileName)
+	config.Startup = "<<\n  dup *\n>> 'SQ' sto\n"
+	if assert.NoError(t, config.runStartup(system, stack)) {
+		assert.NoError(t, RunCommandLine(system, stack, "3 SQ"))
+		assert.Equal(t, []string{"9"}, stackValues(stack), "the programs of the startup stay in memory")
+	}
+
+	config.Startup = "4\n0 /"
+	err := config.runStartup(system, stack)
+	assert.EqualError(t, err, "rcalc.yaml startup:2:3: / failed: division by zero")
+	assert.Equal(t, []string{"9"}, stackValues(stack), "the startup program is rolled back")
+}
@@ -0,0 +1,239 @@
+package rcalc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	parser "troisdizaines.com/rcalc/rcalc/parser"
+)
+
+// Diagnostic an error located in a parsed text, lines start at 1 and columns at 0 like in antlr.
+// A diagnostic without line is not located.
+type Diagnostic struct {
+	Line    int
+	Column  int
+	Length  int
+	Message string
+}
+
+// format displays the message followed by the faulty line and a caret under the located text.
+// The position starts with the name of the source when there is one, like a file name.
+func (d Diagnostic) format(sourceName string, sourceLines []string) string {
+	if d.Line < 1 || d.Line > len(sourceLines) {
+		if sourceName != "" {
+			return fmt.Sprintf("%s: %s", sourceName, d.Message)
+		}
+		return d.Message
+	}
+	line := sourceLines[d.Line-1]
+	var caret strings.Builder
+	for idx, r := range []rune(line) {
+		if idx >= d.Column {
+			break
+		}
+		// tabs are kept to stay aligned with the displayed line
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteString(strings.Repeat("^", max(d.Length, 1)))
+	position := fmt.Sprintf("line %d, column %d", d.Line, d.Column+1)
+	if sourceName != "" {
+		position = fmt.Sprintf("%s:%d:%d", sourceName, d.Line, d.Column+1)
+	}
+	return fmt.Sprintf("%s: %s\n  %s\n  %s", position, d.Message, line, caret.String())
+}
+
+// ParseError errors found while parsing a text
+type ParseError struct {
+	source      string
+	sourceName  string
+	Diagnostics []Diagnostic
+}
+
+func (pe *ParseError) Error() string {
+	sourceLines := strings.Split(pe.source, "\n")
+	messages := make([]string, len(pe.Diagnostics))
+	for idx, diagnostic := range pe.Diagnostics {
+		messages[idx] = diagnostic.format(pe.sourceName, sourceLines)
+	}
+	return strings.Join(messages, "\n")
+}
+
+// shiftLines locates the diagnostics of a text which was the given number of lines after the start
+// of a bigger text
+func (pe *ParseError) shiftLines(offset int) *ParseError {
+	diagnostics := make([]Diagnostic, len(pe.Diagnostics))
+	for idx, diagnostic := range pe.Diagnostics {
+		if diagnostic.Line > 0 {
+			diagnostic.Line += offset
+		}
+		diagnostics[idx] = diagnostic
+	}
+	return &ParseError{source: strings.Repeat("\n", offset) + pe.source, sourceName: pe.sourceName, Diagnostics: diagnostics}
+}
+
+// sourceToken the part of a lexer token needed to locate a diagnostic
+type sourceToken struct {
+	tokenType int
+	text      string
+	line      int
+	column    int
+}
+
+func toSourceToken(token antlr.Token) sourceToken {
+	return sourceToken{
+		tokenType: token.GetTokenType(),
+		text:      token.GetText(),
+		line:      token.GetLine(),
+		column:    token.GetColumn(),
+	}
+}
+
+func (t sourceToken) diagnostic(message string) Diagnostic {
+	return Diagnostic{Line: t.line, Column: t.column, Length: len([]rune(t.text)), Message: message}
+}
+
+// closingTokens tokens closing each opening token, with the text shown in the diagnostics
+var closingTokens = map[int]struct {
+	types []int
+	text  string
+}{
+	parser.RcalcLexerPROG_OPEN:    {[]int{parser.RcalcLexerPROG_CLOSE}, ">>"},
+	parser.RcalcLexerCURLY_OPEN:   {[]int{parser.RcalcLexerCURLY_CLOSE}, "}"},
+	parser.RcalcLexerBRACKET_OPEN: {[]int{parser.RcalcLexerBRACKET_CLOSE}, "]"},
+	parser.RcalcLexerPAREN_OPEN:   {[]int{parser.RcalcLexerPAREN_CLOSE}, ")"},
+	parser.RcalcLexerQUOTE:        {[]int{parser.RcalcLexerQUOTE}, "'"},
+	parser.RcalcLexerKW_IF:        {[]int{parser.RcalcLexerKW_END}, "end"},
+	parser.RcalcLexerKW_IFERR:     {[]int{parser.RcalcLexerKW_END}, "end"},
+	parser.RcalcLexerKW_CASE:      {[]int{parser.RcalcLexerKW_END}, "end"},
+	parser.RcalcLexerKW_THEN:      {[]int{parser.RcalcLexerKW_END}, "end"},
+	parser.RcalcLexerKW_WHILE:     {[]int{parser.RcalcLexerKW_END}, "end"},
+	parser.RcalcLexerKW_DO:        {[]int{parser.RcalcLexerKW_END}, "end"},
+	parser.RcalcLexerKW_START:     {[]int{parser.RcalcLexerKW_NEXT, parser.RcalcLexerKW_STEP}, "next or step"},
+	parser.RcalcLexerKW_FOR:       {[]int{parser.RcalcLexerKW_NEXT, parser.RcalcLexerKW_STEP}, "next or step"},
+}
+
+func isClosingToken(tokenType int) bool {
+	for _, closing := range closingTokens {
+		for _, closingType := range closing.types {
+			if closingType == tokenType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func closes(opening sourceToken, tokenType int) bool {
+	for _, closingType := range closingTokens[opening.tokenType].types {
+		if closingType == tokenType {
+			return true
+		}
+	}
+	return false
+}
+
+// delimiterDiagnostics reports the unbalanced delimiters of a text, like an unclosed << or an end
+// without if. They explain most syntax errors better than the messages of antlr.
+func delimiterDiagnostics(tokens []sourceToken) []Diagnostic {
+	var diagnostics []Diagnostic
+	var opened []sourceToken
+	for _, token := range tokens {
+		switch {
+		case token.tokenType == parser.RcalcLexerDQUOTE:
+			diagnostics = append(diagnostics, token.diagnostic(`unclosed string, missing "`))
+		case token.tokenType == parser.RcalcLexerKW_THEN:
+			// only the clauses of case are closed by an end, the then of if and iferr are not
+			if len(opened) > 0 && opened[len(opened)-1].tokenType == parser.RcalcLexerKW_CASE {
+				opened = append(opened, token)
+			}
+		case token.tokenType == parser.RcalcLexerQUOTE:
+			// the same token opens and closes algebraic expressions
+			if len(opened) > 0 && opened[len(opened)-1].tokenType == parser.RcalcLexerQUOTE {
+				opened = opened[:len(opened)-1]
+			} else {
+				opened = append(opened, token)
+			}
+		case isClosingToken(token.tokenType):
+			// the delimiters left open before this closing one are reported
+			for len(opened) > 0 && !closes(opened[len(opened)-1], token.tokenType) {
+				unclosed := opened[len(opened)-1]
+				diagnostics = append(diagnostics, unclosed.diagnostic(
+					fmt.Sprintf("unclosed %s, missing %s before %s", unclosed.text, closingTokens[unclosed.tokenType].text, token.text)))
+				opened = opened[:len(opened)-1]
+			}
+			if len(opened) == 0 {
+				diagnostics = append(diagnostics, token.diagnostic(fmt.Sprintf("unexpected %s, nothing to close", token.text)))
+			} else {
+				opened = opened[:len(opened)-1]
+			}
+		default:
+			if _, isOpening := closingTokens[token.tokenType]; isOpening {
+				opened = append(opened, token)
+			}
+		}
+	}
+	for _, unclosed := range opened {
+		diagnostics = append(diagnostics, unclosed.diagnostic(
+			fmt.Sprintf("unclosed %s, missing %s", unclosed.text, closingTokens[unclosed.tokenType].text)))
+	}
+	return diagnostics
+}
+
+// validationDiagnostic locates a validation error on the text of its parse rule
+func validationDiagnostic(validationError ValidationError) Diagnostic {
+	start, stop := validationError.location.start, validationError.location.stop
+	if start == nil {
+		return Diagnostic{Message: validationError.String()}
+	}
+	length := len([]rune(start.GetText()))
+	if stop != nil && stop.GetLine() == start.GetLine() {
+		length = stop.GetStop() - start.GetStart() + 1
+	}
+	return Diagnostic{Line: start.GetLine(), Column: start.GetColumn(), Length: length, Message: validationError.String()}
+}
+
+// suggestName finds the candidate closest to a mistyped name, "" when no candidate is close enough
+func suggestName(name string, candidates []string) string {
+	lowerName := strings.ToLower(name)
+	best := ""
+	bestDistance := 0
+	for _, candidate := range candidates {
+		distance := editDistance(lowerName, strings.ToLower(candidate))
+		// 2 typos at most, and not a full rewrite of short names
+		if distance > 2 || distance >= len([]rune(name)) {
+			continue
+		}
+		if best == "" || distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// editDistance Levenshtein distance between 2 strings
+func editDistance(s1 string, s2 string) int {
+	r1, r2 := []rune(s1), []rune(s2)
+	previous := make([]int, len(r2)+1)
+	current := make([]int, len(r2)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		current[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(r2)]
+}
@@ -0,0 +1,76 @@
+package rcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	parser "troisdizaines.com/rcalc/rcalc/parser"
+)
+
+func TestDiagnosticFormat(t *testing.T) {
+	parseError := &ParseError{
+		source: "1 2\n\t<< dup fooo",
+		Diagnostics: []Diagnostic{
+			{Line: 2, Column: 1, Length: 2, Message: "unclosed <<, missing >>"},
+			{Message: "not located"},
+		},
+	}
+	assert.EqualError(t, parseError, "line 2, column 2: unclosed <<, missing >>\n  \t<< dup fooo\n  \t^^\nnot located")
+
+	parseError.sourceName = "area.rcalc"
+	assert.EqualError(t, parseError, "area.rcalc:2:2: unclosed <<, missing >>\n  \t<< dup fooo\n  \t^^\narea.rcalc: not located")
+}
+
+func TestDelimiterDiagnostics(t *testing.T) {
+	token := func(tokenType int, text string, column int) sourceToken {
+		return sourceToken{tokenType: tokenType, text: text, line: 1, column: column}
+	}
+	progOpen := token(parser.RcalcLexerPROG_OPEN, "<<", 0)
+	tests := []struct {
+		name     string
+		tokens   []sourceToken
+		expected []Diagnostic
+	}{
+		{"balanced", []sourceToken{
+			progOpen,
+			token(parser.RcalcLexerKW_CASE, "case", 3),
+			token(parser.RcalcLexerKW_THEN, "then", 8),
+			token(parser.RcalcLexerKW_END, "end", 13),
+			token(parser.RcalcLexerKW_END, "end", 17),
+			token(parser.RcalcLexerQUOTE, "'", 21),
+			token(parser.RcalcLexerQUOTE, "'", 23),
+			token(parser.RcalcLexerPROG_CLOSE, ">>", 25),
+		}, nil},
+		{"unclosed program", []sourceToken{
+			progOpen,
+			token(parser.RcalcLexerKW_IF, "if", 3),
+			token(parser.RcalcLexerKW_THEN, "then", 6),
+			token(parser.RcalcLexerKW_END, "end", 11),
+		}, []Diagnostic{{Line: 1, Column: 0, Length: 2, Message: "unclosed <<, missing >>"}}},
+		{"unclosed before closing", []sourceToken{
+			progOpen,
+			token(parser.RcalcLexerCURLY_OPEN, "{", 3),
+			token(parser.RcalcLexerPROG_CLOSE, ">>", 5),
+		}, []Diagnostic{{Line: 1, Column: 3, Length: 1, Message: "unclosed {, missing } before >>"}}},
+		{"nothing to close", []sourceToken{
+			token(parser.RcalcLexerKW_NEXT, "next", 2),
+		}, []Diagnostic{{Line: 1, Column: 2, Length: 4, Message: "unexpected next, nothing to close"}}},
+		{"unclosed string", []sourceToken{
+			token(parser.RcalcLexerDQUOTE, "\"", 4),
+		}, []Diagnostic{{Line: 1, Column: 4, Length: 1, Message: "unclosed string, missing \""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, delimiterDiagnostics(tt.tokens))
+		})
+	}
+}
+
+func TestSuggestName(t *testing.T) {
+	candidates := []string{"floor", "dup", "drop", "swap", "VELOCITY"}
+	assert.Equal(t, "floor", suggestName("fooo", candidates))
+	assert.Equal(t, "swap", suggestName("SWAP", candidates))
+	assert.Equal(t, "VELOCITY", suggestName("velocty", candidates))
+	assert.Equal(t, "", suggestName("xyz", candidates))
+	assert.Equal(t, "", suggestName("d", candidates), "short names must not match everything")
+}
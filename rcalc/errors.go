@@ -0,0 +1,164 @@
+package rcalc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error numbers, user errors raised by doerr with a message use ERR_USER
+const (
+	ERR_NONE              = 0
+	ERR_ACTION_FAILED     = 1
+	ERR_TOO_FEW_ARGUMENTS = 2
+	ERR_BAD_ARGUMENT_TYPE = 3
+	ERR_DIVISION_BY_ZERO  = 4
+	ERR_UNDEFINED_NAME    = 5
+	ERR_USER              = 100
+)
+
+var errorMessages = map[int]string{
+	ERR_ACTION_FAILED:     "action failed",
+	ERR_TOO_FEW_ARGUMENTS: "too few arguments",
+	ERR_BAD_ARGUMENT_TYPE: "bad argument type",
+	ERR_DIVISION_BY_ZERO:  "division by zero",
+	ERR_UNDEFINED_NAME:    "undefined name",
+}
+
+// RcalcError an error with a number, programs trap them with iferr and read them with errn and errm.
+// The message read by errm is the one of the failing action, the error of a wrapped cause tells
+// where it failed.
+type RcalcError struct {
+	number  int
+	message string
+	cause   error
+}
+
+func (e *RcalcError) Error() string {
+	if e.cause != nil {
+		return e.cause.Error()
+	}
+	return e.message
+}
+
+func (e *RcalcError) Unwrap() error {
+	return e.cause
+}
+
+func (e *RcalcError) Number() int {
+	return e.number
+}
+
+func NewRcalcError(number int, message string) *RcalcError {
+	return &RcalcError{number: number, message: message}
+}
+
+// newErrorFromNumber creates an error with the standard message of its number
+func newErrorFromNumber(number int) *RcalcError {
+	message, ok := errorMessages[number]
+	if !ok {
+		message = fmt.Sprintf("error %d", number)
+	}
+	return NewRcalcError(number, message)
+}
+
+var errDivisionByZero = newErrorFromNumber(ERR_DIVISION_BY_ZERO)
+
+// withErrorNumber gives a number to an error returned by an action. An error wrapping a numbered
+// error keeps its number and its message, the other errors get defaultNumber
+func withErrorNumber(err error, defaultNumber int) *RcalcError {
+	var rcalcErr *RcalcError
+	if errors.As(err, &rcalcErr) {
+		if rcalcErr == err {
+			return rcalcErr
+		}
+		return &RcalcError{number: rcalcErr.number, message: rcalcErr.message, cause: err}
+	}
+	return &RcalcError{number: defaultNumber, message: err.Error(), cause: err}
+}
+
+// ErrorNumber number of an error, ERR_ACTION_FAILED when the error has no number
+func ErrorNumber(err error) int {
+	if err == nil {
+		return ERR_NONE
+	}
+	return withErrorNumber(err, ERR_ACTION_FAILED).number
+}
+
+var doerrAct = NewActionDesc("doerr", 1, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	switch elts[0].getType() {
+	case TYPE_STR:
+		_, _ = stack.Pop()
+		return NewRcalcError(ERR_USER, elts[0].asStringVar().value)
+	case TYPE_NUMERIC:
+		number := elts[0].asNumericVar().value
+		if !number.IsInteger() || number.Sign() <= 0 {
+			return fmt.Errorf("doerr expects a positive integer error number, found: %v", number)
+		}
+		_, _ = stack.Pop()
+		return newErrorFromNumber(int(number.IntPart()))
+	default:
+		return fmt.Errorf("doerr expects an error number or a message, found: %v", elts[0].display(system.Modes()))
+	}
+})
+
+var errmAct = NewActionDesc("errm", 0, CheckNoop, func(system System, stack *Stack) error {
+	message := ""
+	if lastError := system.LastError(); lastError != nil {
+		message = lastError.message
+	}
+	stack.Push(CreateStringVariable(message))
+	return nil
+})
+
+var errnAct = NewActionDesc("errn", 0, CheckNoop, func(system System, stack *Stack) error {
+	number := ERR_NONE
+	if lastError := system.LastError(); lastError != nil {
+		number = lastError.number
+	}
+	stack.Push(CreateNumericVariableFromInt(number))
+	return nil
+})
+
+var err0Act = NewActionDesc("err0", 0, CheckNoop, func(system System, stack *Stack) error {
+	system.setLastError(nil)
+	return nil
+})
+
+var ErrorPackage = ActionPackage{
+	name: "errors",
+	staticActions: []Action{
+		&doerrAct,
+		&errmAct,
+		&errnAct,
+		&err0Act,
+	},
+	docs: map[string]ActionDoc{
+		"doerr": {
+			Summary: "raises an error with a message or a standard error number",
+			Stack:   "message ->",
+			Types:   "a string or a positive integer",
+		},
+		"errm": {
+			Summary:  "pushes the message of the last error",
+			Stack:    "-> message",
+			Types:    "none",
+			Examples: []DocExample{{"err0 errm", "\"\""}},
+		},
+		"errn": {
+			Summary:  "pushes the number of the last error, 0 if none",
+			Stack:    "-> n",
+			Types:    "none",
+			Examples: []DocExample{{"err0 errn", "0"}},
+		},
+		"err0": {
+			Summary:  "clears the last error",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"err0 errn", "0"}},
+		},
+	},
+}
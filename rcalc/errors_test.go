@@ -0,0 +1,155 @@
+package rcalc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorNumbers(t *testing.T) {
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), CreateStack())
+	assert.Equal(t, ERR_TOO_FEW_ARGUMENTS, ErrorNumber(runtimeContext.RunAction(&addOp)))
+
+	runtimeContext.stack.Push(CreateStringVariable("a"))
+	runtimeContext.stack.Push(CreateBooleanVariable(true))
+	assert.Equal(t, ERR_BAD_ARGUMENT_TYPE, ErrorNumber(runtimeContext.RunAction(&mulOp)))
+
+	runtimeContext.stack.Push(CreateNumericVariableFromInt(1))
+	runtimeContext.stack.Push(CreateNumericVariableFromInt(0))
+	err := runtimeContext.RunAction(&divOp)
+	assert.Equal(t, ERR_DIVISION_BY_ZERO, ErrorNumber(err))
+	assert.EqualError(t, err, "division by zero")
+
+	wrapped := withErrorNumber(fmt.Errorf("cannot update X: %w", errDivisionByZero), ERR_ACTION_FAILED)
+	assert.Equal(t, ERR_DIVISION_BY_ZERO, wrapped.Number())
+	assert.EqualError(t, wrapped, "cannot update X: division by zero")
+	assert.Equal(t, ERR_ACTION_FAILED, ErrorNumber(fmt.Errorf("failed")))
+	assert.Equal(t, ERR_NONE, ErrorNumber(nil))
+}
+
+func TestIfErrAction(t *testing.T) {
+	tests := []struct {
+		name          string
+		action        *IfErrActionDesc
+		expectedStack []string
+	}{
+		{"error trapped", &IfErrActionDesc{
+			trapActions: []Action{pushAction(5), pushAction(0), &divOp},
+			thenActions: []Action{&errnAct, &errmAct},
+			elseActions: []Action{pushAction(-1)},
+		}, []string{"1", "4", "\"division by zero\""}},
+		{"no error", &IfErrActionDesc{
+			trapActions: []Action{pushAction(6), pushAction(3), &divOp},
+			thenActions: []Action{pushAction(-1)},
+			elseActions: []Action{pushAction(0)},
+		}, []string{"1", "2", "0"}},
+		{"user error", &IfErrActionDesc{
+			trapActions: []Action{pushVar(CreateStringVariable("no root")), &doerrAct},
+			thenActions: []Action{&errnAct, &errmAct},
+		}, []string{"1", "100", "\"no root\""}},
+		{"numbered user error", &IfErrActionDesc{
+			trapActions: []Action{pushAction(3), &doerrAct},
+			thenActions: []Action{&errnAct, &errmAct},
+		}, []string{"1", "3", "\"bad argument type\""}},
+		{"error in a program", &IfErrActionDesc{
+			trapActions: []Action{&EvalFromArgActionDesc{variable: CreateProgramVariable([]Action{pushAction(0), &divOp})}},
+			thenActions: []Action{&errnAct, &errmAct},
+		}, []string{"1", "4", "\"division by zero\""}},
+		{"nested", &IfErrActionDesc{
+			trapActions: []Action{&IfErrActionDesc{
+				trapActions: []Action{&dropOp, &dropOp},
+				thenActions: []Action{pushVar(CreateStringVariable("inner")), &doerrAct},
+			}},
+			thenActions: []Action{&errmAct},
+		}, []string{"1", "\"inner\""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := CreateStack()
+			stack.Push(CreateNumericVariableFromInt(1))
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			if assert.NoError(t, runtimeContext.RunAction(tt.action)) {
+				assert.Equal(t, tt.expectedStack, stackValues(stack))
+			}
+		})
+	}
+}
+
+func TestErrorInThenActionsIsNotTrapped(t *testing.T) {
+	action := &IfErrActionDesc{
+		trapActions: []Action{&dropOp},
+		thenActions: []Action{&dropOp},
+	}
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), CreateStack())
+	err := runtimeContext.RunAction(action)
+	assert.Equal(t, ERR_TOO_FEW_ARGUMENTS, ErrorNumber(err))
+}
+
+func TestDoerrArguments(t *testing.T) {
+	tests := []struct {
+		value          Variable
+		expectedErrMsg string
+	}{
+		{CreateNumericVariableFromInt(0), "doerr expects a positive integer error number, found: 0"},
+		{CreateBooleanVariable(true), "doerr expects an error number or a message, found: true"},
+	}
+	for _, tt := range tests {
+		stack := CreateStack()
+		stack.Push(tt.value)
+		runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+		assert.EqualError(t, runtimeContext.RunAction(&doerrAct), tt.expectedErrMsg)
+		assert.Equal(t, 1, stack.Size(), "the argument must be kept on error")
+	}
+}
+
+func TestLastErrorOfCommandLine(t *testing.T) {
+	system := CreateSystemInstance()
+	stack := CreateStack()
+	err := RunActionsInTransaction(system, stack, []Action{pushAction(1), pushAction(0), &divOp})
+	assert.Equal(t, ERR_DIVISION_BY_ZERO, ErrorNumber(err))
+	assert.Equal(t, 0, stack.Size())
+
+	assert.NoError(t, RunActionsInTransaction(system, stack, []Action{&errnAct, &errmAct, &err0Act, &errnAct, &errmAct}))
+	assert.Equal(t, []string{"4", "\"division by zero\"", "0", "\"\""}, stackValues(stack))
+	assert.Nil(t, system.LastError())
+}
+
+func TestLastErrorOfProgram(t *testing.T) {
+	system := CreateSystemInstance()
+	stack := CreateStack()
+	program := CreateProgramVariable([]Action{pushAction(1), pushAction(0), &divOp})
+	err := RunActionsInTransaction(system, stack, []Action{&EvalFromArgActionDesc{variable: program}})
+	assert.ErrorContains(t, err, "action 3 (/) of")
+	assert.ErrorContains(t, err, "failed: division by zero")
+	if assert.NotNil(t, system.LastError()) {
+		assert.Equal(t, "division by zero", system.LastError().message)
+	}
+}
+
+func TestUndefinedNameSuggestion(t *testing.T) {
+	system := CreateSystemInstance()
+	_, err := system.Memory().createVariable("RADIUS", system.Memory().getRoot(), CreateNumericVariableFromInt(2))
+	assert.NoError(t, err)
+	runtimeContext := CreateRuntimeContext(system, CreateStack())
+
+	err = runtimeContext.RunAction(&VariableEvaluationActionDesc{varName: "RADUIS"})
+	assert.EqualError(t, err, "unknown command or variable RADUIS, did you mean RADIUS?")
+	assert.Equal(t, ERR_UNDEFINED_NAME, ErrorNumber(err))
+
+	err = runtimeContext.RunAction(&VariableEvaluationActionDesc{varName: "QWERTY"})
+	assert.EqualError(t, err, "unknown command or variable QWERTY")
+}
+
+func TestErrorsOfNestedPrograms(t *testing.T) {
+	system := CreateSystemInstance()
+	root := system.Memory().getRoot()
+	_, err := system.Memory().createVariable("INV", root, CreateProgramVariable([]Action{pushAction(1), &swapOp, &divOp}))
+	assert.NoError(t, err)
+	_, err = system.Memory().createVariable("MAIN", root, CreateProgramVariable([]Action{pushAction(0), &VariableEvaluationActionDesc{varName: "INV"}}))
+	assert.NoError(t, err)
+
+	err = RunActionsInTransaction(system, CreateStack(), []Action{&VariableEvaluationActionDesc{varName: "MAIN"}})
+	assert.EqualError(t, err, "action 1 (MAIN) failed: action 2 (INV) of MAIN failed: action 3 (/) of INV failed: division by zero")
+	assert.Equal(t, ERR_DIVISION_BY_ZERO, ErrorNumber(err))
+}
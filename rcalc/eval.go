@@ -0,0 +1,92 @@
+package rcalc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EvalOptions options of the evaluations without user interface
+type EvalOptions struct {
+	// Ephemeral starts with an empty stack and saves nothing, the memory and the modes are still read
+	Ephemeral bool
+	// TopOnly prints only the level 1 of the resulting stack
+	TopOnly bool
+}
+
+// Eval runs a command line without user interface and prints the resulting stack on out
+func Eval(stackDataFolder string, debugMode bool, options EvalOptions, cmds string, out io.Writer) error {
+	return evalInSession(stackDataFolder, debugMode, options, out, func(system *SystemInstance, stack *Stack) error {
+		return RunCommandLine(system, stack, cmds)
+	})
+}
+
+// EvalInput runs the command lines read from input, like a pipe, and prints the resulting stack on out
+func EvalInput(stackDataFolder string, debugMode bool, options EvalOptions, input io.Reader, out io.Writer) error {
+	return evalInSession(stackDataFolder, debugMode, options, out, func(system *SystemInstance, stack *Stack) error {
+		return EvalLines(system, stack, input)
+	})
+}
+
+func evalInSession(stackDataFolder string, debugMode bool, options EvalOptions, out io.Writer, run func(system *SystemInstance, stack *Stack) error) error {
+	if _, err := prepareDataFolder(stackDataFolder, true, debugMode); err != nil {
+		return err
+	}
+	config, err := LoadConfig(stackDataFolder)
+	if err != nil {
+		return err
+	}
+	system, stack, err := loadConfiguredSession(stackDataFolder, options.Ephemeral, config)
+	if err != nil {
+		return err
+	}
+	if err := run(system, stack); err != nil {
+		return err
+	}
+	return PrintStack(out, stack, system.Modes(), options.TopOnly)
+}
+
+// EvalLines runs the lines of input as command lines until the end of input, quit or the first error.
+// Blank lines are skipped.
+func EvalLines(system *SystemInstance, stack *Stack, input io.Reader) error {
+	scanner := bufio.NewScanner(input)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := RunCommandLine(system, stack, line); err != nil {
+			var parseError *ParseError
+			if errors.As(err, &parseError) {
+				return parseError.shiftLines(lineNumber - 1)
+			}
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if system.shouldStop() {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+// PrintStack prints the values of the stack with the modes, one per line from the highest level to level 1
+func PrintStack(out io.Writer, stack StackReader, modes *Modes, topOnly bool) error {
+	firstLevel := stack.Size() - 1
+	if topOnly {
+		firstLevel = min(firstLevel, 0)
+	}
+	for level := firstLevel; level >= 0; level-- {
+		elt, err := stack.Get(level)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(out, elt.display(modes)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package rcalc
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintStack(t *testing.T) {
+	stack := CreateStack()
+	stack.Push(CreateNumericVariableFromInt(1))
+	stack.Push(CreateStringVariable("two"))
+
+	var buffer bytes.Buffer
+	assert.NoError(t, PrintStack(&buffer, stack, NewModes(), false))
+	assert.Equal(t, "1\n\"two\"\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, PrintStack(&buffer, stack, NewModes(), true))
+	assert.Equal(t, "\"two\"\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, PrintStack(&buffer, CreateStack(), NewModes(), true))
+	assert.Empty(t, buffer.String())
+}
+
+func TestEvalLines(t *testing.T) {
+	system := CreateSystemInstance()
+	stack := CreateStack()
+	assert.NoError(t, EvalLines(system, stack, strings.NewReader("1 2 +\n\n3 *\n")))
+	assert.Equal(t, []string{"9"}, stackValues(stack))
+
+	err := EvalLines(system, stack, strings.NewReader("1\n0 /\n4"))
+	assert.EqualError(t, err, "line 2: action 2 (/) failed: division by zero")
+	assert.Equal(t, []string{"9", "1"}, stackValues(stack), "lines before the error are kept")
+
+	err = EvalLines(system, stack, strings.NewReader("1\n<< 2"))
+	var parseError *ParseError
+	if assert.ErrorAs(t, err, &parseError) {
+		assert.Equal(t, 2, parseError.Diagnostics[0].Line)
+	}
+}
+
+func TestParseErrorShiftLines(t *testing.T) {
+	parseError := &ParseError{
+		source:      "1 >>",
+		Diagnostics: []Diagnostic{{Line: 1, Column: 2, Length: 2, Message: "unexpected >>, nothing to close"}, {Message: "not located"}},
+	}
+	assert.EqualError(t, parseError.shiftLines(2), "line 3, column 3: unexpected >>, nothing to close\n  1 >>\n    ^^\nnot located")
+}
+
+func TestEphemeralSessionSavesNothing(t *testing.T) {
+	folder := t.TempDir()
+	system, stack, err := loadSession(folder, true)
+	assert.NoError(t, err)
+	assert.NoError(t, RunActionsInTransaction(system, stack, []Action{pushAction(1)}))
+
+	entries, err := os.ReadDir(folder)
+	if assert.NoError(t, err) {
+		assert.Empty(t, entries)
+	}
+	_, err = os.Stat(path.Join(folder, "stack.protobuf"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,11 +1,14 @@
 package rcalc
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
 	"github.com/shopspring/decimal"
+	"gonum.org/v1/gonum/mat"
 	parser "troisdizaines.com/rcalc/rcalc/parser"
 )
 
@@ -38,10 +41,10 @@ func parserNumber(txt string) (Variable, error) {
 	return CreateNumericVariable(number), nil
 }
 
+// parseAction finds the action called by txt, an op code or an alias in any case
 func parseAction(txt string, registry *ActionRegistry) (Action, error) {
-	lowerTxt := strings.ToLower(txt)
-	if registry.ContainsOpCode(lowerTxt) {
-		return registry.GetAction(txt), nil
+	if action := registry.GetAction(txt); action != nil {
+		return action, nil
 	} else {
 		return nil, fmt.Errorf("unknown action")
 	}
@@ -67,14 +70,6 @@ func (ve *ValidationError) String() string {
 	return ve.err.Error()
 }
 
-func toErrorMessage(validationErrors []ValidationError) string {
-	var errorsAsString []string
-	for _, validationError := range validationErrors {
-		errorsAsString = append(errorsAsString, " - "+validationError.String())
-	}
-	return strings.Join(errorsAsString, "\n")
-}
-
 type ParseContext[T any] interface {
 	GetParent() ParseContext[T]
 	SetParent(ctx ParseContext[T])
@@ -219,6 +214,71 @@ func (i *IfThenElseContext) CreateFinalItem() ([]Action, error) {
 	}, nil
 }
 
+// IfErrContext same structure as if then else with trap actions instead of a condition
+type IfErrContext struct {
+	IfThenElseContext
+}
+
+var _ ParseContext[Action] = (*IfErrContext)(nil)
+
+func (i *IfErrContext) CreateFinalItem() ([]Action, error) {
+	return []Action{
+		&IfErrActionDesc{
+			trapActions: toNonLocated(i.actions[0]),
+			thenActions: toNonLocated(i.actions[1]),
+			elseActions: toNonLocated(i.actions[2]),
+		},
+	}, nil
+}
+
+// CaseContext collects the clauses of a case structure: the actions before then are the
+// condition of a clause, the actions before its end are its body and the actions before the
+// last end are the default ones
+type CaseContext struct {
+	BaseParseContext[Action]
+
+	clauses        []CaseClause
+	currentActions []LocatedItem[Action]
+	conditionDone  bool
+	condition      []LocatedItem[Action]
+	defaultActions []LocatedItem[Action]
+}
+
+var _ ParseContext[Action] = (*CaseContext)(nil)
+
+func (c *CaseContext) AddItem(item LocatedItem[Action]) {
+	c.currentActions = append(c.currentActions, item)
+}
+
+func (c *CaseContext) TokenVisited(token int) {
+	switch token {
+	case parser.RcalcLexerKW_THEN:
+		c.condition = c.currentActions
+		c.currentActions = nil
+		c.conditionDone = true
+	case parser.RcalcLexerKW_END:
+		if c.conditionDone {
+			c.clauses = append(c.clauses, CaseClause{
+				conditionActions: toNonLocated(c.condition),
+				bodyActions:      toNonLocated(c.currentActions),
+			})
+			c.conditionDone = false
+		} else {
+			c.defaultActions = c.currentActions
+		}
+		c.currentActions = nil
+	}
+}
+
+func (c *CaseContext) CreateFinalItem() ([]Action, error) {
+	return []Action{
+		&CaseActionDesc{
+			clauses:        c.clauses,
+			defaultActions: toNonLocated(c.defaultActions),
+		},
+	}, nil
+}
+
 type StartEndLoopContext struct {
 	BaseParseContext[Action]
 }
@@ -247,6 +307,62 @@ func (pc *ForNextLoopContext) CreateFinalItem() ([]Action, error) {
 	}, nil
 }
 
+type StartStepLoopContext struct {
+	BaseParseContext[Action]
+}
+
+var _ ParseContext[Action] = (*StartStepLoopContext)(nil)
+
+func (pc *StartStepLoopContext) CreateFinalItem() ([]Action, error) {
+	return []Action{
+		&StartStepLoopActionDesc{actions: toNonLocated(pc.BaseParseContext.items)},
+	}, nil
+}
+
+type ForStepLoopContext struct {
+	BaseParseContext[Action]
+}
+
+var _ ParseContext[Action] = (*ForStepLoopContext)(nil)
+
+func (pc *ForStepLoopContext) CreateFinalItem() ([]Action, error) {
+	return []Action{
+		&ForStepLoopActionDesc{
+			varName: pc.BaseParseContext.idDeclarations[0].item,
+			actions: toNonLocated(pc.BaseParseContext.items),
+		},
+	}, nil
+}
+
+// ConditionLoopContext collects the two instruction sequences of while ... repeat ... end and
+// do ... until ... end loops, separatorToken switches from the first to the second one
+type ConditionLoopContext struct {
+	BaseParseContext[Action]
+
+	separatorToken int
+	actions        [2][]LocatedItem[Action]
+	currentAction  int
+	createLoop     func(firstActions []Action, secondActions []Action) Action
+}
+
+var _ ParseContext[Action] = (*ConditionLoopContext)(nil)
+
+func (c *ConditionLoopContext) AddItem(item LocatedItem[Action]) {
+	c.actions[c.currentAction] = append(c.actions[c.currentAction], item)
+}
+
+func (c *ConditionLoopContext) TokenVisited(token int) {
+	if token == c.separatorToken {
+		c.currentAction = 1
+	}
+}
+
+func (c *ConditionLoopContext) CreateFinalItem() ([]Action, error) {
+	return []Action{
+		c.createLoop(toNonLocated(c.actions[0]), toNonLocated(c.actions[1])),
+	}, nil
+}
+
 type ProgramContext struct {
 	BaseParseContext[Variable]
 	parseContextManager *ParseContextManager
@@ -409,7 +525,6 @@ func (l *ParseContextManager) TokenVisited(token int) {
 	if l.actionCtxStack.GetCurrent() != nil {
 		l.actionCtxStack.GetCurrent().TokenVisited(token)
 	}
-	l.actionCtxStack.GetCurrent().TokenVisited(token)
 	if l.variableCtxStack.GetCurrent() != nil {
 		l.variableCtxStack.GetCurrent().TokenVisited(token)
 	}
@@ -428,18 +543,32 @@ func (rac *RootContext[T]) CreateFinalItem() ([]T, error) {
 	return toNonLocated(rac.items), nil
 }
 
+// BackFromChild keeps the locations of the top level instructions, scripts report errors with them
+func (rac *RootContext[T]) BackFromChild(self ParseContext[T], child ParseContext[T]) {
+	if sequence, isSequence := any(child).(*InstructionSequenceParseContext); isSequence && len(child.GetValidationErrors()) == 0 {
+		if items, isLocated := any(sequence.items).([]LocatedItem[T]); isLocated {
+			rac.items = append(rac.items, items...)
+			return
+		}
+	}
+	rac.BaseParseContext.BackFromChild(self, child)
+}
+
 type RcalcParserListener struct {
 	*parser.BaseRcalcListener
 
-	registry       *ActionRegistry
+	registry *ActionRegistry
+	// modes binary integers without a base suffix are read in the base mode
+	modes          *Modes
 	contextManager *ParseContextManager
 }
 
 var _ parser.RcalcListener = (*RcalcParserListener)(nil)
 
-func CreateRcalcParserListener(registry *ActionRegistry) *RcalcParserListener {
+func CreateRcalcParserListener(registry *ActionRegistry, modes *Modes) *RcalcParserListener {
 	return &RcalcParserListener{
 		registry:       registry,
+		modes:          modes,
 		contextManager: CreateParseContextManager(registry),
 	}
 }
@@ -482,6 +611,21 @@ func (l *RcalcParserListener) ExitInstrOp(ctx *parser.InstrOpContext) {
 	}
 }
 
+// ExitEveryRule locates the actions of a structure, like a loop, on the text of its instruction.
+// The structures are added to their parent without location when they are exited, just before.
+func (l *RcalcParserListener) ExitEveryRule(ctx antlr.ParserRuleContext) {
+	if _, isInstr := ctx.(parser.IInstrContext); !isInstr {
+		return
+	}
+	sequence, isSequence := l.contextManager.actionCtxStack.GetCurrent().(*InstructionSequenceParseContext)
+	if !isSequence {
+		return
+	}
+	for idx := len(sequence.items) - 1; idx >= 0 && sequence.items[idx].start == nil; idx-- {
+		sequence.items[idx].Location = toLocation(ctx)
+	}
+}
+
 func (l *RcalcParserListener) VisitTerminal(node antlr.TerminalNode) {
 	l.contextManager.TokenVisited(node.GetSymbol().GetTokenType())
 	//fmt.Printf("VisitTerminal : #%s# / #%d#\n", node.GetSymbol().GetText(), node.GetSymbol().GetTokenType())
@@ -518,6 +662,26 @@ func (l *RcalcParserListener) ExitInstrIfThenElse(ctx *parser.InstrIfThenElseCon
 	l.contextManager.actionCtxStack.backToParentContext()
 }
 
+// EnterInstrIfErr is called when entering the InstrIfErr production.
+func (l *RcalcParserListener) EnterInstrIfErr(ctx *parser.InstrIfErrContext) {
+	l.contextManager.actionCtxStack.startNewSubContext(&IfErrContext{})
+}
+
+// ExitInstrIfErr is called when exiting the InstrIfErr production.
+func (l *RcalcParserListener) ExitInstrIfErr(ctx *parser.InstrIfErrContext) {
+	l.contextManager.actionCtxStack.backToParentContext()
+}
+
+// EnterInstrCase is called when entering the InstrCase production.
+func (l *RcalcParserListener) EnterInstrCase(ctx *parser.InstrCaseContext) {
+	l.contextManager.actionCtxStack.startNewSubContext(&CaseContext{})
+}
+
+// ExitInstrCase is called when exiting the InstrCase production.
+func (l *RcalcParserListener) ExitInstrCase(ctx *parser.InstrCaseContext) {
+	l.contextManager.actionCtxStack.backToParentContext()
+}
+
 // EnterInstrStartNextLoop is called when production InstrStartNextLoop is entered.
 func (l *RcalcParserListener) EnterInstrStartNextLoop(ctx *parser.InstrStartNextLoopContext) {
 	loopContext := &StartEndLoopContext{}
@@ -544,6 +708,61 @@ func (l *RcalcParserListener) ExitInstrForNextLoop(c *parser.InstrForNextLoopCon
 	l.contextManager.actionCtxStack.backToParentContext()
 }
 
+// EnterInstrStartStepLoop is called when production InstrStartStepLoop is entered.
+func (l *RcalcParserListener) EnterInstrStartStepLoop(ctx *parser.InstrStartStepLoopContext) {
+	l.contextManager.actionCtxStack.startNewSubContext(&StartStepLoopContext{})
+}
+
+// ExitInstrStartStepLoop is called when production InstrStartStepLoop is exited.
+func (l *RcalcParserListener) ExitInstrStartStepLoop(ctx *parser.InstrStartStepLoopContext) {
+	l.contextManager.actionCtxStack.backToParentContext()
+}
+
+// EnterInstrForStepLoop is called when production InstrForStepLoop is entered.
+func (l *RcalcParserListener) EnterInstrForStepLoop(ctx *parser.InstrForStepLoopContext) {
+	loopContext := &ForStepLoopContext{
+		BaseParseContext: BaseParseContext[Action]{
+			location: toLocation(ctx),
+		},
+	}
+	l.contextManager.actionCtxStack.startNewSubContext(loopContext)
+}
+
+// ExitInstrForStepLoop is called when production InstrForStepLoop is exited.
+func (l *RcalcParserListener) ExitInstrForStepLoop(ctx *parser.InstrForStepLoopContext) {
+	l.contextManager.actionCtxStack.backToParentContext()
+}
+
+// EnterInstrWhileRepeatLoop is called when production InstrWhileRepeatLoop is entered.
+func (l *RcalcParserListener) EnterInstrWhileRepeatLoop(ctx *parser.InstrWhileRepeatLoopContext) {
+	l.contextManager.actionCtxStack.startNewSubContext(&ConditionLoopContext{
+		separatorToken: parser.RcalcLexerKW_REPEAT,
+		createLoop: func(conditionActions []Action, bodyActions []Action) Action {
+			return &WhileRepeatLoopActionDesc{conditionActions: conditionActions, bodyActions: bodyActions}
+		},
+	})
+}
+
+// ExitInstrWhileRepeatLoop is called when production InstrWhileRepeatLoop is exited.
+func (l *RcalcParserListener) ExitInstrWhileRepeatLoop(ctx *parser.InstrWhileRepeatLoopContext) {
+	l.contextManager.actionCtxStack.backToParentContext()
+}
+
+// EnterInstrDoUntilLoop is called when production InstrDoUntilLoop is entered.
+func (l *RcalcParserListener) EnterInstrDoUntilLoop(ctx *parser.InstrDoUntilLoopContext) {
+	l.contextManager.actionCtxStack.startNewSubContext(&ConditionLoopContext{
+		separatorToken: parser.RcalcLexerKW_UNTIL,
+		createLoop: func(bodyActions []Action, conditionActions []Action) Action {
+			return &DoUntilLoopActionDesc{bodyActions: bodyActions, conditionActions: conditionActions}
+		},
+	})
+}
+
+// ExitInstrDoUntilLoop is called when production InstrDoUntilLoop is exited.
+func (l *RcalcParserListener) ExitInstrDoUntilLoop(ctx *parser.InstrDoUntilLoopContext) {
+	l.contextManager.actionCtxStack.backToParentContext()
+}
+
 /*********************************************************************************/
 /* Variables */
 /*********************************************************************************/
@@ -593,6 +812,126 @@ func (l *RcalcParserListener) ExitVariableNumber(ctx *parser.VariableNumberConte
 	}
 }
 
+// ExitVariableString is called when production VariableString is exited.
+func (l *RcalcParserListener) ExitVariableString(ctx *parser.VariableStringContext) {
+	str := CreateStringVariable(UnquoteString(ctx.GetText()))
+	l.contextManager.AddVariable(newLocatedItem[Variable](str, ctx.GetStart(), ctx.GetStop()))
+}
+
+// ExitVariableComplex is called when production VariableComplex is exited.
+func (l *RcalcParserListener) ExitVariableComplex(ctx *parser.VariableComplexContext) {
+	var parts []float64
+	for _, child := range ctx.GetChildren() {
+		complexCtx, ok := child.(*parser.ComplexContext)
+		if !ok {
+			continue
+		}
+		for _, complexChild := range complexCtx.GetChildren() {
+			if numberCtx, isNumber := complexChild.(*parser.NumberContext); isNumber {
+				number, err := decimal.NewFromString(numberCtx.GetText())
+				if err != nil {
+					l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), err)
+					return
+				}
+				parts = append(parts, number.InexactFloat64())
+			}
+		}
+	}
+	if len(parts) != 2 {
+		l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), fmt.Errorf("complex number must have 2 parts"))
+		return
+	}
+	c := CreateComplexVariable(complex(parts[0], parts[1]))
+	l.contextManager.AddVariable(newLocatedItem[Variable](c, ctx.GetStart(), ctx.GetStop()))
+}
+
+// ExitVariableRational is called when production VariableRational is exited.
+func (l *RcalcParserListener) ExitVariableRational(ctx *parser.VariableRationalContext) {
+	value, ok := new(big.Rat).SetString(strings.TrimPrefix(ctx.GetText(), "+"))
+	if !ok {
+		l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), fmt.Errorf("invalid rational number %s", ctx.GetText()))
+		return
+	}
+	l.contextManager.AddVariable(newLocatedItem(CreateExactNumberVariable(value), ctx.GetStart(), ctx.GetStop()))
+}
+
+// ExitVariableBinaryInteger is called when production VariableBinaryInteger is exited.
+func (l *RcalcParserListener) ExitVariableBinaryInteger(ctx *parser.VariableBinaryIntegerContext) {
+	value, err := ParseBinaryInteger(ctx.GetText(), l.modes.base)
+	if err != nil {
+		l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), err)
+		return
+	}
+	l.contextManager.AddVariable(newLocatedItem[Variable](CreateBinaryIntegerVariable(value), ctx.GetStart(), ctx.GetStop()))
+}
+
+// ExitVariableQuantity is called when production VariableQuantity is exited.
+func (l *RcalcParserListener) ExitVariableQuantity(ctx *parser.VariableQuantityContext) {
+	numberText, unitText, _ := strings.Cut(strings.TrimPrefix(ctx.GetText(), "+"), "_")
+	value, err := decimal.NewFromString(numberText)
+	if err != nil {
+		l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), err)
+		return
+	}
+	unit, err := ParseUnit(unitText)
+	if err != nil {
+		l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), err)
+		return
+	}
+	l.contextManager.AddVariable(newLocatedItem(CreateQuantityOrNumericVariable(value, unit), ctx.GetStart(), ctx.GetStop()))
+}
+
+// ExitVariableVector is called when production VariableVector is exited.
+func (l *RcalcParserListener) ExitVariableVector(ctx *parser.VariableVectorContext) {
+	for _, child := range ctx.GetChildren() {
+		if vectorCtx, ok := child.(*parser.VectorContext); ok {
+			vector, err := vectorFromParseTree(vectorCtx)
+			if err != nil {
+				l.contextManager.actionCtxStack.GetCurrent().ReportValidationError(toLocation(ctx), err)
+			} else {
+				l.contextManager.AddVariable(newLocatedItem(vector, ctx.GetStart(), ctx.GetStop()))
+			}
+		}
+	}
+}
+
+// vectorFromParseTree creates a vector from a vector of numbers or a matrix from a vector of row vectors
+func vectorFromParseTree(ctx *parser.VectorContext) (Variable, error) {
+	var values []float64
+	var rows [][]float64
+	for _, child := range ctx.GetChildren() {
+		switch childCtx := child.(type) {
+		case *parser.VectorContext:
+			row, err := vectorFromParseTree(childCtx)
+			if err != nil {
+				return nil, err
+			}
+			if row.getType() != TYPE_VECTOR {
+				return nil, fmt.Errorf("matrix rows must be vectors of numbers")
+			}
+			rows = append(rows, row.asVectorVar().values())
+		case *parser.NumberContext:
+			number, err := decimal.NewFromString(childCtx.GetText())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, number.InexactFloat64())
+		}
+	}
+	if rows == nil {
+		return CreateVectorVariableFromValues(values), nil
+	}
+	nbCols := len(rows[0])
+	matrixValues := make([]float64, 0, len(rows)*nbCols)
+	for idx, row := range rows {
+		if len(row) != nbCols {
+			return nil, fmt.Errorf("row %d of the matrix has %d values instead of %d", idx+1, len(row), nbCols)
+		}
+		matrixValues = append(matrixValues, row...)
+	}
+	return CreateMatrixVariable(mat.NewDense(len(rows), nbCols, matrixValues)), nil
+}
+
 type ParserProvider interface {
 	antlr.InterpreterRuleContext
 	GetParser() antlr.Parser
@@ -859,21 +1198,35 @@ func (l *RcalcParserListener) ExitStatementLocalVarAlgebraicExpression(c *parser
 /* Error Reporting */
 
 type RcalcParserErrorListener struct {
-	messages []string
+	diagnostics []Diagnostic
 }
 
 var _ antlr.ErrorListener = (*RcalcParserErrorListener)(nil)
 
 func (el *RcalcParserErrorListener) HasErrors() bool {
-	return len(el.messages) > 0
+	return len(el.diagnostics) > 0
 
 }
 
+// SyntaxError is called by the lexer and by the parser, the raw antlr message is only logged
 func (el *RcalcParserErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{}, line, column int, msg string, e antlr.RecognitionException) {
-	antlrParser := recognizer.(antlr.Parser)
-	stack := antlrParser.GetRuleInvocationStack(antlrParser.GetParserRuleContext())
-	message := fmt.Sprintf("SyntaxError (%d, %d) : %s with stack %v", line, column, msg, stack)
-	el.messages = append(el.messages, message)
+	if antlrParser, isParser := recognizer.(antlr.Parser); isParser {
+		stack := antlrParser.GetRuleInvocationStack(antlrParser.GetParserRuleContext())
+		GetLogger().Debugf("SyntaxError (%d, %d) : %s with stack %v", line, column, msg, stack)
+	}
+	diagnostic := Diagnostic{Line: line, Column: column, Length: 1}
+	token, isToken := offendingSymbol.(antlr.Token)
+	switch {
+	case !isToken || token == nil:
+		// lexer errors have no token, the message ends with the unknown text
+		diagnostic.Message = "unexpected character " + strings.TrimPrefix(msg, "token recognition error at: ")
+	case token.GetTokenType() == antlr.TokenEOF:
+		diagnostic.Message = "unexpected end of input"
+	default:
+		diagnostic.Length = len([]rune(token.GetText()))
+		diagnostic.Message = fmt.Sprintf("unexpected %s", token.GetText())
+	}
+	el.diagnostics = append(el.diagnostics, diagnostic)
 }
 
 func (el *RcalcParserErrorListener) ReportAmbiguity(recognizer antlr.Parser, dfa *antlr.DFA, startIndex, stopIndex int, exact bool, ambigAlts *antlr.BitSet, configs *antlr.ATNConfigSet) {
@@ -886,18 +1239,40 @@ func (el *RcalcParserErrorListener) ReportAttemptingFullContext(recognizer antlr
 		el.messages = append(el.messages, message)*/
 }
 
+// ReportContextSensitivity is not an error, the parser only needed the full context to decide
 func (el *RcalcParserErrorListener) ReportContextSensitivity(recognizer antlr.Parser, dfa *antlr.DFA, startIndex, stopIndex, prediction int, configs *antlr.ATNConfigSet) {
-	message := "ReportContextSensitivity"
-	el.messages = append(el.messages, message)
 }
 
-func ParseToActions(cmds string, lexerName string, registry *ActionRegistry) ([]Action, error) {
-	return parseToActionsImpl(cmds, lexerName, registry, func(listener parser.RcalcListener) parser.RcalcListener {
+// ParseToActions parses a text, binary integers without a base suffix are read in the base mode
+// of modes
+func ParseToActions(cmds string, lexerName string, registry *ActionRegistry, modes *Modes) ([]Action, error) {
+	return parseToActionsImpl(cmds, lexerName, registry, modes, func(listener parser.RcalcListener) parser.RcalcListener {
 		return listener
 	})
 }
 
-func parseToActionsImpl(cmds string, lexerName string, registry *ActionRegistry, listenerTransformer func(listener parser.RcalcListener) parser.RcalcListener) ([]Action, error) {
+// parseToLocatedActions parses a text keeping the location of the top level actions, the errors
+// are located in sourceName when it is not empty
+func parseToLocatedActions(cmds string, sourceName string, registry *ActionRegistry, modes *Modes) ([]LocatedItem[Action], error) {
+	actions, err := parseToLocatedActionsImpl(cmds, registry, modes, func(listener parser.RcalcListener) parser.RcalcListener {
+		return listener
+	})
+	var parseError *ParseError
+	if errors.As(err, &parseError) {
+		parseError.sourceName = sourceName
+	}
+	return actions, err
+}
+
+func parseToActionsImpl(cmds string, lexerName string, registry *ActionRegistry, modes *Modes, listenerTransformer func(listener parser.RcalcListener) parser.RcalcListener) ([]Action, error) {
+	actions, err := parseToLocatedActionsImpl(cmds, registry, modes, listenerTransformer)
+	if err != nil {
+		return nil, err
+	}
+	return toNonLocated(actions), nil
+}
+
+func parseToLocatedActionsImpl(cmds string, registry *ActionRegistry, modes *Modes, listenerTransformer func(listener parser.RcalcListener) parser.RcalcListener) ([]LocatedItem[Action], error) {
 
 	is := antlr.NewInputStream(cmds)
 
@@ -908,24 +1283,39 @@ func parseToActionsImpl(cmds string, lexerName string, registry *ActionRegistry,
 	// Create the Parser
 	p := parser.NewRcalcParser(stream)
 
-	// Error Listener
+	// Error Listener, the default console listeners would print the raw antlr messages
 	el := &RcalcParserErrorListener{}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(el)
 
 	// Finally parse the expression (by walking the tree)
-	var listener *RcalcParserListener = CreateRcalcParserListener(registry)
-	//p.RemoveErrorListeners()
+	var listener *RcalcParserListener = CreateRcalcParserListener(registry, modes)
+	p.RemoveErrorListeners()
 	p.AddErrorListener(el)
 	parseResult := p.Start_()
 	if el.HasErrors() {
-		return nil, fmt.Errorf("there are %d error(s):\n - %s", len(el.messages), strings.Join(el.messages, "\n - "))
+		stream.Fill()
+		var tokens []sourceToken
+		for _, token := range stream.GetAllTokens() {
+			tokens = append(tokens, toSourceToken(token))
+		}
+		// unbalanced delimiters are the cause of most syntax errors and are easier to understand
+		diagnostics := delimiterDiagnostics(tokens)
+		if len(diagnostics) == 0 {
+			diagnostics = el.diagnostics
+		}
+		return nil, &ParseError{source: cmds, Diagnostics: diagnostics}
 	}
 
 	var pluggedListener parser.RcalcListener = listenerTransformer(listener)
 	antlr.ParseTreeWalkerDefault.Walk(pluggedListener, parseResult)
-	if len(listener.contextManager.actionCtxStack.GetCurrentRoot().GetValidationErrors()) > 0 {
-		errorsAsString := toErrorMessage(listener.contextManager.actionCtxStack.GetCurrentRoot().GetValidationErrors())
-		return nil, fmt.Errorf("there are %d validation error(s):\n%s", len(listener.contextManager.actionCtxStack.GetCurrentRoot().GetValidationErrors()), errorsAsString)
+	if validationErrors := listener.contextManager.actionCtxStack.GetCurrentRoot().GetValidationErrors(); len(validationErrors) > 0 {
+		diagnostics := make([]Diagnostic, len(validationErrors))
+		for idx, validationError := range validationErrors {
+			diagnostics[idx] = validationDiagnostic(validationError)
+		}
+		return nil, &ParseError{source: cmds, Diagnostics: diagnostics}
 	}
 
-	return listener.contextManager.actionCtxStack.GetCurrentRoot().CreateFinalItem()
+	return listener.contextManager.actionCtxStack.GetCurrentRoot().(*RootContext[Action]).GetItems(), nil
 }
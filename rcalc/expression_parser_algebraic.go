@@ -64,7 +64,7 @@ func (asc *AlgebraicAddSubContext) CreateFinalItem() ([]AlgebraicExpressionNode,
 		return []AlgebraicExpressionNode{items[0].item}, nil
 	}
 	operators, err := tokenToPosition([]int{parser.RcalcLexerOP_ADD, parser.RcalcLexerOP_SUB}, asc.tokens)
-	fmt.Printf("Length of operators is %d / tokens : %d\n", len(operators), len(asc.tokens))
+	GetLogger().Debugf("Length of operators is %d / tokens : %d", len(operators), len(asc.tokens))
 	if err != nil {
 		panic("Unknown token")
 	}
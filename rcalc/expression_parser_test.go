@@ -6,6 +6,8 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"gonum.org/v1/gonum/mat"
+	"math/big"
 	"runtime"
 	"strings"
 	"testing"
@@ -129,6 +131,16 @@ func (l *LoggingParserListener) EnterInstrIfThenElse(c *parser.InstrIfThenElseCo
 	l.subListener.EnterInstrIfThenElse(c)
 }
 
+func (l *LoggingParserListener) EnterInstrCase(c *parser.InstrCaseContext) {
+	l.logMethodCalled()
+	l.subListener.EnterInstrCase(c)
+}
+
+func (l *LoggingParserListener) EnterInstrIfErr(c *parser.InstrIfErrContext) {
+	l.logMethodCalled()
+	l.subListener.EnterInstrIfErr(c)
+}
+
 func (l *LoggingParserListener) EnterInstrStartNextLoop(c *parser.InstrStartNextLoopContext) {
 	l.logMethodCalled()
 	l.subListener.EnterInstrStartNextLoop(c)
@@ -139,6 +151,26 @@ func (l *LoggingParserListener) EnterInstrForNextLoop(c *parser.InstrForNextLoop
 	l.subListener.EnterInstrForNextLoop(c)
 }
 
+func (l *LoggingParserListener) EnterInstrStartStepLoop(c *parser.InstrStartStepLoopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterInstrStartStepLoop(c)
+}
+
+func (l *LoggingParserListener) EnterInstrForStepLoop(c *parser.InstrForStepLoopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterInstrForStepLoop(c)
+}
+
+func (l *LoggingParserListener) EnterInstrWhileRepeatLoop(c *parser.InstrWhileRepeatLoopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterInstrWhileRepeatLoop(c)
+}
+
+func (l *LoggingParserListener) EnterInstrDoUntilLoop(c *parser.InstrDoUntilLoopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterInstrDoUntilLoop(c)
+}
+
 func (l *LoggingParserListener) EnterInstrLocalVarCreation(c *parser.InstrLocalVarCreationContext) {
 	l.logMethodCalled()
 	l.subListener.EnterInstrLocalVarCreation(c)
@@ -164,6 +196,41 @@ func (l *LoggingParserListener) EnterFor_next_loop(c *parser.For_next_loopContex
 	l.subListener.EnterFor_next_loop(c)
 }
 
+func (l *LoggingParserListener) EnterStart_step_loop(c *parser.Start_step_loopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterStart_step_loop(c)
+}
+
+func (l *LoggingParserListener) EnterFor_step_loop(c *parser.For_step_loopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterFor_step_loop(c)
+}
+
+func (l *LoggingParserListener) EnterWhile_repeat_loop(c *parser.While_repeat_loopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterWhile_repeat_loop(c)
+}
+
+func (l *LoggingParserListener) EnterDo_until_loop(c *parser.Do_until_loopContext) {
+	l.logMethodCalled()
+	l.subListener.EnterDo_until_loop(c)
+}
+
+func (l *LoggingParserListener) EnterCase_then_end(c *parser.Case_then_endContext) {
+	l.logMethodCalled()
+	l.subListener.EnterCase_then_end(c)
+}
+
+func (l *LoggingParserListener) EnterCase_clause(c *parser.Case_clauseContext) {
+	l.logMethodCalled()
+	l.subListener.EnterCase_clause(c)
+}
+
+func (l *LoggingParserListener) EnterIferr_then_else(c *parser.Iferr_then_elseContext) {
+	l.logMethodCalled()
+	l.subListener.EnterIferr_then_else(c)
+}
+
 func (l *LoggingParserListener) EnterLocalVarCreation(c *parser.LocalVarCreationContext) {
 	l.logMethodCalledf(" => %s", c.GetText())
 	l.subListener.EnterLocalVarCreation(c)
@@ -189,6 +256,11 @@ func (l *LoggingParserListener) EnterVariableNumber(c *parser.VariableNumberCont
 	l.subListener.EnterVariableNumber(c)
 }
 
+func (l *LoggingParserListener) EnterVariableString(c *parser.VariableStringContext) {
+	l.logMethodCalled()
+	l.subListener.EnterVariableString(c)
+}
+
 func (l *LoggingParserListener) EnterVariableAlgebraicExpression(c *parser.VariableAlgebraicExpressionContext) {
 	l.logMethodCalled()
 	l.subListener.EnterVariableAlgebraicExpression(c)
@@ -226,6 +298,26 @@ func (l *LoggingParserListener) EnterVariableVector(c *parser.VariableVectorCont
 	l.subListener.EnterVariableVector(c)
 }
 
+func (l *LoggingParserListener) EnterVariableComplex(c *parser.VariableComplexContext) {
+	l.logMethodCalled()
+	l.subListener.EnterVariableComplex(c)
+}
+
+func (l *LoggingParserListener) EnterVariableRational(c *parser.VariableRationalContext) {
+	l.logMethodCalled()
+	l.subListener.EnterVariableRational(c)
+}
+
+func (l *LoggingParserListener) EnterVariableQuantity(c *parser.VariableQuantityContext) {
+	l.logMethodCalled()
+	l.subListener.EnterVariableQuantity(c)
+}
+
+func (l *LoggingParserListener) EnterVariableBinaryInteger(c *parser.VariableBinaryIntegerContext) {
+	l.logMethodCalled()
+	l.subListener.EnterVariableBinaryInteger(c)
+}
+
 func (l *LoggingParserListener) EnterQuoted_algebraic_expression(c *parser.Quoted_algebraic_expressionContext) {
 	l.logMethodCalled()
 	l.subListener.EnterQuoted_algebraic_expression(c)
@@ -326,6 +418,16 @@ func (l *LoggingParserListener) ExitInstrIfThenElse(c *parser.InstrIfThenElseCon
 	l.subListener.ExitInstrIfThenElse(c)
 }
 
+func (l *LoggingParserListener) ExitInstrCase(c *parser.InstrCaseContext) {
+	l.logMethodCalled()
+	l.subListener.ExitInstrCase(c)
+}
+
+func (l *LoggingParserListener) ExitInstrIfErr(c *parser.InstrIfErrContext) {
+	l.logMethodCalled()
+	l.subListener.ExitInstrIfErr(c)
+}
+
 func (l *LoggingParserListener) ExitInstrStartNextLoop(c *parser.InstrStartNextLoopContext) {
 	l.logMethodCalled()
 	l.subListener.ExitInstrStartNextLoop(c)
@@ -336,6 +438,26 @@ func (l *LoggingParserListener) ExitInstrForNextLoop(c *parser.InstrForNextLoopC
 	l.subListener.ExitInstrForNextLoop(c)
 }
 
+func (l *LoggingParserListener) ExitInstrStartStepLoop(c *parser.InstrStartStepLoopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitInstrStartStepLoop(c)
+}
+
+func (l *LoggingParserListener) ExitInstrForStepLoop(c *parser.InstrForStepLoopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitInstrForStepLoop(c)
+}
+
+func (l *LoggingParserListener) ExitInstrWhileRepeatLoop(c *parser.InstrWhileRepeatLoopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitInstrWhileRepeatLoop(c)
+}
+
+func (l *LoggingParserListener) ExitInstrDoUntilLoop(c *parser.InstrDoUntilLoopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitInstrDoUntilLoop(c)
+}
+
 func (l *LoggingParserListener) ExitInstrLocalVarCreation(c *parser.InstrLocalVarCreationContext) {
 	l.logMethodCalled()
 	l.subListener.ExitInstrLocalVarCreation(c)
@@ -361,6 +483,41 @@ func (l *LoggingParserListener) ExitFor_next_loop(c *parser.For_next_loopContext
 	l.subListener.ExitFor_next_loop(c)
 }
 
+func (l *LoggingParserListener) ExitStart_step_loop(c *parser.Start_step_loopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitStart_step_loop(c)
+}
+
+func (l *LoggingParserListener) ExitFor_step_loop(c *parser.For_step_loopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitFor_step_loop(c)
+}
+
+func (l *LoggingParserListener) ExitWhile_repeat_loop(c *parser.While_repeat_loopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitWhile_repeat_loop(c)
+}
+
+func (l *LoggingParserListener) ExitDo_until_loop(c *parser.Do_until_loopContext) {
+	l.logMethodCalled()
+	l.subListener.ExitDo_until_loop(c)
+}
+
+func (l *LoggingParserListener) ExitCase_then_end(c *parser.Case_then_endContext) {
+	l.logMethodCalled()
+	l.subListener.ExitCase_then_end(c)
+}
+
+func (l *LoggingParserListener) ExitCase_clause(c *parser.Case_clauseContext) {
+	l.logMethodCalled()
+	l.subListener.ExitCase_clause(c)
+}
+
+func (l *LoggingParserListener) ExitIferr_then_else(c *parser.Iferr_then_elseContext) {
+	l.logMethodCalled()
+	l.subListener.ExitIferr_then_else(c)
+}
+
 func (l *LoggingParserListener) ExitLocalVarCreation(c *parser.LocalVarCreationContext) {
 	l.logMethodCalled()
 	l.subListener.ExitLocalVarCreation(c)
@@ -386,6 +543,11 @@ func (l *LoggingParserListener) ExitVariableNumber(c *parser.VariableNumberConte
 	l.subListener.ExitVariableNumber(c)
 }
 
+func (l *LoggingParserListener) ExitVariableString(c *parser.VariableStringContext) {
+	l.logMethodCalled()
+	l.subListener.ExitVariableString(c)
+}
+
 func (l *LoggingParserListener) ExitVariableAlgebraicExpression(c *parser.VariableAlgebraicExpressionContext) {
 	l.logMethodCalled()
 	l.subListener.ExitVariableAlgebraicExpression(c)
@@ -406,6 +568,26 @@ func (l *LoggingParserListener) ExitVariableVector(c *parser.VariableVectorConte
 	l.subListener.ExitVariableVector(c)
 }
 
+func (l *LoggingParserListener) ExitVariableComplex(c *parser.VariableComplexContext) {
+	l.logMethodCalled()
+	l.subListener.ExitVariableComplex(c)
+}
+
+func (l *LoggingParserListener) ExitVariableRational(c *parser.VariableRationalContext) {
+	l.logMethodCalled()
+	l.subListener.ExitVariableRational(c)
+}
+
+func (l *LoggingParserListener) ExitVariableQuantity(c *parser.VariableQuantityContext) {
+	l.logMethodCalled()
+	l.subListener.ExitVariableQuantity(c)
+}
+
+func (l *LoggingParserListener) ExitVariableBinaryInteger(c *parser.VariableBinaryIntegerContext) {
+	l.logMethodCalled()
+	l.subListener.ExitVariableBinaryInteger(c)
+}
+
 func (l *LoggingParserListener) ExitQuoted_algebraic_expression(c *parser.Quoted_algebraic_expressionContext) {
 	l.logMethodCalled()
 	l.subListener.ExitQuoted_algebraic_expression(c)
@@ -486,11 +668,41 @@ func (l *LoggingParserListener) EnterNumber(c *parser.NumberContext) {
 	l.subListener.EnterNumber(c)
 }
 
+func (l *LoggingParserListener) EnterComplex(c *parser.ComplexContext) {
+	l.logMethodCalled()
+	l.subListener.EnterComplex(c)
+}
+
+func (l *LoggingParserListener) EnterRational(c *parser.RationalContext) {
+	l.logMethodCalled()
+	l.subListener.EnterRational(c)
+}
+
+func (l *LoggingParserListener) EnterQuantity(c *parser.QuantityContext) {
+	l.logMethodCalled()
+	l.subListener.EnterQuantity(c)
+}
+
 func (l *LoggingParserListener) ExitNumber(c *parser.NumberContext) {
 	l.logMethodCalled()
 	l.subListener.ExitNumber(c)
 }
 
+func (l *LoggingParserListener) ExitComplex(c *parser.ComplexContext) {
+	l.logMethodCalled()
+	l.subListener.ExitComplex(c)
+}
+
+func (l *LoggingParserListener) ExitRational(c *parser.RationalContext) {
+	l.logMethodCalled()
+	l.subListener.ExitRational(c)
+}
+
+func (l *LoggingParserListener) ExitQuantity(c *parser.QuantityContext) {
+	l.logMethodCalled()
+	l.subListener.ExitQuantity(c)
+}
+
 type ParsingTestSuite struct {
 	suite.Suite
 
@@ -506,7 +718,7 @@ func TestParsingSuite(t *testing.T) {
 }
 
 func (suite *ParsingTestSuite) parseWithDebugLogging(txt string) ([]Action, error) {
-	return parseToActionsImpl(txt, "Test", suite.registry, func(listener parser.RcalcListener) parser.RcalcListener {
+	return parseToActionsImpl(txt, "Test", suite.registry, NewModes(), func(listener parser.RcalcListener) parser.RcalcListener {
 		return &LoggingParserListener{
 			subListener: listener,
 		}
@@ -618,6 +830,174 @@ func (suite *ParsingTestSuite) TestAntlrParseForNextLoopError() {
 	assert.Errorf(suite.T(), err, "")
 }
 
+func (suite *ParsingTestSuite) TestAntlrParseStepLoops() {
+	elt, err := suite.parseWithDebugLogging("10 1 start 1 -2 step 1 2 for i i 0.5 step")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 6) {
+			if assert.IsType(suite.T(), &StartStepLoopActionDesc{}, elt[2]) {
+				assert.Len(suite.T(), elt[2].(*StartStepLoopActionDesc).actions, 2)
+			}
+			if assert.IsType(suite.T(), &ForStepLoopActionDesc{}, elt[5]) {
+				forStepLoopActionDesc := elt[5].(*ForStepLoopActionDesc)
+				assert.Equal(suite.T(), "i", forStepLoopActionDesc.varName)
+				assert.Len(suite.T(), forStepLoopActionDesc.actions, 2)
+			}
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseConditionLoops() {
+	elt, err := suite.parseWithDebugLogging("while 0 over < repeat 1 - end do 1 + until dup 10 == end")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) {
+			if assert.IsType(suite.T(), &WhileRepeatLoopActionDesc{}, elt[0]) {
+				whileRepeatLoopActionDesc := elt[0].(*WhileRepeatLoopActionDesc)
+				assert.Len(suite.T(), whileRepeatLoopActionDesc.conditionActions, 3)
+				assert.Len(suite.T(), whileRepeatLoopActionDesc.bodyActions, 2)
+			}
+			if assert.IsType(suite.T(), &DoUntilLoopActionDesc{}, elt[1]) {
+				doUntilLoopActionDesc := elt[1].(*DoUntilLoopActionDesc)
+				assert.Len(suite.T(), doUntilLoopActionDesc.bodyActions, 2)
+				assert.Len(suite.T(), doUntilLoopActionDesc.conditionActions, 3)
+			}
+		}
+		stack := CreateStack()
+		stack.Push(numVar(5))
+		if assert.NoError(suite.T(), RunActionsInTransaction(CreateSystemInstance(), stack, elt)) {
+			assert.Equal(suite.T(), []string{"10"}, stackValues(stack))
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseWhileLoopError() {
+	_, err := suite.parseWithDebugLogging("while 1 repeat 2")
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseCase() {
+	elt, err := suite.parseWithDebugLogging("case dup 1 == then 10 end dup 2 == then 20 end 0 end")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 1) && assert.IsType(suite.T(), &CaseActionDesc{}, elt[0]) {
+			caseActionDesc := elt[0].(*CaseActionDesc)
+			if assert.Len(suite.T(), caseActionDesc.clauses, 2) {
+				assert.Len(suite.T(), caseActionDesc.clauses[0].conditionActions, 3)
+				assert.Len(suite.T(), caseActionDesc.clauses[0].bodyActions, 1)
+				assert.Len(suite.T(), caseActionDesc.clauses[1].conditionActions, 3)
+			}
+			assert.Len(suite.T(), caseActionDesc.defaultActions, 1)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseAndRunCase() {
+	elt, err := suite.parseWithDebugLogging("case dup 1 == then drop 10 end dup 2 == then drop 20 end drop 0 end")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		for _, test := range []struct {
+			input    int
+			expected string
+		}{{1, "10"}, {2, "20"}, {3, "0"}} {
+			stack := CreateStack()
+			stack.Push(numVar(test.input))
+			if assert.NoError(suite.T(), RunActionsInTransaction(CreateSystemInstance(), stack, elt)) {
+				assert.Equal(suite.T(), []string{test.expected}, stackValues(stack), "case of %d", test.input)
+			}
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseNestedCaseWithoutDefault() {
+	elt, err := suite.parseWithDebugLogging("case dup 0 < then if 1 then 2 end end end")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 1) && assert.IsType(suite.T(), &CaseActionDesc{}, elt[0]) {
+			caseActionDesc := elt[0].(*CaseActionDesc)
+			if assert.Len(suite.T(), caseActionDesc.clauses, 1) {
+				assert.IsType(suite.T(), &IfThenElseActionDesc{}, caseActionDesc.clauses[0].bodyActions[0])
+			}
+			assert.Empty(suite.T(), caseActionDesc.defaultActions)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseKeywordsIgnoringCase() {
+	elt, err := suite.parseWithDebugLogging("IF 1 THEN 2 Else 3 End CASE 1 then 4 END END")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) {
+			assert.IsType(suite.T(), &IfThenElseActionDesc{}, elt[0])
+			assert.IsType(suite.T(), &CaseActionDesc{}, elt[1])
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseSymbolName() {
+	elt, err := suite.parseWithDebugLogging("16 √")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) {
+			assert.Same(suite.T(), &sqrtOp, elt[1])
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseIfErr() {
+	elt, err := suite.parseWithDebugLogging("iferr 1 0 / then errm else 2 end iferr drop then 0 end")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) && assert.IsType(suite.T(), &IfErrActionDesc{}, elt[0]) {
+			ifErrActionDesc := elt[0].(*IfErrActionDesc)
+			assert.Len(suite.T(), ifErrActionDesc.trapActions, 3)
+			assert.Len(suite.T(), ifErrActionDesc.thenActions, 1)
+			assert.Len(suite.T(), ifErrActionDesc.elseActions, 1)
+			if assert.IsType(suite.T(), &IfErrActionDesc{}, elt[1]) {
+				assert.Empty(suite.T(), elt[1].(*IfErrActionDesc).elseActions)
+			}
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseLocatedErrors() {
+	tests := []struct {
+		txt      string
+		expected Diagnostic
+	}{
+		{"1 << dup", Diagnostic{Line: 1, Column: 2, Length: 2, Message: "unclosed <<, missing >>"}},
+		{"1 2 + >>", Diagnostic{Line: 1, Column: 6, Length: 2, Message: "unexpected >>, nothing to close"}},
+		{"{ 1 << 2 }", Diagnostic{Line: 1, Column: 4, Length: 2, Message: "unclosed <<, missing >> before }"}},
+	}
+	for _, tt := range tests {
+		_, err := suite.parseWithDebugLogging(tt.txt)
+		var parseError *ParseError
+		if assert.ErrorAs(suite.T(), err, &parseError, tt.txt) && assert.NotEmpty(suite.T(), parseError.Diagnostics, tt.txt) {
+			assert.Equal(suite.T(), tt.expected, parseError.Diagnostics[0], tt.txt)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseComments() {
+	txt := "@ circle area\n<< dup * @ square\n  pi * >> 'AREA' sto\n\n@ the end"
+	elt, err := suite.parseWithDebugLogging(txt)
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) && assert.Len(suite.T(), elt, 3) {
+		assert.Equal(suite.T(), "<< dup * pi * >>", elt[0].Display())
+	}
+
+	elt, err = suite.parseWithDebugLogging("@ nothing to run\n")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), elt)
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseTopLevelLocations() {
+	txt := "1 2\nif 1 then\n  3\nend +\n'A' sto"
+	actions, err := parseToLocatedActions(txt, "test.rcalc", suite.registry, NewModes())
+	if assert.NoError(suite.T(), err) && assert.Len(suite.T(), actions, 6) {
+		var positions []string
+		for _, action := range actions {
+			positions = append(positions, fmt.Sprintf("%d:%d", action.start.GetLine(), action.start.GetColumn()))
+		}
+		assert.Equal(suite.T(), []string{"1:0", "1:2", "2:0", "4:4", "5:0", "5:4"}, positions)
+	}
+
+	_, err = parseToLocatedActions("1\n<< 2", "test.rcalc", suite.registry, NewModes())
+	assert.EqualError(suite.T(), err, "test.rcalc:2:1: unclosed <<, missing >>\n  << 2\n  ^^")
+}
+
 func (suite *ParsingTestSuite) TestAntlrParseIfThenElse() {
 
 	var txt string = " if 1 1 == then 2 else 3 end"
@@ -743,6 +1123,81 @@ func (suite *ParsingTestSuite) TestAntlrParseList() {
 	}
 }
 
+func (suite *ParsingTestSuite) TestAntlrParseString() {
+	var txt string = `"a \"quoted\" word" "tab\there"`
+
+	elt, err := suite.parseWithDebugLogging(txt)
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) {
+			assert.Equal(suite.T(), CreateStringVariable(`a "quoted" word`), elt[0].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateStringVariable("tab\there"), elt[1].(*VariablePutOnStackActionDesc).value)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseVectorAndMatrix() {
+	var txt string = "[ 1 -2 3.5 ] [[1 2] [3 4]]"
+
+	elt, err := suite.parseWithDebugLogging(txt)
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) {
+			assert.Equal(suite.T(), CreateVectorVariableFromValues([]float64{1, -2, 3.5}), elt[0].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateMatrixVariable(mat.NewDense(2, 2, []float64{1, 2, 3, 4})), elt[1].(*VariablePutOnStackActionDesc).value)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseMatrixWithRowsOfDifferentSizes() {
+	_, err := suite.parseWithDebugLogging("[[1 2] [3]]")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseComplex() {
+	elt, err := suite.parseWithDebugLogging("(3, -4.5) ( 1 ,2 )")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 2) {
+			assert.Equal(suite.T(), CreateComplexVariable(complex(3, -4.5)), elt[0].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateComplexVariable(complex(1, 2)), elt[1].(*VariablePutOnStackActionDesc).value)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseRational() {
+	elt, err := suite.parseWithDebugLogging("1/3 -2/4 6/3")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 3) {
+			assert.Equal(suite.T(), CreateRationalVariable(big.NewRat(1, 3)), elt[0].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateRationalVariable(big.NewRat(-1, 2)), elt[1].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateNumericVariable(decimal.NewFromInt(2)), elt[2].(*VariablePutOnStackActionDesc).value)
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseQuantity() {
+	elt, err := suite.parseWithDebugLogging("9.81_m/s^2 -3_km 2_m/cm")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 3) {
+			assert.Equal(suite.T(), "9.81_m/s^2", elt[0].(*VariablePutOnStackActionDesc).value.display(NewModes()))
+			assert.Equal(suite.T(), "-3_km", elt[1].(*VariablePutOnStackActionDesc).value.display(NewModes()))
+			assert.Equal(suite.T(), "200", elt[2].(*VariablePutOnStackActionDesc).value.display(NewModes()))
+		}
+	}
+}
+
+func (suite *ParsingTestSuite) TestAntlrParseBinaryInteger() {
+	elt, err := suite.parseWithDebugLogging("#FFh #1011b #17 #1Bh #1b #ABCDh")
+	if assert.NoError(suite.T(), err, "Parse error : %s", err) {
+		if assert.Len(suite.T(), elt, 6) {
+			assert.Equal(suite.T(), CreateBinaryIntegerVariable(255), elt[0].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateBinaryIntegerVariable(11), elt[1].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateBinaryIntegerVariable(17), elt[2].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateBinaryIntegerVariable(27), elt[3].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateBinaryIntegerVariable(1), elt[4].(*VariablePutOnStackActionDesc).value)
+			assert.Equal(suite.T(), CreateBinaryIntegerVariable(0xABCD), elt[5].(*VariablePutOnStackActionDesc).value)
+		}
+	}
+}
+
 type TestErrorListener struct {
 	hasErrors bool
 }
@@ -819,6 +1274,18 @@ func (suite *ParsingTestSuite) TestAlgebraicExpressionParsing() {
 			literal: "'1 + 2 - 3'",
 			value:   decimal.Zero,
 		},
+		{
+			literal: "'5 - 3'",
+			value:   decimal.NewFromInt(2),
+		},
+		{
+			literal: "'6 / 2 * 3'",
+			value:   decimal.NewFromInt(9),
+		},
+		{
+			literal: "'-a + a'",
+			value:   decimal.Zero,
+		},
 		{
 			literal: "'a'",
 			value:   decimal.NewFromInt(7),
@@ -871,7 +1338,7 @@ func (suite *ParsingTestSuite) TestAlgebraicExpressionParsing() {
 				el := &TestErrorListener{}
 
 				// Finally parse the expression (by walking the tree)
-				var listener = CreateRcalcParserListener(Registry)
+				var listener = CreateRcalcParserListener(Registry, NewModes())
 				//p.RemoveErrorListeners()
 				p.AddErrorListener(el)
 				antlr.ParseTreeWalkerDefault.Walk(listener, p.Start_())
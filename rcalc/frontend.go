@@ -0,0 +1,63 @@
+package rcalc
+
+import (
+	"bufio"
+	"io"
+)
+
+// StackReader Read only view of the stack given to the frontends to render it
+type StackReader interface {
+	Size() int
+	Get(level int) (Variable, error)
+}
+
+var _ StackReader = (*Stack)(nil)
+
+// Frontend User facing part of rcalc: renders the stack and reads the command lines to run
+type Frontend interface {
+	Start() error
+	Stop()
+	// Refresh displays the stack with the modes and the message resulting from the last command line
+	Refresh(stack StackReader, modes *Modes, message string)
+	// ReadCommandLine blocks until the user validates a line, io.EOF is returned when the user leaves
+	ReadCommandLine() (string, error)
+}
+
+// ConsoleFrontend Line based frontend, prints the stack after each command line
+type ConsoleFrontend struct {
+	scanner       *bufio.Scanner
+	minLevels     int
+	clearTerminal bool
+}
+
+var _ Frontend = (*ConsoleFrontend)(nil)
+
+// NewConsoleFrontend reads the command lines from input and prints at least minLevels stack levels,
+// after clearing the terminal when clearTerminal is set
+func NewConsoleFrontend(input io.Reader, minLevels int, clearTerminal bool) *ConsoleFrontend {
+	return &ConsoleFrontend{
+		scanner:       bufio.NewScanner(input),
+		minLevels:     minLevels,
+		clearTerminal: clearTerminal,
+	}
+}
+
+func (cf *ConsoleFrontend) Start() error {
+	return nil
+}
+
+func (cf *ConsoleFrontend) Stop() {}
+
+func (cf *ConsoleFrontend) Refresh(stack StackReader, modes *Modes, message string) {
+	DisplayStack(stack, modes, message, cf.minLevels, cf.clearTerminal)
+}
+
+func (cf *ConsoleFrontend) ReadCommandLine() (string, error) {
+	if !cf.scanner.Scan() {
+		if err := cf.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return cf.scanner.Text(), nil
+}
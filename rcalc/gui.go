@@ -2,7 +2,7 @@ package rcalc
 
 import "fmt"
 
-func DisplayStack(s *Stack, message string, minElts int, clearTerminal bool) {
+func DisplayStack(s StackReader, modes *Modes, message string, minElts int, clearTerminal bool) {
 	// Clear terminal
 	if clearTerminal {
 		fmt.Print("\033c")
@@ -11,18 +11,18 @@ func DisplayStack(s *Stack, message string, minElts int, clearTerminal bool) {
 	fmt.Printf("I: %s\n", message)
 	stackSize := s.Size()
 	for i := minElts - 1; i >= stackSize; i-- {
-		displayStackLevel(i, nil)
+		displayStackLevel(modes, i, nil)
 	}
 	for i := stackSize - 1; i >= 0; i-- {
 		elt, _ := s.Get(i)
-		displayStackLevel(i, elt)
+		displayStackLevel(modes, i, elt)
 	}
 }
 
-func displayStackLevel(level int, elt Variable) {
+func displayStackLevel(modes *Modes, level int, elt Variable) {
 	var value string = ""
 	if elt != nil {
-		value = elt.display()
+		value = elt.display(modes)
 	}
 	fmt.Printf("%2d: %10s", level+1, value)
 	fmt.Println()
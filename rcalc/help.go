@@ -0,0 +1,164 @@
+package rcalc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ActionDoc documentation of an action, shown by help and listed by apropos
+type ActionDoc struct {
+	Summary string
+	// Stack diagram, the arguments from the deepest level to level 1 then the results: x y -> x+y
+	Stack string
+	// Types accepted types of the arguments
+	Types    string
+	Examples []DocExample
+}
+
+// DocExample a command line run on an empty stack and the resulting stack, levels separated by
+// spaces from the deepest one. The examples are run by the tests.
+type DocExample struct {
+	Input  string
+	Result string
+}
+
+// Line describes an action in one line: op code, stack diagram and summary
+func (doc ActionDoc) Line(opCode string) string {
+	return fmt.Sprintf("%s ( %s ) %s", opCode, doc.Stack, doc.Summary)
+}
+
+// PackageDoc the op codes of a package, in their registration order
+type PackageDoc struct {
+	Name    string
+	OpCodes []string
+}
+
+// GetPackageDocs the packages of the registry in their registration order
+func (reg *ActionRegistry) GetPackageDocs() []PackageDoc {
+	result := make([]PackageDoc, len(reg.packages))
+	for idx, aPackage := range reg.packages {
+		result[idx].Name = aPackage.name
+		for _, action := range aPackage.staticActions {
+			result[idx].OpCodes = append(result[idx].OpCodes, action.OpCode())
+		}
+	}
+	return result
+}
+
+// GetDoc documentation of an action and the name of its package, the action can be given by an
+// alias and in any case
+func (reg *ActionRegistry) GetDoc(opCode string) (ActionDoc, string, bool) {
+	opCode = reg.canonicalOpCode(opCode)
+	for _, aPackage := range reg.packages {
+		if doc, ok := aPackage.docs[opCode]; ok {
+			return doc, aPackage.name, true
+		}
+	}
+	return ActionDoc{}, "", false
+}
+
+// canonicalOpCode op code of the action called by name, name itself when it is unknown
+func (reg *ActionRegistry) canonicalOpCode(name string) string {
+	if action := reg.GetAction(name); action != nil {
+		return action.OpCode()
+	}
+	return name
+}
+
+// Help full documentation of an action, an error suggesting a close op code when it is unknown
+func (reg *ActionRegistry) Help(opCode string) (string, error) {
+	opCode = reg.canonicalOpCode(opCode)
+	doc, packageName, ok := reg.GetDoc(opCode)
+	if !ok {
+		if suggestion := suggestName(opCode, reg.GetOpCodes()); suggestion != "" {
+			return "", fmt.Errorf("unknown operation %s, did you mean %s?", opCode, suggestion)
+		}
+		return "", fmt.Errorf("unknown operation %s", opCode)
+	}
+	lines := []string{
+		doc.Line(opCode),
+		"package: " + packageName,
+		"types: " + doc.Types,
+	}
+	if aliases := reg.GetAliases(opCode); len(aliases) > 0 {
+		lines = append(lines, "aliases: "+strings.Join(aliases, ", "))
+	}
+	if len(doc.Examples) > 0 {
+		lines = append(lines, "examples:")
+		for _, example := range doc.Examples {
+			lines = append(lines, fmt.Sprintf("  %s  =>  %s", example.Input, example.Result))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Apropos sorted op codes of the actions whose op code, summary or package contains keyword,
+// ignoring case
+func (reg *ActionRegistry) Apropos(keyword string) []string {
+	keyword = strings.ToLower(keyword)
+	var opCodes []string
+	for _, aPackage := range reg.packages {
+		for opCode, doc := range aPackage.docs {
+			if strings.Contains(strings.ToLower(opCode), keyword) ||
+				strings.Contains(strings.ToLower(doc.Summary), keyword) ||
+				strings.Contains(aPackage.name, keyword) {
+				opCodes = append(opCodes, opCode)
+			}
+		}
+	}
+	slices.Sort(opCodes)
+	return opCodes
+}
+
+// helpTopic the op code given to help and apropos, as a string or as a name like 'dup'
+func helpTopic(opCode string, v Variable) (string, error) {
+	switch v.getType() {
+	case TYPE_STR:
+		return v.asStringVar().value, nil
+	case TYPE_ALG_EXPR:
+		return memoryName(v)
+	default:
+		return "", fmt.Errorf("%s expects a string or a name, found: %v", opCode, v.getType())
+	}
+}
+
+// helpAct pushes the one line description of an action: "dup" help
+var helpAct = NewActionDesc("help", 1, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	opCode, err := helpTopic("help", elts[0])
+	if err != nil {
+		return err
+	}
+	if _, err = system.Registry().Help(opCode); err != nil {
+		return err
+	}
+	opCode = system.Registry().canonicalOpCode(opCode)
+	doc, _, _ := system.Registry().GetDoc(opCode)
+	_, _ = stack.Pop()
+	stack.Push(CreateStringVariable(doc.Line(opCode)))
+	return nil
+})
+
+// aproposAct pushes the list of the op codes related to a keyword: "matrix" apropos
+var aproposAct = NewActionDesc("apropos", 1, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	keyword, err := helpTopic("apropos", elts[0])
+	if err != nil {
+		return err
+	}
+	opCodes := system.Registry().Apropos(keyword)
+	items := make([]Variable, len(opCodes))
+	for idx, opCode := range opCodes {
+		items[idx] = CreateStringVariable(opCode)
+	}
+	_, _ = stack.Pop()
+	stack.Push(CreateListVariable(items))
+	return nil
+})
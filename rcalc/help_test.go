@@ -0,0 +1,70 @@
+package rcalc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryActionIsDocumented(t *testing.T) {
+	for _, opCode := range Registry.GetOpCodes() {
+		doc, _, ok := Registry.GetDoc(opCode)
+		if assert.True(t, ok, "%s is not documented", opCode) {
+			assert.NotEmpty(t, doc.Summary, opCode)
+			assert.NotEmpty(t, doc.Stack, opCode)
+			assert.NotEmpty(t, doc.Types, opCode)
+		}
+	}
+	for _, aPackage := range Registry.packages {
+		assert.NotEmpty(t, aPackage.name)
+		for opCode := range aPackage.docs {
+			assert.True(t, Registry.ContainsOpCode(opCode), "%s is documented but not registered", opCode)
+		}
+	}
+}
+
+// TestDocExamples runs the examples of the documentation, each on an empty stack
+func TestDocExamples(t *testing.T) {
+	for _, packageDoc := range Registry.GetPackageDocs() {
+		for _, opCode := range packageDoc.OpCodes {
+			doc, _, _ := Registry.GetDoc(opCode)
+			for _, example := range doc.Examples {
+				t.Run(opCode+" "+example.Input, func(t *testing.T) {
+					system := CreateSystemInstance()
+					stack := CreateStack()
+					if assert.NoError(t, RunCommandLine(system, stack, example.Input)) {
+						assert.Equal(t, example.Result, strings.Join(displayedStackValues(stack, system.Modes()), " "))
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	help, err := Registry.Help("dup")
+	assert.NoError(t, err)
+	assert.Equal(t, "dup ( x -> x x ) duplicates level 1\npackage: stack\ntypes: any\nexamples:\n  1 dup  =>  1 1", help)
+
+	help, err = Registry.Help("√")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(help, "sqrt ( x -> sqrt(x) )"), help)
+	assert.Contains(t, help, "\naliases: √\n")
+
+	_, err = Registry.Help("dupp")
+	assert.EqualError(t, err, "unknown operation dupp, did you mean dup?")
+
+	stack := CreateStack()
+	stack.Push(CreateStringVariable("swap"))
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+	if assert.NoError(t, runtimeContext.RunAction(&helpAct)) {
+		assert.Equal(t, []string{`"swap ( x y -> y x ) exchanges levels 1 and 2"`}, stackValues(stack))
+	}
+}
+
+func TestApropos(t *testing.T) {
+	assert.Equal(t, []string{"redo", "undo"}, Registry.Apropos("UNDO"))
+	assert.Contains(t, Registry.Apropos("matri"), "det")
+	assert.Empty(t, Registry.Apropos("nothing like this"))
+}
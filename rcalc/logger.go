@@ -6,7 +6,8 @@ import (
 	"os"
 )
 
-var logger *zap.SugaredLogger
+// logger discards everything until one of the Init functions is called
+var logger = zap.NewNop().Sugar()
 
 func InitDevLogger(filePath string) {
 
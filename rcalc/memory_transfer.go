@@ -0,0 +1,239 @@
+package rcalc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Text transfer of memory folders. A folder is written as:
+//
+//	DIR LIB
+//	  SQ = << dup * >>
+//	  DIR CONSTANTS
+//	    G = 9.80665_m/s^2
+//	  END
+//	END
+//
+// Values are written with display() and the default modes, and read back with the parser.
+
+const (
+	transferFolderMarker   = "DIR"
+	transferEndMarker      = "END"
+	transferValueSeparator = " = "
+	// maxTransferLineLength allows long programs, which are written on a single line
+	maxTransferLineLength = 1024 * 1024
+)
+
+// ExportFolder writes a folder and all its content in a text form read by ImportFolders
+func ExportFolder(w io.Writer, folder *MemoryFolder) error {
+	bw := bufio.NewWriter(w)
+	// values are displayed with the default modes so that they are read back without loss
+	exportFolder(bw, NewModes(), folder, "")
+	return bw.Flush()
+}
+
+func exportFolder(w *bufio.Writer, modes *Modes, folder *MemoryFolder, indent string) {
+	_, _ = fmt.Fprintf(w, "%s%s %s\n", indent, transferFolderMarker, folder.name)
+	for _, variable := range folder.variables {
+		_, _ = fmt.Fprintf(w, "%s  %s%s%s\n", indent, variable.name, transferValueSeparator, variable.value.display(modes))
+	}
+	for _, subFolder := range folder.subFolders {
+		exportFolder(w, modes, subFolder, indent+"  ")
+	}
+	_, _ = fmt.Fprintf(w, "%s%s\n", indent, transferEndMarker)
+}
+
+// ImportFolders reads folders written by ExportFolder into parent. Folders which already exist
+// are merged and variables which already exist are replaced.
+func ImportFolders(memory Memory, parent *MemoryFolder, r io.Reader, registry *ActionRegistry) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxTransferLineLength)
+	folders := []*MemoryFolder{parent}
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var err error
+		folders, err = importLine(memory, folders, line, registry)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(folders) > 1 {
+		return fmt.Errorf("missing %s for folder %s", transferEndMarker, folders[len(folders)-1].name)
+	}
+	return nil
+}
+
+// importLine handles a line of a transfer file and returns the folders opened so far
+func importLine(memory Memory, folders []*MemoryFolder, line string, registry *ActionRegistry) ([]*MemoryFolder, error) {
+	current := folders[len(folders)-1]
+	if line == transferEndMarker {
+		if len(folders) == 1 {
+			return nil, fmt.Errorf("%s without %s", transferEndMarker, transferFolderMarker)
+		}
+		return folders[:len(folders)-1], nil
+	}
+	if folderName, found := strings.CutPrefix(line, transferFolderMarker+" "); found {
+		folderName = strings.TrimSpace(folderName)
+		switch node := current.subNode(folderName).(type) {
+		case *MemoryFolder:
+			return append(folders, node), nil
+		case *MemoryVariable:
+			return nil, fmt.Errorf("%s is a variable of folder %s", folderName, current.name)
+		}
+		folder, err := memory.createFolder(folderName, current)
+		if err != nil {
+			return nil, err
+		}
+		return append(folders, folder), nil
+	}
+	name, valueText, found := strings.Cut(line, transferValueSeparator)
+	if !found {
+		return nil, fmt.Errorf("expected %s NAME, %s or NAME%svalue, found: %s", transferFolderMarker, transferEndMarker, transferValueSeparator, line)
+	}
+	value, err := parseTransferValue(valueText, registry)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read value of %s: %w", name, err)
+	}
+	if _, err = memory.createVariable(strings.TrimSpace(name), current, value); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+func parseTransferValue(valueText string, registry *ActionRegistry) (Variable, error) {
+	// the values are exported with the default modes, binary integers always have a base suffix
+	actions, err := ParseToActions(valueText, "Import", registry, NewModes())
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 1 {
+		if putOnStackAction, ok := actions[0].(*VariablePutOnStackActionDesc); ok {
+			return putOnStackAction.value, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a single value", valueText)
+}
+
+// ExportFolderToFile writes a folder to a transfer file
+func ExportFolderToFile(folder *MemoryFolder, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err = ExportFolder(file, folder); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ImportFoldersFromFile reads the folders of a transfer file into parent
+func ImportFoldersFromFile(memory Memory, parent *MemoryFolder, filePath string, registry *ActionRegistry) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return ImportFolders(memory, parent, file, registry)
+}
+
+// ResolveFolderPath returns the folder designated by names separated by slashes, starting from
+// HOME like in LIB/CONSTANTS. An empty path, / and HOME designate HOME.
+func ResolveFolderPath(memory Memory, folderPath string) (*MemoryFolder, error) {
+	folder := memory.getRoot()
+	names := strings.Split(strings.Trim(folderPath, "/"), "/")
+	if names[0] == folder.name {
+		names = names[1:]
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		subFolder, ok := folder.subNode(name).(*MemoryFolder)
+		if !ok {
+			return nil, fmt.Errorf("folder %s not found in %s", name, folderPath)
+		}
+		folder = subFolder
+	}
+	return folder, nil
+}
+
+// ExportSavedFolder exports a folder of the memory saved in configFolder to a transfer file
+func ExportSavedFolder(configFolder string, folderPath string, filePath string) error {
+	memory, err := ReadMemoryFromDisk(path.Join(configFolder, memoryFileName))
+	if err != nil {
+		return fmt.Errorf("cannot read memory: %w", err)
+	}
+	folder, err := ResolveFolderPath(memory, folderPath)
+	if err != nil {
+		return err
+	}
+	return ExportFolderToFile(folder, filePath)
+}
+
+// ImportIntoSavedFolder imports a transfer file into a folder of the memory saved in configFolder,
+// the memory is saved only when the whole file is imported
+func ImportIntoSavedFolder(configFolder string, filePath string, folderPath string) error {
+	memoryFilePath := path.Join(configFolder, memoryFileName)
+	memory, err := ReadMemoryFromDisk(memoryFilePath)
+	if err != nil {
+		return fmt.Errorf("cannot read memory: %w", err)
+	}
+	folder, err := ResolveFolderPath(memory, folderPath)
+	if err != nil {
+		return err
+	}
+	if err = ImportFoldersFromFile(memory, folder, filePath, Registry); err != nil {
+		return err
+	}
+	return SaveMemoryToDisk(memoryFilePath, memory)
+}
+
+// exportAct writes a folder, given by its path or by its name, to a file: { 'HOME' 'LIB' } "lib.txt" export
+var exportAct = NewActionDesc("export", 2, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(2)
+	if err != nil {
+		return err
+	}
+	if elts[1].getType() != TYPE_STR {
+		return fmt.Errorf("expected a file name, found: %v", elts[1].getType())
+	}
+	folder, err := resolveMemoryFolder(system.Memory(), elts[0])
+	if err != nil {
+		return err
+	}
+	if err = ExportFolderToFile(folder, elts[1].asStringVar().value); err != nil {
+		return err
+	}
+	_, err = stack.PopN(2)
+	return err
+})
+
+// importAct reads the folders of a file into the current folder: "lib.txt" import
+var importAct = NewActionDesc("import", 1, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	if elts[0].getType() != TYPE_STR {
+		return fmt.Errorf("expected a file name, found: %v", elts[0].getType())
+	}
+	memory := system.Memory()
+	if err = ImportFoldersFromFile(memory, memory.getCurrentFolder(), elts[0].asStringVar().value, system.Registry()); err != nil {
+		return err
+	}
+	_, err = stack.Pop()
+	return err
+})
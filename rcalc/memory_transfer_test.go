@@ -0,0 +1,123 @@
+package rcalc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createTransferMemory creates HOME/LIB/SUB with variables in LIB and SUB
+func createTransferMemory(t *testing.T) (*InternalMemory, *MemoryFolder) {
+	memory := NewInternalMemory()
+	lib, err := memory.createFolder("LIB", memory.getRoot())
+	assert.NoError(t, err)
+	sub, err := memory.createFolder("SUB", lib)
+	assert.NoError(t, err)
+	_, err = memory.createVariable("SQ", lib, CreateProgramVariable([]Action{&dupOp, &mulOp}))
+	assert.NoError(t, err)
+	_, err = memory.createVariable("X", sub, ratVar(1, 3))
+	assert.NoError(t, err)
+	_, err = memory.createVariable("S", sub, strVar("a \"b\"\nc"))
+	assert.NoError(t, err)
+	return memory, lib
+}
+
+const expectedTransferText = `DIR LIB
+  SQ = << dup * >>
+  DIR SUB
+    X = 1/3
+    S = "a \"b\"\nc"
+  END
+END
+`
+
+func TestExportFolder(t *testing.T) {
+	_, lib := createTransferMemory(t)
+
+	var buffer bytes.Buffer
+	if assert.NoError(t, ExportFolder(&buffer, lib)) {
+		assert.Equal(t, expectedTransferText, buffer.String())
+	}
+}
+
+func TestExportNumbersAreExact(t *testing.T) {
+	memory := NewInternalMemory()
+	_, err := memory.createVariable("PI", memory.getRoot(), createNumericVariableFromFloat(3.14159))
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	if assert.NoError(t, ExportFolder(&buffer, memory.getRoot())) {
+		assert.Equal(t, "DIR HOME\n  PI = 3.14159\nEND\n", buffer.String())
+	}
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	memory := NewInternalMemory()
+	err := ImportFolders(memory, memory.getRoot(), strings.NewReader(expectedTransferText), Registry)
+	if !assert.NoError(t, err) {
+		return
+	}
+	lib, ok := memory.getRoot().subNode("LIB").(*MemoryFolder)
+	if assert.True(t, ok) {
+		var buffer bytes.Buffer
+		assert.NoError(t, ExportFolder(&buffer, lib))
+		assert.Equal(t, expectedTransferText, buffer.String())
+	}
+}
+
+func TestImportStructureErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		expectedError string
+	}{
+		{"end without dir", "DIR A\nEND\nEND\n", "line 3: END without DIR"},
+		{"missing end", "DIR A\n  DIR B\n  END\n", "missing END for folder A"},
+		{"unknown line", "DIR A\n  what\nEND\n", "line 2: expected DIR NAME, END or NAME = value, found: what"},
+		{"folder over variable", "DIR X\nEND\n", "line 1: X is a variable of folder HOME"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := NewInternalMemory()
+			_, err := memory.createVariable("X", memory.getRoot(), numVar(1))
+			assert.NoError(t, err)
+			err = ImportFolders(memory, memory.getRoot(), strings.NewReader(tt.text), Registry)
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestImportMergesFolders(t *testing.T) {
+	memory, lib := createTransferMemory(t)
+	err := ImportFolders(memory, memory.getRoot(), strings.NewReader("DIR LIB\n  DIR SUB\n  END\n  DIR NEW\n  END\nEND\n"), Registry)
+	if assert.NoError(t, err) {
+		assert.Len(t, memory.getRoot().subFolders, 1)
+		assert.Len(t, lib.subFolders, 2)
+		assert.NotNil(t, lib.subNode("SQ"))
+	}
+}
+
+func TestResolveFolderPath(t *testing.T) {
+	memory, lib := createTransferMemory(t)
+	tests := []struct {
+		path     string
+		expected *MemoryFolder
+	}{
+		{"", memory.getRoot()},
+		{"/", memory.getRoot()},
+		{"HOME", memory.getRoot()},
+		{"LIB", lib},
+		{"HOME/LIB/SUB", lib.subFolders[0]},
+		{"/LIB/SUB/", lib.subFolders[0]},
+	}
+	for _, tt := range tests {
+		folder, err := ResolveFolderPath(memory, tt.path)
+		if assert.NoError(t, err, tt.path) {
+			assert.Equal(t, tt.expected, folder, tt.path)
+		}
+	}
+	_, err := ResolveFolderPath(memory, "LIB/SQ")
+	assert.EqualError(t, err, "folder SQ not found in LIB/SQ")
+}
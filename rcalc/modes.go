@@ -0,0 +1,337 @@
+package rcalc
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/proto"
+	"troisdizaines.com/rcalc/rcalc/protostack"
+)
+
+type DisplayMode int
+
+const (
+	DISPLAY_STD DisplayMode = 0
+	DISPLAY_FIX DisplayMode = 1
+	DISPLAY_SCI DisplayMode = 2
+	DISPLAY_ENG DisplayMode = 3
+)
+
+type AngleMode int
+
+const (
+	ANGLE_RAD  AngleMode = 0
+	ANGLE_DEG  AngleMode = 1
+	ANGLE_GRAD AngleMode = 2
+)
+
+type BinaryBase int
+
+const (
+	BASE_DEC BinaryBase = 0
+	BASE_HEX BinaryBase = 1
+	BASE_OCT BinaryBase = 2
+	BASE_BIN BinaryBase = 3
+)
+
+// maxWordSize word size of the binary integers is between 1 and 64 bits
+const maxWordSize = 64
+
+// maxDisplayDigits limits the digits count of the fix, sci and eng modes
+const maxDisplayDigits = 32
+
+// Modes settings of a calculator: display format of the numbers, unit of the angles and binary
+// integers format. Each system holds its own modes, they are given to the functions displaying
+// the values and to the operations depending on them.
+type Modes struct {
+	display DisplayMode
+	// digits number of decimals in fix and sci modes, of significant digits minus one in eng mode
+	digits int
+	angle  AngleMode
+	// base used to display binary integers
+	base BinaryBase
+	// wordSize number of bits of the binary integers
+	wordSize int
+}
+
+// checkpoint copy of the modes, restored when a command line fails or is undone
+func (m *Modes) checkpoint() Modes {
+	return *m
+}
+
+func (m *Modes) restore(checkpoint Modes) {
+	*m = checkpoint
+}
+
+// NewModes returns the default modes, the values are displayed with them when the modes of the
+// user must not apply: error messages, exported memory, listings of the programs
+func NewModes() *Modes {
+	return &Modes{display: DISPLAY_STD, angle: ANGLE_RAD, base: BASE_DEC, wordSize: maxWordSize}
+}
+
+func (m *Modes) SetDisplay(display DisplayMode, digits int) {
+	m.display = display
+	m.digits = digits
+}
+
+func (m *Modes) SetAngle(angle AngleMode) {
+	m.angle = angle
+}
+
+func (m *Modes) SetBase(base BinaryBase) {
+	m.base = base
+}
+
+func (m *Modes) SetWordSize(wordSize int) {
+	m.wordSize = wordSize
+}
+
+// wordMask keeps the bits of a binary integer inside the word size
+func (m *Modes) wordMask() uint64 {
+	return ^uint64(0) >> (maxWordSize - m.wordSize)
+}
+
+// Display of numbers
+
+// formatDecimal formats a number according to the display mode
+func (m *Modes) formatDecimal(d decimal.Decimal) string {
+	switch m.display {
+	case DISPLAY_FIX:
+		return d.StringFixed(int32(m.digits))
+	case DISPLAY_SCI:
+		return formatWithExponent(d, m.digits, 1)
+	case DISPLAY_ENG:
+		return formatWithExponent(d, m.digits, 3)
+	default:
+		return d.String()
+	}
+}
+
+func (m *Modes) formatFloat(f float64) string {
+	return m.formatDecimal(decimal.NewFromFloat(f))
+}
+
+// formatWithExponent displays digits+1 significant digits and an exponent multiple of exponentStep,
+// 1 for the scientific notation and 3 for the engineering one
+func formatWithExponent(d decimal.Decimal, digits int, exponentStep int) string {
+	if d.IsZero() {
+		return decimal.Zero.StringFixed(int32(digits)) + "E0"
+	}
+	exponent := d.NumDigits() - 1 + int(d.Exponent())
+	for {
+		displayedExponent := exponent - ((exponent%exponentStep)+exponentStep)%exponentStep
+		decimals := max(digits-(exponent-displayedExponent), 0)
+		mantissa := d.Shift(int32(-displayedExponent)).Round(int32(decimals))
+		// Rounding can add a digit like in 9.99 -> 10.0
+		if mantissa.Abs().LessThan(decimal.New(1, int32(exponent-displayedExponent+1))) {
+			return fmt.Sprintf("%sE%d", mantissa.StringFixed(int32(decimals)), displayedExponent)
+		}
+		exponent++
+	}
+}
+
+// Angles
+
+var piDecimal = decimal.RequireFromString("3.14159265358979323846264338327950288")
+
+// angleUnitsInHalfTurn value of a half turn in each angle unit
+var angleUnitsInHalfTurn = map[AngleMode]decimal.Decimal{
+	ANGLE_RAD:  piDecimal,
+	ANGLE_DEG:  decimal.NewFromInt(180),
+	ANGLE_GRAD: decimal.NewFromInt(200),
+}
+
+// toRadians converts an angle expressed in the angle unit
+func (m *Modes) toRadians(angle decimal.Decimal) decimal.Decimal {
+	if m.angle == ANGLE_RAD {
+		return angle
+	}
+	return angle.Mul(piDecimal).Div(angleUnitsInHalfTurn[m.angle])
+}
+
+// fromRadians converts an angle to the angle unit
+func (m *Modes) fromRadians(angle decimal.Decimal) decimal.Decimal {
+	if m.angle == ANGLE_RAD {
+		return angle
+	}
+	return angle.Mul(angleUnitsInHalfTurn[m.angle]).Div(piDecimal)
+}
+
+// Modes package
+
+// checkDigits checks the number at index 0 is a valid digits count for fix, sci and eng modes
+func checkDigits(elts ...Variable) (bool, error) {
+	if elts[0].getType() != TYPE_NUMERIC {
+		return false, fmt.Errorf("digits count must be a number, found: %v", elts[0].getType())
+	}
+	v := elts[0].asNumericVar().value
+	if !v.IsInteger() || v.IsNegative() || v.IntPart() > maxDisplayDigits {
+		return false, fmt.Errorf("%v is not a valid digits count, expected 0..%d", v, maxDisplayDigits)
+	}
+	return true, nil
+}
+
+func newDisplayModeWithDigitsAct(opCode string, display DisplayMode) ActionDesc {
+	return NewRawStackOpWithCheck(opCode, 1, checkDigits, func(system System, stack *Stack) error {
+		elts, err := stack.PeekN(1)
+		if err != nil {
+			return err
+		}
+		if _, err = checkDigits(elts...); err != nil {
+			return err
+		}
+		digits, _ := stack.Pop()
+		system.Modes().SetDisplay(display, int(digits.asNumericVar().value.IntPart()))
+		return nil
+	})
+}
+
+var fixAct = newDisplayModeWithDigitsAct("fix", DISPLAY_FIX)
+
+var sciAct = newDisplayModeWithDigitsAct("sci", DISPLAY_SCI)
+
+var stdAct = NewRawStackOpWithCheck("std", 0, CheckNoop, func(system System, stack *Stack) error {
+	system.Modes().SetDisplay(DISPLAY_STD, 0)
+	return nil
+})
+
+// engAct keeps the digits count of the current mode
+var engAct = NewRawStackOpWithCheck("eng", 0, CheckNoop, func(system System, stack *Stack) error {
+	system.Modes().SetDisplay(DISPLAY_ENG, system.Modes().digits)
+	return nil
+})
+
+func newAngleModeAct(opCode string, angle AngleMode) ActionDesc {
+	return NewRawStackOpWithCheck(opCode, 0, CheckNoop, func(system System, stack *Stack) error {
+		system.Modes().SetAngle(angle)
+		return nil
+	})
+}
+
+var degAct = newAngleModeAct("deg", ANGLE_DEG)
+
+var radAct = newAngleModeAct("rad", ANGLE_RAD)
+
+var gradAct = newAngleModeAct("grad", ANGLE_GRAD)
+
+var ModesPackage = ActionPackage{
+	name: "modes",
+	staticActions: []Action{
+		&fixAct,
+		&sciAct,
+		&stdAct,
+		&engAct,
+		&degAct,
+		&radAct,
+		&gradAct,
+	},
+	docs: map[string]ActionDoc{
+		"fix": {
+			Summary:  "displays the numbers with n decimals",
+			Stack:    "n ->",
+			Types:    "an integer",
+			Examples: []DocExample{{"2 fix 0.125", "0.13"}},
+		},
+		"sci": {
+			Summary:  "displays the numbers in scientific notation with n+1 significant digits",
+			Stack:    "n ->",
+			Types:    "an integer",
+			Examples: []DocExample{{"2 sci 1234", "1.23E3"}},
+		},
+		"std": {
+			Summary:  "displays the numbers with all their digits",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"2 fix std 0.125", "0.125"}},
+		},
+		"eng": {
+			Summary:  "displays the numbers in engineering notation, keeping the digits count",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"2 sci eng 12345", "12.3E3"}},
+		},
+		"deg": {
+			Summary:  "expresses the angles in degrees",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"deg 3 fix 180 cos", "-1.000"}},
+		},
+		"rad": {
+			Summary:  "expresses the angles in radians",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"rad 0 cos", "1"}},
+		},
+		"grad": {
+			Summary:  "expresses the angles in grads",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"grad 3 fix 200 cos", "-1.000"}},
+		},
+	},
+}
+
+// Persistence of the modes
+
+func CreateProtoFromModes(m *Modes) *protostack.Modes {
+	return &protostack.Modes{
+		Display:  protostack.DisplayMode(m.display),
+		Digits:   int32(m.digits),
+		Angle:    protostack.AngleMode(m.angle),
+		Base:     protostack.BinaryBase(m.base),
+		WordSize: int32(m.wordSize),
+	}
+}
+
+func (m *Modes) setFromProto(protoModes *protostack.Modes) {
+	m.display = DisplayMode(protoModes.GetDisplay())
+	m.digits = min(max(int(protoModes.GetDigits()), 0), maxDisplayDigits)
+	m.angle = AngleMode(protoModes.GetAngle())
+	m.base = BinaryBase(protoModes.GetBase())
+	m.wordSize = int(protoModes.GetWordSize())
+	if m.wordSize < 1 || m.wordSize > maxWordSize {
+		m.wordSize = maxWordSize
+	}
+}
+
+// ReadModesFromDisk restores the modes saved by a ModesSavingListener, default modes are kept
+// when the file cannot be read
+func ReadModesFromDisk(modesSavingPath string, m *Modes) {
+	file, err := os.ReadFile(modesSavingPath)
+	if err != nil {
+		return
+	}
+	protoModes := &protostack.Modes{}
+	if err = proto.Unmarshal(file, protoModes); err != nil {
+		GetLogger().Errorf("Error reading modes from %s: %v", modesSavingPath, err)
+		return
+	}
+	m.setFromProto(protoModes)
+}
+
+// ModesSavingListener saves the modes at the end of each command line
+type ModesSavingListener struct {
+	modes           *Modes
+	modesSavingPath string
+}
+
+var _ StackSessionListener = (*ModesSavingListener)(nil)
+
+func NewModesSavingListener(modesSavingPath string, m *Modes) *ModesSavingListener {
+	return &ModesSavingListener{modes: m, modesSavingPath: modesSavingPath}
+}
+
+func (ml *ModesSavingListener) SessionStart(s *Stack) {
+}
+
+func (ml *ModesSavingListener) SessionClose(s *Stack) {
+	protoModesBytes, err := proto.Marshal(CreateProtoFromModes(ml.modes))
+	if err != nil {
+		GetLogger().Errorf("Error saving modes: %v", err)
+		return
+	}
+	if err = os.WriteFile(ml.modesSavingPath, protoModesBytes, 0644); err != nil {
+		GetLogger().Errorf("Error saving modes: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package rcalc
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayModes(t *testing.T) {
+	tests := []struct {
+		display  DisplayMode
+		digits   int
+		value    string
+		expected string
+	}{
+		{DISPLAY_STD, 0, "1234.5678", "1234.5678"},
+		{DISPLAY_FIX, 2, "1234.5678", "1234.57"},
+		{DISPLAY_FIX, 3, "-2", "-2.000"},
+		{DISPLAY_SCI, 2, "1234.5678", "1.23E3"},
+		{DISPLAY_SCI, 2, "0.00099999", "1.00E-3"},
+		{DISPLAY_SCI, 3, "0", "0.000E0"},
+		{DISPLAY_ENG, 3, "12345", "12.35E3"},
+		{DISPLAY_ENG, 2, "-0.000123", "-123E-6"},
+		{DISPLAY_ENG, 2, "999.9", "1.00E3"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.expected), func(t *testing.T) {
+			modes := NewModes()
+			modes.SetDisplay(test.display, test.digits)
+			assert.Equal(t, test.expected, CreateNumericVariable(decimal.RequireFromString(test.value)).display(modes))
+		})
+	}
+}
+
+func TestDisplayModesOfStructuredVariables(t *testing.T) {
+	modes := NewModes()
+	modes.SetDisplay(DISPLAY_FIX, 1)
+	assert.Equal(t, "(3.0, -4.5)", cplxVar(3, -4.5).display(modes))
+	assert.Equal(t, "[ 1.0 2.5 ]", vecVar(1, 2.5).display(modes))
+	assert.Equal(t, "9.8_m/s^2", qtyVar(9.81, "m/s^2").display(modes))
+	assert.Equal(t, "{ 1.0 2.0 }", CreateListVariable([]Variable{numVar(1), numVar(2)}).display(modes))
+}
+
+func TestAngleModes(t *testing.T) {
+	tests := []struct {
+		commandLine string
+		expected    float64
+	}{
+		{"deg 180 cos", -1},
+		{"deg 60 cos", 0.5},
+		{"deg 1 atan", 45},
+		{"grad 0.5 asin", 100.0 / 3},
+		{"rad 0 cos", 1},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.commandLine), func(t *testing.T) {
+			stack := CreateStack()
+			system := CreateSystemInstance()
+			if assert.NoError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest(test.commandLine))) {
+				result, err := stack.Pop()
+				if assert.NoError(t, err) {
+					assert.InDelta(t, test.expected, result.asNumericVar().value.InexactFloat64(), 1e-9)
+				}
+			}
+		})
+	}
+}
+
+// splitCommandLineForTest creates the actions of a command line made of numbers and operations
+// without using the parser
+func splitCommandLineForTest(commandLine string) []Action {
+	var actions []Action
+	for _, word := range strings.Fields(commandLine) {
+		if number, err := decimal.NewFromString(word); err == nil {
+			actions = append(actions, &VariablePutOnStackActionDesc{value: CreateNumericVariable(number)})
+		} else {
+			actions = append(actions, Registry.GetAction(word))
+		}
+	}
+	return actions
+}
+
+func TestModesActions(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	assert.NoError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("4 fix grad")))
+	assert.Equal(t, Modes{display: DISPLAY_FIX, digits: 4, angle: ANGLE_GRAD, base: BASE_DEC, wordSize: 64}, *system.Modes())
+	assert.NoError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("eng")))
+	assert.Equal(t, Modes{display: DISPLAY_ENG, digits: 4, angle: ANGLE_GRAD, base: BASE_DEC, wordSize: 64}, *system.Modes())
+	assert.EqualError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("-1 sci")),
+		"action 2 (sci) failed: -1 is not a valid digits count, expected 0..32")
+	assert.Equal(t, 0, stack.Size())
+}
+
+func TestModesRollback(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	assert.Error(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("deg 2 fix 1 0 /")))
+	assert.Equal(t, *NewModes(), *system.Modes(), "the modes of a failing line are rolled back")
+}
+
+func TestModesOfEachSystem(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	other := CreateSystemInstance()
+	assert.NoError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("2 fix deg")))
+	assert.Equal(t, *NewModes(), *other.Modes(), "the modes of a system do not change the other ones")
+
+	assert.NoError(t, RunActionsInTransaction(other, stack, splitCommandLineForTest("180 cos")))
+	assert.Equal(t, "-0.60", stack.elts[0].display(system.Modes()), "180 radians")
+	assert.NoError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("drop 180 cos")))
+	assert.Equal(t, "-1.00", stack.elts[0].display(system.Modes()))
+}
+
+func TestModesPersistence(t *testing.T) {
+	modesPath := path.Join(t.TempDir(), "modes.protobuf")
+	stack := CreateStack()
+	modes := NewModes()
+	modes.SetDisplay(DISPLAY_SCI, 5)
+	modes.SetAngle(ANGLE_DEG)
+	stack.AddSessionListener(NewModesSavingListener(modesPath, modes))
+	assert.NoError(t, stack.StartSession())
+	assert.NoError(t, stack.CloseSession())
+
+	readModes := &Modes{}
+	ReadModesFromDisk(modesPath, readModes)
+	assert.Equal(t, Modes{display: DISPLAY_SCI, digits: 5, angle: ANGLE_DEG, base: BASE_DEC, wordSize: 64}, *readModes)
+}
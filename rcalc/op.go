@@ -2,6 +2,7 @@ package rcalc
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/shopspring/decimal"
@@ -33,7 +34,7 @@ func CheckGen(types []Type) CheckTypeFn {
 			observedType := elts[idx].getType()
 			if varType == TYPE_GENERIC {
 				break
-			} else if observedType != varType {
+			} else if !isOfType(elts[idx], varType) {
 				errors = append(errors, fmt.Sprintf("Type error at level %d, expected: %v, found: %v", idx+1, observedType, varType))
 			}
 		}
@@ -45,6 +46,120 @@ func CheckGen(types []Type) CheckTypeFn {
 	}
 }
 
+// OperationVariant implementation of an operation for a given list of argument types,
+// TYPE_GENERIC matches any type. Types are given from the deepest stack level to level 1.
+type OperationVariant struct {
+	types []Type
+	// checkFn optional additional check done once the types match (dimensions, ranges...)
+	checkFn CheckTypeFn
+	applyFn ModesOperationApplyFn
+}
+
+// ModesOperationApplyFn operation depending on the modes of the calculator, like the angle unit
+type ModesOperationApplyFn func(modes *Modes, elts ...Variable) []Variable
+
+func NewOperationVariant(applyFn PureOperationApplyFn, types ...Type) OperationVariant {
+	return NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		return applyFn(elts...)
+	}, types...)
+}
+
+func NewModesOperationVariant(applyFn ModesOperationApplyFn, types ...Type) OperationVariant {
+	return OperationVariant{types: types, applyFn: applyFn}
+}
+
+// WithCheck returns a copy of the variant with an additional check of its arguments
+func (v OperationVariant) WithCheck(checkFn CheckTypeFn) OperationVariant {
+	v.checkFn = checkFn
+	return v
+}
+
+func (v OperationVariant) matches(elts ...Variable) bool {
+	if len(elts) != len(v.types) {
+		return false
+	}
+	for idx, varType := range v.types {
+		if varType != TYPE_GENERIC && !isOfType(elts[idx], varType) {
+			return false
+		}
+	}
+	return true
+}
+
+// isOfType checks the type of a variable, rationals being also numbers
+func isOfType(v Variable, varType Type) bool {
+	return v.getType() == varType || (varType == TYPE_NUMERIC && v.getType() == TYPE_RATIONAL)
+}
+
+func findVariant(variants []OperationVariant, elts ...Variable) (OperationVariant, bool) {
+	for _, variant := range variants {
+		if variant.matches(elts...) {
+			return variant, true
+		}
+	}
+	return OperationVariant{}, false
+}
+
+// CheckVariants accepts the arguments if one of the variants matches their types
+func CheckVariants(opCode string, variants []OperationVariant) CheckTypeFn {
+	return func(elts ...Variable) (bool, error) {
+		if variant, ok := findVariant(variants, elts...); ok {
+			if variant.checkFn != nil {
+				return variant.checkFn(elts...)
+			}
+			return true, nil
+		}
+		if err := checkQuantitiesUsedAsNumbers(variants, elts...); err != nil {
+			return false, err
+		}
+		typeNames := make([]string, len(elts))
+		for idx, elt := range elts {
+			typeNames[idx] = elt.getType().String()
+		}
+		return false, fmt.Errorf("unsupported argument types (%s) for %s", strings.Join(typeNames, ", "), opCode)
+	}
+}
+
+// checkQuantitiesUsedAsNumbers gives a hint when the arguments would be accepted without their units
+func checkQuantitiesUsedAsNumbers(variants []OperationVariant, elts ...Variable) error {
+	values := make([]Variable, len(elts))
+	var quantity Variable
+	for idx, elt := range elts {
+		values[idx] = elt
+		if elt.getType() == TYPE_QUANTITY {
+			values[idx] = CreateNumericVariable(elt.asQuantityVar().value)
+			quantity = elt
+		}
+	}
+	if _, ok := findVariant(variants, values...); ok && quantity != nil {
+		return quantityUsedAsNumberError(quantity)
+	}
+	return nil
+}
+
+func quantityUsedAsNumberError(quantity Variable) error {
+	return fmt.Errorf("%s has a unit, use uval or ubase to get a number", quantity.display(NewModes()))
+}
+
+// VariantsApplyFn applies the first variant matching the arguments types
+func VariantsApplyFn(variants []OperationVariant) OperationApplyFn {
+	return func(system System, elts ...Variable) []Variable {
+		variant, ok := findVariant(variants, elts...)
+		if !ok {
+			panic("no operation variant matching the arguments, types must be checked first")
+		}
+		return variant.applyFn(system.Modes(), elts...)
+	}
+}
+
+func NewVariantsOp(opCode string, nbArgs int, nbResults int, variants ...OperationVariant) OperationDesc {
+	return NewOperationDesc(opCode, nbArgs, CheckVariants(opCode, variants), nbResults, VariantsApplyFn(variants))
+}
+
+func NewExpandableVariantsOp(opCode string, nbArgs int, nbResults int, variants ...OperationVariant) OperationDesc {
+	return NewExpandableOperationDesc(opCode, nbArgs, CheckVariants(opCode, variants), nbResults, VariantsApplyFn(variants))
+}
+
 func ExpandableOpToActionFn(opFn PureOperationApplyFn) OperationApplyFn {
 	return func(system System, elts ...Variable) []Variable {
 
@@ -66,19 +181,59 @@ func NewRawStackOpWithCheck(opCode string, nbArgs int, checkFn CheckTypeFn, fn A
 
 // Tooling for Numeric (Decimal) functions
 
+// GetEltAsNumeric returns the value of a number, rationals are converted to decimals
 func GetEltAsNumeric(elts []Variable, idx int) decimal.Decimal {
+	if elts[idx].getType() == TYPE_RATIONAL {
+		return elts[idx].asRationalVar().decimalValue()
+	}
 	return elts[idx].asNumericVar().value
 }
 
 func CheckAllNumerics(elts ...Variable) (bool, error) {
 	for _, e := range elts {
-		if e.getType() != TYPE_NUMERIC {
+		if e.getType() == TYPE_QUANTITY {
+			return false, quantityUsedAsNumberError(e)
+		}
+		if !isOfType(e, TYPE_NUMERIC) {
 			return false, nil
 		}
 	}
 	return true, nil
 }
 
+// Tooling for exact computations on integers and rationals
+
+func isExactNumber(v Variable) bool {
+	return v.getType() == TYPE_RATIONAL || (v.getType() == TYPE_NUMERIC && v.asNumericVar().value.IsInteger())
+}
+
+func GetEltAsRational(elts []Variable, idx int) *big.Rat {
+	if elts[idx].getType() == TYPE_RATIONAL {
+		return elts[idx].asRationalVar().value
+	}
+	return new(big.Rat).SetInt(elts[idx].asNumericVar().value.BigInt())
+}
+
+// A2R1RationalFn exact version of an A2R1NumericFn, ok is false when no exact result can be computed
+type A2R1RationalFn func(r1 *big.Rat, r2 *big.Rat) (result *big.Rat, ok bool)
+
+// A2R1ExactNumericApplyFn computes exactly with rationalFn when both arguments are integers or rationals
+// and falls back to decimalFn otherwise, arguments are given in the same order as A2R1NumericApplyFn
+func A2R1ExactNumericApplyFn(decimalFn A2R1NumericFn, rationalFn A2R1RationalFn) PureOperationApplyFn {
+	return func(elts ...Variable) []Variable {
+		if isExactNumber(elts[0]) && isExactNumber(elts[1]) {
+			if result, ok := rationalFn(GetEltAsRational(elts, 1), GetEltAsRational(elts, 0)); ok {
+				return []Variable{CreateExactNumberVariable(result)}
+			}
+		}
+		return A2R1NumericApplyFn(decimalFn)(elts...)
+	}
+}
+
+func NewA2R1ExactNumericVariant(decimalFn A2R1NumericFn, rationalFn A2R1RationalFn) OperationVariant {
+	return NewOperationVariant(A2R1ExactNumericApplyFn(decimalFn, rationalFn), TYPE_NUMERIC, TYPE_NUMERIC)
+}
+
 type A1R1NumericFn func(num1 decimal.Decimal) decimal.Decimal
 
 func A1R1NumericApplyFn(f A1R1NumericFn) PureOperationApplyFn {
@@ -115,7 +270,7 @@ func GetEltAsBoolean(elts []Variable, idx int) bool {
 }
 
 func CheckAllBooleans(elts ...Variable) (bool, error) {
-	fmt.Printf("CheckAllBooleans %v\n", elts)
+	GetLogger().Debugf("CheckAllBooleans %v", elts)
 	for _, e := range elts {
 		if e.getType() != TYPE_BOOL {
 			return false, nil
@@ -172,7 +327,7 @@ func NewA2NumericR1BooleanOp(opCode string, numericToBooleanFunc A2NumericR1Bool
 }
 
 var VersionOp = NewOperationDesc(
-	"VERSION",
+	"version",
 	0,
 	func(elts ...Variable) (bool, error) { return true, nil },
 	1,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 )
 
@@ -15,7 +16,7 @@ func TestAddApply(t *testing.T) {
 	stack := CreateStack()
 	stack.Push(i1)
 	stack.Push(i2)
-	runtimeContext := CreateRuntimeContext(nil, stack)
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
 	err := addOp.Apply(runtimeContext)
 	if assert.NoError(t, err) {
 		i3, err := stack.Pop()
@@ -77,7 +78,7 @@ func TestExpandableApply(t *testing.T) {
 		stack := CreateStack()
 		stack.Push(i1)
 		stack.Push(i2)
-		runtimeContext := CreateRuntimeContext(nil, stack)
+		runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
 		err := expandableTestOp.Apply(runtimeContext)
 		if assert.NoError(t, err) {
 			i3, err := stack.Pop()
@@ -100,11 +101,11 @@ func TestExpandableApply(t *testing.T) {
 		stack := CreateStack()
 		stack.Push(i1)
 		stack.Push(i2)
-		runtimeContext := CreateRuntimeContext(nil, stack)
+		runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
 		err := expandableTestOp.Apply(runtimeContext)
 		if assert.NoError(t, err) {
 			i3, err := stack.Pop()
-			fmt.Printf("%s\n%s\n-> %s\n", i1.display(), i2.display(), i3.display())
+			fmt.Printf("%s\n%s\n-> %s\n", i1.display(NewModes()), i2.display(NewModes()), i3.display(NewModes()))
 			if assert.NoError(t, err) {
 				assert.Equal(t, TYPE_LIST, i3.getType())
 				i3List := i3.asListVar()
@@ -114,18 +115,18 @@ func TestExpandableApply(t *testing.T) {
 
 				i3List0 := i3List.items[0].asListVar()
 				i3List1 := i3List.items[1].asListVar()
-				assert.Equal(t, 2, i3List0.Size(), i3List0.display())
-				assert.Equal(t, 2, i3List1.Size(), i3List1.display())
+				assert.Equal(t, 2, i3List0.Size(), i3List0.display(NewModes()))
+				assert.Equal(t, 2, i3List1.Size(), i3List1.display(NewModes()))
 
 				i3List00 := i3List0.items[0].asNumericVar()
 				i3List01 := i3List0.items[1].asNumericVar()
 				i3List10 := i3List1.items[0].asNumericVar()
 				i3List11 := i3List1.items[1].asNumericVar()
 
-				assert.True(t, decimal.NewFromInt(3).Equal(i3List00.value), i3List00.display())
-				assert.True(t, decimal.NewFromInt(5).Equal(i3List01.value), i3List01.display())
-				assert.True(t, decimal.NewFromInt(13).Equal(i3List10.value), i3List10.display())
-				assert.True(t, decimal.NewFromInt(15).Equal(i3List11.value), i3List11.display())
+				assert.True(t, decimal.NewFromInt(3).Equal(i3List00.value), i3List00.display(NewModes()))
+				assert.True(t, decimal.NewFromInt(5).Equal(i3List01.value), i3List01.display(NewModes()))
+				assert.True(t, decimal.NewFromInt(13).Equal(i3List10.value), i3List10.display(NewModes()))
+				assert.True(t, decimal.NewFromInt(15).Equal(i3List11.value), i3List11.display(NewModes()))
 			}
 		}
 	})
@@ -138,11 +139,11 @@ func TestExpandableApply(t *testing.T) {
 		stack := CreateStack()
 		stack.Push(i1)
 		stack.Push(i2)
-		runtimeContext := CreateRuntimeContext(nil, stack)
+		runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
 		err := addOp.Apply(runtimeContext)
 		if assert.NoError(t, err) {
 			i3, err := stack.Pop()
-			fmt.Printf("%s\n%s\n-> %s\n", i1.display(), i2.display(), i3.display())
+			fmt.Printf("%s\n%s\n-> %s\n", i1.display(NewModes()), i2.display(NewModes()), i3.display(NewModes()))
 			if assert.NoError(t, err) {
 				assert.Equal(t, TYPE_LIST, i3.getType())
 				i3List := i3.asListVar()
@@ -150,8 +151,8 @@ func TestExpandableApply(t *testing.T) {
 				assert.Equal(t, TYPE_NUMERIC, i3List.items[0].getType())
 				assert.Equal(t, TYPE_NUMERIC, i3List.items[1].getType())
 
-				assert.True(t, decimal.NewFromInt(8).Equal(i3List.items[0].asNumericVar().value), i3List.items[0].display())
-				assert.True(t, decimal.NewFromInt(28).Equal(i3List.items[1].asNumericVar().value), i3List.items[1].display())
+				assert.True(t, decimal.NewFromInt(8).Equal(i3List.items[0].asNumericVar().value), i3List.items[0].display(NewModes()))
+				assert.True(t, decimal.NewFromInt(28).Equal(i3List.items[1].asNumericVar().value), i3List.items[1].display(NewModes()))
 			}
 		}
 	})
@@ -168,7 +169,7 @@ func TestExpandableApply(t *testing.T) {
 		stack := CreateStack()
 		stack.Push(i1)
 		stack.Push(i2)
-		runtimeContext := CreateRuntimeContext(nil, stack)
+		runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
 		err := expandableTestOp.Apply(runtimeContext)
 		if assert.NoError(t, err) {
 
@@ -180,7 +181,7 @@ func TestExpandableApply(t *testing.T) {
 				r2, err := stack.Pop()
 				assert.NoError(t, err)
 
-				fmt.Printf("%s\n%s\n-> %s\n%s\n", i1.display(), i2.display(), r1.display(), r2.display())
+				fmt.Printf("%s\n%s\n-> %s\n%s\n", i1.display(NewModes()), i2.display(NewModes()), r1.display(NewModes()), r2.display(NewModes()))
 				if assert.NoError(t, err) {
 					assert.Equal(t, TYPE_LIST, r1.getType())
 					r1List := r1.asListVar()
@@ -194,12 +195,77 @@ func TestExpandableApply(t *testing.T) {
 					assert.Equal(t, TYPE_NUMERIC, r2List.items[0].getType(), "Expected TYPE_NUMERIC(1) and got other type")
 					assert.Equal(t, TYPE_NUMERIC, r2List.items[1].getType(), "Expected TYPE_NUMERIC(1) and got other type")
 
-					assert.True(t, decimal.NewFromInt(5).Equal(r1List.items[0].asNumericVar().value), "%s (expected %d)", r1List.items[0].display(), 5)
-					assert.True(t, decimal.NewFromInt(15).Equal(r1List.items[1].asNumericVar().value), "%s (expected %d)", r1List.items[1].display(), 15)
-					assert.True(t, decimal.NewFromInt(3).Equal(r2List.items[0].asNumericVar().value), "%s (expected %d)", r2List.items[0].display(), 3)
-					assert.True(t, decimal.NewFromInt(13).Equal(r2List.items[1].asNumericVar().value), "%s (expected %d)", r2List.items[1].display(), 13)
+					assert.True(t, decimal.NewFromInt(5).Equal(r1List.items[0].asNumericVar().value), "%s (expected %d)", r1List.items[0].display(NewModes()), 5)
+					assert.True(t, decimal.NewFromInt(15).Equal(r1List.items[1].asNumericVar().value), "%s (expected %d)", r1List.items[1].display(NewModes()), 15)
+					assert.True(t, decimal.NewFromInt(3).Equal(r2List.items[0].asNumericVar().value), "%s (expected %d)", r2List.items[0].display(NewModes()), 3)
+					assert.True(t, decimal.NewFromInt(13).Equal(r2List.items[1].asNumericVar().value), "%s (expected %d)", r2List.items[1].display(NewModes()), 13)
 				}
 			}
 		}
 	})
 }
+
+func ratVar(num int64, denom int64) Variable {
+	return CreateRationalVariable(big.NewRat(num, denom))
+}
+
+func TestExactNumericOperationsErrors(t *testing.T) {
+	tests := []struct {
+		action Action
+		inputs []Variable
+	}{
+		{&divOp, []Variable{numVar(1), numVar(0)}},
+		{&divOp, []Variable{ratVar(1, 2), numVar(0)}},
+		{&powOp, []Variable{numVar(0), numVar(-1)}},
+		{&powOp, []Variable{numVar(0), createNumericVariableFromFloat(-0.5)}},
+		{&powOp, []Variable{numVar(0), ratVar(-1, 2)}},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			assert.EqualError(t, runtimeContext.RunAction(test.action), "division by zero")
+			assert.Equal(t, len(test.inputs), stack.Size())
+		})
+	}
+}
+
+func TestExactNumericOperations(t *testing.T) {
+	tests := []struct {
+		action   Action
+		inputs   []Variable
+		expected Variable
+	}{
+		{&divOp, []Variable{numVar(1), numVar(3)}, ratVar(1, 3)},
+		{&mulOp, []Variable{ratVar(1, 3), numVar(3)}, numVar(1)},
+		{&addOp, []Variable{ratVar(1, 3), ratVar(1, 6)}, ratVar(1, 2)},
+		{&subOp, []Variable{numVar(1), ratVar(1, 3)}, ratVar(2, 3)},
+		{&divOp, []Variable{ratVar(1, 2), ratVar(1, 4)}, numVar(2)},
+		{&powOp, []Variable{numVar(2), numVar(-1)}, ratVar(1, 2)},
+		{&powOp, []Variable{ratVar(2, 3), numVar(2)}, ratVar(4, 9)},
+		{&powOp, []Variable{numVar(0), numVar(0)}, numVar(1)},
+		{&powOp, []Variable{createNumericVariableFromFloat(0.5), numVar(0)}, numVar(1)},
+		{&powOp, []Variable{numVar(0), numVar(2)}, numVar(0)},
+		{&addOp, []Variable{ratVar(1, 2), CreateNumericVariable(decimal.RequireFromString("0.25"))}, CreateNumericVariable(decimal.RequireFromString("0.75"))},
+		{&toNumOp, []Variable{ratVar(1, 4)}, CreateNumericVariable(decimal.RequireFromString("0.25"))},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			if assert.NoError(t, runtimeContext.RunAction(test.action)) {
+				result, err := stack.Pop()
+				if assert.NoError(t, err) {
+					assert.Equal(t, test.expected.getType(), result.getType())
+					assert.Equal(t, test.expected.display(NewModes()), result.display(NewModes()))
+				}
+			}
+		})
+	}
+}
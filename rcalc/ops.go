@@ -1,87 +1,304 @@
 package rcalc
 
 import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"slices"
+
 	"github.com/shopspring/decimal"
 	"gonum.org/v1/gonum/stat/combin"
 )
 
 // Arithmetic package
 
-var addOp = NewExpandedA2R1NumericOp("+", func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
-	return num1.Add(num2)
-})
+// addOp adds numbers, complex numbers, vectors and matrices and concatenates strings, the
+// other argument of a concatenation is converted to a string
+var addOp = NewExpandableVariantsOp("+", 2, 1,
+	slices.Concat([]OperationVariant{
+		NewA2R1ExactNumericVariant(func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
+			return num1.Add(num2)
+		}, func(r1 *big.Rat, r2 *big.Rat) (*big.Rat, bool) {
+			return new(big.Rat).Add(r1, r2), true
+		}),
+		NewModesOperationVariant(concatStringsApplyFn, TYPE_STR, TYPE_GENERIC),
+		NewModesOperationVariant(concatStringsApplyFn, TYPE_GENERIC, TYPE_STR),
+		addVectorsVariant,
+		addMatricesVariant,
+		addQuantitiesVariant,
+		addBinariesVariant,
+	}, NewA2R1ComplexVariants(func(c1 complex128, c2 complex128) complex128 {
+		return c1 + c2
+	}))...,
+)
 
-var subOp = NewExpandedA2R1NumericOp("-", func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
-	return num2.Sub(num1)
-})
+var subOp = NewExpandableVariantsOp("-", 2, 1,
+	slices.Concat([]OperationVariant{
+		NewA2R1ExactNumericVariant(func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
+			return num2.Sub(num1)
+		}, func(r1 *big.Rat, r2 *big.Rat) (*big.Rat, bool) {
+			return new(big.Rat).Sub(r2, r1), true
+		}),
+		subVectorsVariant,
+		subMatricesVariant,
+		subQuantitiesVariant,
+		subBinariesVariant,
+	}, NewA2R1ComplexVariants(func(c1 complex128, c2 complex128) complex128 {
+		return c2 - c1
+	}))...,
+)
 
-var mulOp = NewExpandedA2R1NumericOp("*", func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
-	return num1.Mul(num2)
-})
+// mulOp multiplies numbers and complex numbers, scales vectors and matrices and computes matrix products
+var mulOp = NewExpandableVariantsOp("*", 2, 1,
+	slices.Concat([]OperationVariant{
+		NewA2R1ExactNumericVariant(func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
+			return num1.Mul(num2)
+		}, func(r1 *big.Rat, r2 *big.Rat) (*big.Rat, bool) {
+			return new(big.Rat).Mul(r1, r2), true
+		}),
+		mulMatricesVariant,
+		mulMatrixVectorVariant,
+		mulBinariesVariant,
+	}, scaleVariants, mulQuantitiesVariants, NewA2R1ComplexVariants(func(c1 complex128, c2 complex128) complex128 {
+		return c1 * c2
+	}))...,
+)
 
-var divOp = NewExpandedA2R1NumericOp("/", func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
-	return num2.Div(num1)
-})
+var divOp = NewExpandableVariantsOp("/", 2, 1,
+	slices.Concat([]OperationVariant{
+		NewA2R1ExactNumericVariant(func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
+			return num2.Div(num1)
+		}, func(r1 *big.Rat, r2 *big.Rat) (*big.Rat, bool) {
+			if r1.Sign() == 0 {
+				return nil, false
+			}
+			return new(big.Rat).Quo(r2, r1), true
+		}).WithCheck(checkNonZeroDivisor),
+		divBinariesVariant,
+	}, divQuantitiesVariants, NewA2R1ComplexVariants(func(c1 complex128, c2 complex128) complex128 {
+		return c2 / c1
+	}))...,
+)
+
+var powOp = NewExpandableVariantsOp("^", 2, 1,
+	slices.Concat([]OperationVariant{
+		NewOperationVariant(powNumbersApplyFn, TYPE_NUMERIC, TYPE_NUMERIC).WithCheck(checkNonZeroBaseOfNegativePower),
+		powQuantityVariant,
+	}, NewA2R1ComplexVariants(func(c1 complex128, c2 complex128) complex128 {
+		return cmplx.Pow(c2, c1)
+	}))...,
+)
+
+// checkNonZeroBaseOfNegativePower rejects 0 raised to a negative power, it is a division by zero
+func checkNonZeroBaseOfNegativePower(elts ...Variable) (bool, error) {
+	if GetEltAsNumeric(elts, 0).IsZero() && GetEltAsNumeric(elts, 1).IsNegative() {
+		return false, errDivisionByZero
+	}
+	return true, nil
+}
+
+// powNumbersApplyFn computes exactly when possible, 0^0 is 1. A negative base with a non-integer exponent
+// gives a complex number, like sqrt of a negative number.
+func powNumbersApplyFn(elts ...Variable) []Variable {
+	base := GetEltAsNumeric(elts, 0)
+	exponent := GetEltAsNumeric(elts, 1)
+	if base.IsNegative() && !exponent.IsInteger() {
+		return []Variable{CreateComplexVariable(cmplx.Pow(complex(base.InexactFloat64(), 0), complex(exponent.InexactFloat64(), 0)))}
+	}
+	return A2R1ExactNumericApplyFn(func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
+		return num2.Pow(num1)
+	}, ratPow)(elts...)
+}
+
+// maxExactExponent limits the size of the exact results of ^
+const maxExactExponent = 1024
+
+// ratPow computes r2^r1 exactly when r1 is a reasonably small integer, any number raised to the
+// power 0 is 1
+func ratPow(r1 *big.Rat, r2 *big.Rat) (*big.Rat, bool) {
+	if !r1.IsInt() || !r1.Num().IsInt64() {
+		return nil, false
+	}
+	exponent := r1.Num().Int64()
+	if exponent == 0 {
+		return big.NewRat(1, 1), true
+	}
+	if exponent > maxExactExponent || exponent < -maxExactExponent || (exponent < 0 && r2.Sign() == 0) {
+		return nil, false
+	}
+	absExponent := big.NewInt(max(exponent, -exponent))
+	num := new(big.Int).Exp(r2.Num(), absExponent, nil)
+	denom := new(big.Int).Exp(r2.Denom(), absExponent, nil)
+	if exponent < 0 {
+		num, denom = denom, num
+	}
+	return new(big.Rat).SetFrac(num, denom), true
+}
 
-var powOp = NewExpandedA2R1NumericOp("^", func(num1 decimal.Decimal, num2 decimal.Decimal) decimal.Decimal {
-	return num2.Pow(num1)
+// toNumOp converts rationals to decimals
+var toNumOp = NewA1R1NumericOp("->num", func(num decimal.Decimal) decimal.Decimal {
+	return num
 })
 
 var ArithmeticPackage = ActionPackage{
-	staticActions: []Action{&addOp, &subOp, &mulOp, &divOp, &powOp},
+	name:          "arithmetic",
+	staticActions: []Action{&addOp, &subOp, &mulOp, &divOp, &powOp, &sqrtOp, &toNumOp},
+	aliases:       map[string]string{"√": "sqrt"},
+	docs: map[string]ActionDoc{
+		"+": {
+			Summary:  "adds numbers, vectors, matrices, quantities and binary integers, concatenates strings",
+			Stack:    "x y -> x+y",
+			Types:    "numbers, rationals, complex numbers, vectors, matrices, quantities, binary integers, strings with any value, lists item by item",
+			Examples: []DocExample{{"2 3 +", "5"}, {"1/2 1/3 +", "5/6"}, {"\"n=\" 3 +", "\"n=3\""}, {"{ 1 2 } 10 +", "{ 11 12 }"}},
+		},
+		"-": {
+			Summary:  "subtracts level 1 from level 2",
+			Stack:    "x y -> x-y",
+			Types:    "numbers, rationals, complex numbers, vectors, matrices, quantities, binary integers, lists item by item",
+			Examples: []DocExample{{"5 3 -", "2"}, {"[ 3 4 ] [ 1 1 ] -", "[ 2 3 ]"}},
+		},
+		"*": {
+			Summary:  "multiplies numbers, scales vectors and matrices and computes matrix products",
+			Stack:    "x y -> x*y",
+			Types:    "numbers, rationals, complex numbers, vectors and matrices, quantities, binary integers, lists item by item",
+			Examples: []DocExample{{"6 7 *", "42"}, {"[ 1 2 ] 3 *", "[ 3 6 ]"}},
+		},
+		"/": {
+			Summary:  "divides level 2 by level 1, the division of integers is exact, fails on a division by zero",
+			Stack:    "x y -> x/y",
+			Types:    "numbers, rationals, complex numbers, quantities, binary integers, lists item by item",
+			Examples: []DocExample{{"1 4 /", "1/4"}, {"1.5 4 /", "0.375"}},
+		},
+		"^": {
+			Summary:  "raises level 2 to the power of level 1, 0^0 is 1, 0 to a negative power fails and a negative number to a non-integer power is complex",
+			Stack:    "x y -> x^y",
+			Types:    "numbers, rationals, complex numbers, quantity and number, lists item by item",
+			Examples: []DocExample{{"2 10 ^", "1024"}, {"1/2 2 ^", "1/4"}},
+		},
+		"sqrt": {
+			Summary:  "square root, complex for negative numbers",
+			Stack:    "x -> sqrt(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"0.25 sqrt", "0.5"}, {"-4 sqrt", "(0, 2)"}},
+		},
+		"->num": {
+			Summary:  "converts a rational to a decimal number",
+			Stack:    "x -> x",
+			Types:    "numbers, rationals",
+			Examples: []DocExample{{"1/4 ->num", "0.25"}},
+		},
+	},
 }
 
-// Trigonometry package
+// Trigonometry package, real angles are expressed in the angle mode and complex ones in radians.
+// The derivatives of the algebraic functions are the ones of functions of radians, deriv refuses
+// them in the other angle modes.
 
-var sinOp = NewA1R1NumericOp("sin", func(num decimal.Decimal) decimal.Decimal {
-	return num.Sin()
-})
+// newA1R1AngleVariant applies f, a function of radians, to a real angle expressed in the angle mode
+func newA1R1AngleVariant(f A1R1NumericFn) OperationVariant {
+	return NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		return []Variable{CreateNumericVariable(f(modes.toRadians(GetEltAsNumeric(elts, 0))))}
+	}, TYPE_NUMERIC)
+}
+
+// newA1R1ArcVariant expresses the result of realFn, in radians, in the angle mode. Outside of
+// [-1, 1] the result is complex and expressed in radians.
+func newA1R1ArcVariant(realFn A1R1NumericFn, complexFn A1R1ComplexFn) OperationVariant {
+	return NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		num := GetEltAsNumeric(elts, 0)
+		if !isBetweenMinusOneAndOne(num) {
+			return []Variable{CreateComplexVariable(complexFn(complex(num.InexactFloat64(), 0)))}
+		}
+		return []Variable{CreateNumericVariable(modes.fromRadians(realFn(num)))}
+	}, TYPE_NUMERIC)
+}
+
+var sinOp = NewVariantsOp("sin", 1, 1,
+	newA1R1AngleVariant(decimal.Decimal.Sin),
+	NewA1R1ComplexVariant(cmplx.Sin),
+)
 
 var sinAlgDesc = AlgebraicFunctionDesc{
-	name:      "sin",
-	argsCount: 1,
-	fn: func(args ...decimal.Decimal) decimal.Decimal {
-		return args[0].Sin()
+	name:          "sin",
+	argsCount:     1,
+	angleArgument: true,
+	fn: func(modes *Modes, args ...decimal.Decimal) decimal.Decimal {
+		return modes.toRadians(args[0]).Sin()
+	},
+	derivative: func(reg *ActionRegistry, arg AlgebraicExpressionNode) (AlgebraicExpressionNode, error) {
+		return algFunctionCall(reg, "cos", arg)
 	},
 }
 
-var arcSinOp = NewA1R1NumericOp("asin", func(num decimal.Decimal) decimal.Decimal {
-	return num.Div(decimal.NewFromInt(1).Sub(num.Pow(decimal.NewFromInt(2))).Pow(decimal.New(5, -1))).Atan()
-})
+// arcSinOp returns a complex number outside of [-1, 1]
+var arcSinOp = NewVariantsOp("asin", 1, 1,
+	newA1R1ArcVariant(func(num decimal.Decimal) decimal.Decimal {
+		return decimal.NewFromFloat(math.Asin(num.InexactFloat64()))
+	}, cmplx.Asin),
+	NewA1R1ComplexVariant(cmplx.Asin),
+)
 
-var cosOp = NewA1R1NumericOp("cos", func(num decimal.Decimal) decimal.Decimal {
-	return num.Cos()
-})
+var cosOp = NewVariantsOp("cos", 1, 1,
+	newA1R1AngleVariant(decimal.Decimal.Cos),
+	NewA1R1ComplexVariant(cmplx.Cos),
+)
 
 var cosAlgDesc = AlgebraicFunctionDesc{
-	name:      "cos",
-	argsCount: 1,
-	fn: func(args ...decimal.Decimal) decimal.Decimal {
-		return args[0].Cos()
+	name:          "cos",
+	argsCount:     1,
+	angleArgument: true,
+	fn: func(modes *Modes, args ...decimal.Decimal) decimal.Decimal {
+		return modes.toRadians(args[0]).Cos()
+	},
+	derivative: func(reg *ActionRegistry, arg AlgebraicExpressionNode) (AlgebraicExpressionNode, error) {
+		sin, err := algFunctionCall(reg, "sin", arg)
+		if err != nil {
+			return nil, err
+		}
+		return algNeg(sin), nil
 	},
 }
 
-var arcCosOp = NewA1R1NumericOp("acos", func(num decimal.Decimal) decimal.Decimal {
-	return decimal.NewFromInt(1).Sub(num.Pow(decimal.NewFromInt(2))).Pow(decimal.New(5, -1)).Div(num).Atan()
-})
+// arcCosOp returns a complex number outside of [-1, 1]
+var arcCosOp = NewVariantsOp("acos", 1, 1,
+	newA1R1ArcVariant(func(num decimal.Decimal) decimal.Decimal {
+		return decimal.NewFromFloat(math.Acos(num.InexactFloat64()))
+	}, cmplx.Acos),
+	NewA1R1ComplexVariant(cmplx.Acos),
+)
 
-var tanOp = NewA1R1NumericOp("sin", func(num decimal.Decimal) decimal.Decimal {
-	return num.Tan()
-})
+var tanOp = NewVariantsOp("tan", 1, 1,
+	newA1R1AngleVariant(decimal.Decimal.Tan),
+	NewA1R1ComplexVariant(cmplx.Tan),
+)
 
 var tanAlgDesc = AlgebraicFunctionDesc{
-	name:      "tan",
-	argsCount: 1,
-	fn: func(args ...decimal.Decimal) decimal.Decimal {
-		return args[0].Tan()
+	name:          "tan",
+	argsCount:     1,
+	angleArgument: true,
+	fn: func(modes *Modes, args ...decimal.Decimal) decimal.Decimal {
+		return modes.toRadians(args[0]).Tan()
+	},
+	derivative: func(reg *ActionRegistry, arg AlgebraicExpressionNode) (AlgebraicExpressionNode, error) {
+		// 1 + tan(x)^2
+		tan, err := algFunctionCall(reg, "tan", arg)
+		if err != nil {
+			return nil, err
+		}
+		return algAdd(algNumberFromInt(1), algPow(tan, algNumberFromInt(2))), nil
 	},
 }
 
-var arcTanOp = NewA1R1NumericOp("atan", func(num decimal.Decimal) decimal.Decimal {
-	return num.Atan()
-})
+var arcTanOp = NewVariantsOp("atan", 1, 1,
+	NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		return []Variable{CreateNumericVariable(modes.fromRadians(GetEltAsNumeric(elts, 0).Atan()))}
+	}, TYPE_NUMERIC),
+	NewA1R1ComplexVariant(cmplx.Atan),
+)
 
 var TrigonometricPackage = ActionPackage{
+	name: "trigonometry",
 	staticActions: []Action{
 		&sinOp, &cosOp, &tanOp,
 		&arcSinOp, &arcCosOp, &arcTanOp,
@@ -89,6 +306,44 @@ var TrigonometricPackage = ActionPackage{
 	algrebraicFunctions: []AlgebraicFunctionDesc{
 		sinAlgDesc, cosAlgDesc, tanAlgDesc,
 	},
+	docs: map[string]ActionDoc{
+		"sin": {
+			Summary:  "sine, real angles are in the current angle mode and complex ones in radians",
+			Stack:    "x -> sin(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"0 sin", "0"}},
+		},
+		"cos": {
+			Summary:  "cosine, real angles are in the current angle mode and complex ones in radians",
+			Stack:    "x -> cos(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"0 cos", "1"}},
+		},
+		"tan": {
+			Summary:  "tangent, real angles are in the current angle mode and complex ones in radians",
+			Stack:    "x -> tan(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"0 tan", "0"}},
+		},
+		"asin": {
+			Summary:  "arc sine in the current angle mode, complex outside of [-1, 1]",
+			Stack:    "x -> asin(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"0 asin", "0"}},
+		},
+		"acos": {
+			Summary:  "arc cosine in the current angle mode, complex outside of [-1, 1]",
+			Stack:    "x -> acos(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"1 acos", "0"}},
+		},
+		"atan": {
+			Summary:  "arc tangent in the current angle mode",
+			Stack:    "x -> atan(x)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"0 atan", "0"}},
+		},
+	},
 }
 
 // Logic Package
@@ -108,7 +363,7 @@ var getNumOp = NewA2NumericR1BooleanOp(">=", func(d1 decimal.Decimal, d2 decimal
 	return d2.GreaterThanOrEqual(d1)
 })
 
-var gtNumOp = NewA2NumericR1BooleanOp("<", func(d1 decimal.Decimal, d2 decimal.Decimal) bool {
+var gtNumOp = NewA2NumericR1BooleanOp(">", func(d1 decimal.Decimal, d2 decimal.Decimal) bool {
 	return d2.GreaterThan(d1)
 })
 
@@ -116,25 +371,112 @@ var negOp = NewA1R1BooleanOp("neg", func(b bool) bool {
 	return !b
 })
 
-var andOp = NewA2R1BooleanOp("and", func(b bool, b2 bool) bool {
-	return b && b2
-})
+// and, or, xor and not are logical operations on booleans and bitwise ones on binary integers
 
-var orOp = NewA2R1BooleanOp("or", func(b bool, b2 bool) bool {
-	return b || b2
-})
+var andOp = NewVariantsOp("and", 2, 1,
+	NewOperationVariant(A2R1BooleanApplyFn(func(b bool, b2 bool) bool {
+		return b && b2
+	}), TYPE_BOOL, TYPE_BOOL),
+	andBinariesVariant,
+)
 
-var xorOp = NewA2R1BooleanOp("xor", func(b bool, b2 bool) bool {
-	return b != b2
-})
+var orOp = NewVariantsOp("or", 2, 1,
+	NewOperationVariant(A2R1BooleanApplyFn(func(b bool, b2 bool) bool {
+		return b || b2
+	}), TYPE_BOOL, TYPE_BOOL),
+	orBinariesVariant,
+)
+
+var xorOp = NewVariantsOp("xor", 2, 1,
+	NewOperationVariant(A2R1BooleanApplyFn(func(b bool, b2 bool) bool {
+		return b != b2
+	}), TYPE_BOOL, TYPE_BOOL),
+	xorBinariesVariant,
+)
+
+var notOp = NewVariantsOp("not", 1, 1,
+	NewOperationVariant(A1R1BooleanApplyFn(func(b bool) bool {
+		return !b
+	}), TYPE_BOOL),
+	notBinaryVariant,
+)
 
 var xandOp = NewA2R1BooleanOp("xand", func(b bool, b2 bool) bool {
 	return b == b2
 })
 
 var BooleanLogicPackage = ActionPackage{
+	name: "logic",
 	staticActions: []Action{
-		&eqNumOp, &ltNumOp, &letNumOp, &gtNumOp, &getNumOp, &negOp, &andOp, &orOp, &xorOp, &xandOp,
+		&eqNumOp, &ltNumOp, &letNumOp, &gtNumOp, &getNumOp, &negOp, &andOp, &orOp, &xorOp, &xandOp, &notOp,
+	},
+	docs: map[string]ActionDoc{
+		"==": {
+			Summary:  "tests if two numbers are equal",
+			Stack:    "x y -> x==y",
+			Types:    "numbers",
+			Examples: []DocExample{{"2 2 ==", "true"}, {"2 3 ==", "false"}},
+		},
+		"<": {
+			Summary:  "tests if level 2 is less than level 1",
+			Stack:    "x y -> x<y",
+			Types:    "numbers",
+			Examples: []DocExample{{"2 3 <", "true"}, {"3 3 <", "false"}},
+		},
+		"<=": {
+			Summary:  "tests if level 2 is less than or equal to level 1",
+			Stack:    "x y -> x<=y",
+			Types:    "numbers",
+			Examples: []DocExample{{"2 3 <=", "true"}, {"3 3 <=", "true"}},
+		},
+		">": {
+			Summary:  "tests if level 2 is greater than level 1",
+			Stack:    "x y -> x>y",
+			Types:    "numbers",
+			Examples: []DocExample{{"3 2 >", "true"}, {"3 3 >", "false"}},
+		},
+		">=": {
+			Summary:  "tests if level 2 is greater than or equal to level 1",
+			Stack:    "x y -> x>=y",
+			Types:    "numbers",
+			Examples: []DocExample{{"2 3 >=", "false"}},
+		},
+		"neg": {
+			Summary:  "negates a boolean",
+			Stack:    "b -> not b",
+			Types:    "booleans",
+			Examples: []DocExample{{"1 1 == neg", "false"}},
+		},
+		"and": {
+			Summary:  "logical and of booleans, bitwise and of binary integers",
+			Stack:    "x y -> x and y",
+			Types:    "booleans, binary integers",
+			Examples: []DocExample{{"#1100b #1010b and", "#8d"}},
+		},
+		"or": {
+			Summary:  "logical or of booleans, bitwise or of binary integers",
+			Stack:    "x y -> x or y",
+			Types:    "booleans, binary integers",
+			Examples: []DocExample{{"#1100b #1010b or", "#14d"}},
+		},
+		"xor": {
+			Summary:  "logical exclusive or of booleans, bitwise one of binary integers",
+			Stack:    "x y -> x xor y",
+			Types:    "booleans, binary integers",
+			Examples: []DocExample{{"#1100b #1010b xor", "#6d"}},
+		},
+		"xand": {
+			Summary:  "true when two booleans are equal",
+			Stack:    "x y -> x xand y",
+			Types:    "booleans",
+			Examples: []DocExample{{"1 1 == 1 2 == xand", "false"}},
+		},
+		"not": {
+			Summary:  "logical not of a boolean, bitwise not of a binary integer",
+			Stack:    "x -> not x",
+			Types:    "booleans, binary integers",
+			Examples: []DocExample{{"1 2 == not", "true"}},
+		},
 	},
 }
 
@@ -157,9 +499,24 @@ var permOp = NewA2R1NumericOp("perm", func(p decimal.Decimal, n decimal.Decimal)
 })
 
 var StatPackage = ActionPackage{
+	name: "statistics",
 	staticActions: []Action{
 		&combOp, &permOp,
 	},
+	docs: map[string]ActionDoc{
+		"comb": {
+			Summary:  "number of combinations of p items among n",
+			Stack:    "n p -> C(n,p)",
+			Types:    "integers",
+			Examples: []DocExample{{"5 2 comb", "10"}},
+		},
+		"perm": {
+			Summary:  "number of arrangements of p items among n",
+			Stack:    "n p -> P(n,p)",
+			Types:    "integers",
+			Examples: []DocExample{{"5 2 perm", "20"}},
+		},
+	},
 }
 
 // Stack package
@@ -169,7 +526,7 @@ var dupOp = NewStackOp("dup", 1, 2, func(elts ...Variable) []Variable {
 })
 
 var dup2Op = NewStackOp("dup2", 2, 4, func(elts ...Variable) []Variable {
-	return []Variable{elts[1], elts[0], elts[1], elts[0]}
+	return []Variable{elts[0], elts[1], elts[0], elts[1]}
 })
 
 var dropOp = NewStackOp("drop", 1, 0, func(elts ...Variable) []Variable {
@@ -196,6 +553,7 @@ var dupNOp = NewRawStackOpWithCheck("dupn", 1, CheckFirstInt, func(system System
 		return err
 	}
 	stack.PushN(stackElts)
+	stack.PushN(stackElts)
 	return nil
 })
 
@@ -205,6 +563,7 @@ var depthAct = NewRawStackOpWithCheck("depth", 0, CheckNoop, func(system System,
 })
 
 var StackPackage = ActionPackage{
+	name: "stack",
 	staticActions: []Action{
 		&dupOp,
 		&dup2Op,
@@ -214,41 +573,165 @@ var StackPackage = ActionPackage{
 		&dupNOp,
 		&depthAct,
 	},
+	docs: map[string]ActionDoc{
+		"dup": {
+			Summary:  "duplicates level 1",
+			Stack:    "x -> x x",
+			Types:    "any",
+			Examples: []DocExample{{"1 dup", "1 1"}},
+		},
+		"dup2": {
+			Summary:  "duplicates levels 1 and 2",
+			Stack:    "x y -> x y x y",
+			Types:    "any",
+			Examples: []DocExample{{"1 2 dup2", "1 2 1 2"}},
+		},
+		"drop": {
+			Summary:  "removes level 1",
+			Stack:    "x ->",
+			Types:    "any",
+			Examples: []DocExample{{"1 2 drop", "1"}},
+		},
+		"drop2": {
+			Summary:  "removes levels 1 and 2",
+			Stack:    "x y ->",
+			Types:    "any",
+			Examples: []DocExample{{"1 2 3 drop2", "1"}},
+		},
+		"swap": {
+			Summary:  "exchanges levels 1 and 2",
+			Stack:    "x y -> y x",
+			Types:    "any",
+			Examples: []DocExample{{"1 2 swap", "2 1"}},
+		},
+		"dupn": {
+			Summary:  "duplicates the n levels above n",
+			Stack:    "x1 ... xn n -> x1 ... xn x1 ... xn",
+			Types:    "any, an integer for n",
+			Examples: []DocExample{{"1 2 3 2 dupn", "1 2 3 2 3"}},
+		},
+		"depth": {
+			Summary:  "number of levels of the stack",
+			Stack:    "-> n",
+			Types:    "none",
+			Examples: []DocExample{{"5 6 depth", "5 6 2"}},
+		},
+	},
+}
+
+// storeAct stores a value in the current folder, replacing the previous value: value 'NAME' sto
+var storeAct = NewActionDesc("sto", 2, CheckGen([]Type{TYPE_GENERIC, TYPE_ALG_EXPR}), func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(2)
+	if err != nil {
+		return err
+	}
+	name, err := memoryName(elts[1])
+	if err != nil {
+		return err
+	}
+	memory := system.Memory()
+	if _, err = memory.createVariable(name, memory.getCurrentFolder(), elts[0]); err != nil {
+		return err
+	}
+	_, err = stack.PopN(2)
+	return err
+})
+
+// findStoredVariable looks for a variable in the current folder, then in its parents up to HOME
+func findStoredVariable(memory Memory, name string) (*MemoryVariable, error) {
+	variable := memory.findVariable(name)
+	if variable == nil {
+		return nil, fmt.Errorf("variable %s not found in the current folder nor in its parents", name)
+	}
+	return variable, nil
 }
 
-var storeAct = NewActionDesc("sto",
-	2,
-	CheckGen([]Type{TYPE_ALG_EXPR, TYPE_GENERIC}),
-	func(system System, stack *Stack) error {
+var recallAct = NewActionDesc("rcl", 1, CheckGen([]Type{TYPE_ALG_EXPR}), func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	name, err := memoryName(elts[0])
+	if err != nil {
+		return err
+	}
+	variable, err := findStoredVariable(system.Memory(), name)
+	if err != nil {
+		return err
+	}
+	_, _ = stack.Pop()
+	stack.Push(variable.value)
+	return nil
+})
+
+// updateStoredVariable replaces the value of a stored variable by the result of op applied to
+// this value and operand
+func updateStoredVariable(system System, name string, op *OperationDesc, operand Variable) (Variable, error) {
+	variable, err := findStoredVariable(system.Memory(), name)
+	if err != nil {
+		return nil, err
+	}
+	opStack := CreateStack()
+	opStack.Push(variable.value)
+	opStack.Push(operand)
+	if err = CreateRuntimeContext(system, opStack).RunAction(op); err != nil {
+		return nil, fmt.Errorf("cannot update %s: %w", name, err)
+	}
+	result, err := opStack.Pop()
+	if err != nil {
+		return nil, err
+	}
+	variable.value = result
+	return result, nil
+}
 
-		name, _ := stack.Pop()
-		value, _ := stack.Pop()
-		memory := system.Memory()
-		rootFolder := memory.getRoot()
-		_, err := memory.createVariable(name.asIdentifierVar().value, rootFolder, value)
+// newStoreWithOpAct updates a stored variable in place: value 'NAME' sto+ stores NAME + value in NAME
+func newStoreWithOpAct(opCode string, op *OperationDesc) ActionDesc {
+	return NewActionDesc(opCode, 2, CheckGen([]Type{TYPE_GENERIC, TYPE_ALG_EXPR}), func(system System, stack *Stack) error {
+		elts, err := stack.PeekN(2)
+		if err != nil {
+			return err
+		}
+		name, err := memoryName(elts[1])
+		if err != nil {
+			return err
+		}
+		if _, err = updateStoredVariable(system, name, op, elts[0]); err != nil {
+			return err
+		}
+		_, err = stack.PopN(2)
 		return err
 	})
+}
+
+var storeAddAct = newStoreWithOpAct("sto+", &addOp)
 
-var loadAct = NewActionDesc("load",
-	1,
-	CheckGen([]Type{TYPE_ALG_EXPR}),
-	func(system System, stack *Stack) error {
+var storeSubAct = newStoreWithOpAct("sto-", &subOp)
 
-		variable, err := stack.Pop()
+// newIncrementAct adds or subtracts one to a stored variable and pushes its new value: 'NAME' incr
+func newIncrementAct(opCode string, op *OperationDesc) ActionDesc {
+	return NewActionDesc(opCode, 1, CheckGen([]Type{TYPE_ALG_EXPR}), func(system System, stack *Stack) error {
+		elts, err := stack.PeekN(1)
 		if err != nil {
 			return err
 		}
-		idAsString := variable.asIdentifierVar().value
-		memory := system.Memory()
-		rootFolder := memory.getRoot()
-		for _, varName := range rootFolder.variables {
-			if varName.name == idAsString {
-				stack.Push(varName.value)
-				break
-			}
+		name, err := memoryName(elts[0])
+		if err != nil {
+			return err
+		}
+		result, err := updateStoredVariable(system, name, op, CreateNumericVariableFromInt(1))
+		if err != nil {
+			return err
 		}
+		_, _ = stack.Pop()
+		stack.Push(result)
 		return nil
 	})
+}
+
+var incrAct = newIncrementAct("incr", &addOp)
+
+var decrAct = newIncrementAct("decr", &subOp)
 
 var crdirAct = NewActionDesc("crdir", 1, CheckNoop, func(system System, stack *Stack) error {
 	variable, err := stack.Pop()
@@ -262,26 +745,372 @@ var crdirAct = NewActionDesc("crdir", 1, CheckNoop, func(system System, stack *S
 	return err
 })
 
-/*
-var purgeAct = NewActionDesc("purge", 1, CheckNoop, func(system System, stack *Stack) error {
+// memoryName returns the name held by an identifier like 'X'
+func memoryName(v Variable) (string, error) {
+	if v.getType() != TYPE_ALG_EXPR {
+		return "", fmt.Errorf("expected a name, found: %v", v.getType())
+	}
+	return v.asIdentifierVar().value, nil
+}
+
+func createIdentifierVariable(name string) Variable {
+	return CreateAlgebraicExpressionVariable(name, &AlgExprVariable{value: name})
+}
+
+// isMemoryPath tells if v is an absolute path like { 'HOME' 'DIR' 'SUBDIR' }
+func isMemoryPath(v Variable) bool {
+	if v.getType() != TYPE_LIST {
+		return false
+	}
+	items := v.asListVar().items
+	if len(items) == 0 || items[0].getType() != TYPE_ALG_EXPR || items[0].asIdentifierVar().value != "HOME" {
+		return false
+	}
+	for _, item := range items {
+		if item.getType() != TYPE_ALG_EXPR {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveMemoryPath returns the folder designated by an absolute path
+func resolveMemoryPath(memory Memory, path Variable) (*MemoryFolder, error) {
+	if !isMemoryPath(path) {
+		return nil, fmt.Errorf("%s is not a path, expected { 'HOME' ... }", path.display(NewModes()))
+	}
+	folder := memory.getRoot()
+	for _, item := range path.asListVar().items[1:] {
+		name := item.asIdentifierVar().value
+		node, ok := folder.subNode(name).(*MemoryFolder)
+		if !ok {
+			return nil, fmt.Errorf("folder %s not found in %s", name, path.display(NewModes()))
+		}
+		folder = node
+	}
+	return folder, nil
+}
+
+// resolveMemoryFolder returns the folder designated by an absolute path or by the name of a folder
+// inside the current folder
+func resolveMemoryFolder(memory Memory, v Variable) (*MemoryFolder, error) {
+	if v.getType() == TYPE_LIST {
+		return resolveMemoryPath(memory, v)
+	}
+	name, err := memoryName(v)
+	if err != nil {
+		return nil, err
+	}
+	folder, ok := memory.getCurrentFolder().subNode(name).(*MemoryFolder)
+	if !ok {
+		return nil, fmt.Errorf("folder %s not found", name)
+	}
+	return folder, nil
+}
+
+// resolveMemoryNode returns the variable or folder named v inside the current folder
+func resolveMemoryNode(memory Memory, v Variable) (MemoryNode, error) {
+	name, err := memoryName(v)
+	if err != nil {
+		return nil, err
+	}
+	node := memory.getCurrentFolder().subNode(name)
+	if node == nil {
+		return nil, fmt.Errorf("%s not found in folder %s", name, memory.getCurrentFolder().name)
+	}
+	return node, nil
+}
+
+var updirAct = NewActionDesc("updir", 0, CheckNoop, func(system System, stack *Stack) error {
+	memory := system.Memory()
+	if parent := memory.getCurrentFolder().getParent(); parent != nil {
+		memory.setCurrentFolder(parent)
+	}
+	return nil
+})
+
+var homeAct = NewActionDesc("home", 0, CheckNoop, func(system System, stack *Stack) error {
+	memory := system.Memory()
+	memory.setCurrentFolder(memory.getRoot())
+	return nil
+})
+
+// pathAct pushes the absolute path of the current folder, which goes back to it when evaluated
+var pathAct = NewActionDesc("path", 0, CheckNoop, func(system System, stack *Stack) error {
+	memory := system.Memory()
+	items := []Variable{createIdentifierVariable(memory.getRoot().name)}
+	for _, name := range memory.getPath(memory.getCurrentFolder()) {
+		items = append(items, createIdentifierVariable(name))
+	}
+	stack.Push(CreateListVariable(items))
+	return nil
+})
+
+// varsAct pushes the names of the variables of the current folder
+var varsAct = NewActionDesc("vars", 0, CheckNoop, func(system System, stack *Stack) error {
+	memory := system.Memory()
+	variables, err := memory.listVariables(memory.getCurrentFolder())
+	if err != nil {
+		return err
+	}
+	items := make([]Variable, len(variables))
+	for i, variable := range variables {
+		items[i] = createIdentifierVariable(variable.name)
+	}
+	stack.Push(CreateListVariable(items))
 	return nil
 })
-*/
 
-// UPDIR to go upper dir
-// eval dirname => goto inside dirname
-// { HOME TOTO } goes to TOTO
-// RCL recall ?
-// PURGE / PGDIR (only empty / delete recursively)
+// purgeAct deletes a variable or an empty folder, or a list of them
+var purgeAct = NewActionDesc("purge", 1, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	names := []Variable{elts[0]}
+	if elts[0].getType() == TYPE_LIST {
+		names = elts[0].asListVar().items
+	}
+	memory := system.Memory()
+	for _, name := range names {
+		node, err := resolveMemoryNode(memory, name)
+		if err != nil {
+			return err
+		}
+		if folder, ok := node.(*MemoryFolder); ok && len(folder.SubNodes()) > 0 {
+			return fmt.Errorf("folder %s is not empty, use pgdir to delete it", folder.name)
+		}
+		if err = memory.deleteNode(node); err != nil {
+			return err
+		}
+	}
+	_, err = stack.Pop()
+	return err
+})
+
+// pgdirAct deletes a folder with all its content
+var pgdirAct = NewActionDesc("pgdir", 1, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	memory := system.Memory()
+	folder, err := resolveMemoryFolder(memory, elts[0])
+	if err != nil {
+		return err
+	}
+	if err = memory.deleteNode(folder); err != nil {
+		return err
+	}
+	_, err = stack.Pop()
+	return err
+})
+
+// renameAct renames a variable or a folder of the current folder: 'OLD' 'NEW' rename
+var renameAct = NewActionDesc("rename", 2, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(2)
+	if err != nil {
+		return err
+	}
+	memory := system.Memory()
+	node, err := resolveMemoryNode(memory, elts[0])
+	if err != nil {
+		return err
+	}
+	newName, err := memoryName(elts[1])
+	if err != nil {
+		return err
+	}
+	if err = memory.renameNode(node, newName); err != nil {
+		return err
+	}
+	_, err = stack.PopN(2)
+	return err
+})
+
+// moveAct moves a variable or a folder of the current folder to another folder, given by its
+// absolute path or by its name in the current folder: 'X' { 'HOME' 'DIR' } move
+var moveAct = NewActionDesc("move", 2, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(2)
+	if err != nil {
+		return err
+	}
+	memory := system.Memory()
+	node, err := resolveMemoryNode(memory, elts[0])
+	if err != nil {
+		return err
+	}
+	target, err := resolveMemoryFolder(memory, elts[1])
+	if err != nil {
+		return err
+	}
+	if err = memory.moveNode(node, target); err != nil {
+		return err
+	}
+	_, err = stack.PopN(2)
+	return err
+})
 
 var MemoryPackage = ActionPackage{
+	name: "memory",
+	// load was the name of rcl before the folders
+	aliases: map[string]string{"load": "rcl"},
 	staticActions: []Action{
 		&storeAct,
-		&loadAct,
+		&recallAct,
+		&storeAddAct,
+		&storeSubAct,
+		&incrAct,
+		&decrAct,
 		&crdirAct,
+		&updirAct,
+		&homeAct,
+		&pathAct,
+		&varsAct,
+		&purgeAct,
+		&pgdirAct,
+		&renameAct,
+		&moveAct,
+		&exportAct,
+		&importAct,
+	},
+	docs: map[string]ActionDoc{
+		"sto": {
+			Summary:  "stores a value in a variable of the current folder",
+			Stack:    "x 'NAME' ->",
+			Types:    "any value and a name",
+			Examples: []DocExample{{"5 'X' sto 'X' rcl", "5"}},
+		},
+		"rcl": {
+			Summary:  "pushes the value of a variable of the current folder or of its parents",
+			Stack:    "'NAME' -> x",
+			Types:    "a name",
+			Examples: []DocExample{{"5 'X' sto 'X' rcl", "5"}},
+		},
+		"sto+": {
+			Summary:  "adds a value to a variable",
+			Stack:    "x 'NAME' ->",
+			Types:    "any value accepted by + and a name",
+			Examples: []DocExample{{"5 'X' sto 2 'X' sto+ 'X' rcl", "7"}},
+		},
+		"sto-": {
+			Summary:  "subtracts a value from a variable",
+			Stack:    "x 'NAME' ->",
+			Types:    "any value accepted by - and a name",
+			Examples: []DocExample{{"5 'X' sto 2 'X' sto- 'X' rcl", "3"}},
+		},
+		"incr": {
+			Summary:  "adds one to a variable and pushes its new value",
+			Stack:    "'NAME' -> x+1",
+			Types:    "a name",
+			Examples: []DocExample{{"5 'N' sto 'N' incr", "6"}},
+		},
+		"decr": {
+			Summary:  "subtracts one from a variable and pushes its new value",
+			Stack:    "'NAME' -> x-1",
+			Types:    "a name",
+			Examples: []DocExample{{"5 'N' sto 'N' decr", "4"}},
+		},
+		"crdir": {
+			Summary:  "creates a folder in the current folder",
+			Stack:    "'NAME' ->",
+			Types:    "a name",
+			Examples: []DocExample{{"'LIB' crdir vars", "{  }"}},
+		},
+		"updir": {
+			Summary: "goes to the parent folder",
+			Stack:   "->",
+			Types:   "none",
+		},
+		"home": {
+			Summary:  "goes to the HOME folder",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"home path", "{ 'HOME' }"}},
+		},
+		"path": {
+			Summary:  "pushes the path of the current folder",
+			Stack:    "-> { 'HOME' ... }",
+			Types:    "none",
+			Examples: []DocExample{{"path", "{ 'HOME' }"}},
+		},
+		"vars": {
+			Summary:  "pushes the names of the variables of the current folder",
+			Stack:    "-> { 'NAME' ... }",
+			Types:    "none",
+			Examples: []DocExample{{"1 'A' sto 2 'B' sto vars", "{ 'A' 'B' }"}},
+		},
+		"purge": {
+			Summary:  "deletes a variable or an empty folder, or a list of them",
+			Stack:    "'NAME' ->",
+			Types:    "a name or a list of names",
+			Examples: []DocExample{{"1 'A' sto 'A' purge vars", "{  }"}},
+		},
+		"pgdir": {
+			Summary: "deletes a folder with all its content",
+			Stack:   "'NAME' ->",
+			Types:   "a name or a path",
+		},
+		"rename": {
+			Summary:  "renames a variable or a folder of the current folder",
+			Stack:    "'OLD' 'NEW' ->",
+			Types:    "names",
+			Examples: []DocExample{{"1 'A' sto 'A' 'B' rename vars", "{ 'B' }"}},
+		},
+		"move": {
+			Summary: "moves a variable or a folder to another folder",
+			Stack:   "'NAME' 'FOLDER' ->",
+			Types:   "a name, then a folder name or a path like { 'HOME' 'DIR' }",
+		},
+		"export": {
+			Summary: "writes a folder and its content to a text file",
+			Stack:   "'FOLDER' \"file\" ->",
+			Types:   "a folder name or a path, then a file name",
+		},
+		"import": {
+			Summary: "reads the folders of a text file written by export into the current folder",
+			Stack:   "\"file\" ->",
+			Types:   "a file name",
+		},
 	},
 }
 
 var MiscPackage = ActionPackage{
-	staticActions: []Action{},
+	name: "misc",
+	staticActions: []Action{
+		&helpAct,
+		&aproposAct,
+		&DebugOp,
+		&VersionOp,
+		&EXIT_ACTION,
+	},
+	docs: map[string]ActionDoc{
+		"help": {
+			Summary:  "describes an operation in one line",
+			Stack:    "\"op\" -> \"description\"",
+			Types:    "a string or a name",
+			Examples: []DocExample{{"\"swap\" help", "\"swap ( x y -> y x ) exchanges levels 1 and 2\""}},
+		},
+		"apropos": {
+			Summary:  "lists the operations whose name, summary or package contains a keyword",
+			Stack:    "\"keyword\" -> { \"op\" ... }",
+			Types:    "a string or a name",
+			Examples: []DocExample{{"\"undo\" apropos", "{ \"redo\" \"undo\" }"}},
+		},
+		"debug": {
+			Summary: "prints level 1 on the standard output, for debugging",
+			Stack:   "x -> x",
+			Types:   "any",
+		},
+		"version": {
+			Summary: "pushes the version of rcalc",
+			Stack:   "-> version",
+			Types:   "none",
+		},
+		"quit": {
+			Summary: "leaves rcalc",
+			Stack:   "->",
+			Types:   "none",
+		},
+	},
 }
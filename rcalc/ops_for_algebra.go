@@ -0,0 +1,528 @@
+package rcalc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Symbolic computations on the trees of the algebraic expressions. The nodes are built by the
+// alg* functions which simplify them on the fly: 0*x is 0, 1*x is x, x^1 is x, numbers are
+// computed, sums and products are flattened and the signs are moved in front of the products.
+
+func algNumber(value decimal.Decimal) AlgebraicExpressionNode {
+	return &AlgExprNumber{value: value}
+}
+
+func algNumberFromInt(value int64) AlgebraicExpressionNode {
+	return algNumber(decimal.NewFromInt(value))
+}
+
+// algUnwrap removes the positive signs that the parser puts around the atoms
+func algUnwrap(node AlgebraicExpressionNode) AlgebraicExpressionNode {
+	for {
+		signed, ok := node.(*AlgExprSignedElt)
+		if !ok || signed.operator != OPERATOR_ADD {
+			return node
+		}
+		node = signed.items
+	}
+}
+
+func algNumberValue(node AlgebraicExpressionNode) (decimal.Decimal, bool) {
+	number, ok := algUnwrap(node).(*AlgExprNumber)
+	if !ok {
+		return decimal.Zero, false
+	}
+	return number.value, true
+}
+
+func algIsNumber(node AlgebraicExpressionNode, value int64) bool {
+	number, ok := algNumberValue(node)
+	return ok && number.Equal(decimal.NewFromInt(value))
+}
+
+// algNegated returns x when node is -x, a negative number or a product starting with one
+func algNegated(node AlgebraicExpressionNode) (AlgebraicExpressionNode, bool) {
+	switch n := algUnwrap(node).(type) {
+	case *AlgExprNumber:
+		if n.value.IsNegative() {
+			return algNumber(n.value.Neg()), true
+		}
+	case *AlgExprSignedElt:
+		return n.items, true
+	case *AlgExprMulDiv:
+		if first, ok := algNumberValue(n.items[0]); ok && first.IsNegative() {
+			return algWithFirstFactor(n, first.Neg()), true
+		}
+	}
+	return nil, false
+}
+
+// algWithFirstFactor replaces the number starting a product, 1*x is x
+func algWithFirstFactor(product *AlgExprMulDiv, value decimal.Decimal) AlgebraicExpressionNode {
+	if value.Equal(decimal.NewFromInt(1)) && product.operators[0] == OPERATOR_MUL {
+		if len(product.items) == 2 {
+			return product.items[1]
+		}
+		return &AlgExprMulDiv{items: product.items[1:], operators: product.operators[1:]}
+	}
+	items := slices.Clone(product.items)
+	items[0] = algNumber(value)
+	return &AlgExprMulDiv{items: items, operators: product.operators}
+}
+
+func algNeg(node AlgebraicExpressionNode) AlgebraicExpressionNode {
+	node = algUnwrap(node)
+	if negated, ok := algNegated(node); ok {
+		return negated
+	}
+	switch n := node.(type) {
+	case *AlgExprNumber:
+		return algNumber(n.value.Neg())
+	case *AlgExprMulDiv:
+		if first, ok := algNumberValue(n.items[0]); ok {
+			return algWithFirstFactor(n, first.Neg())
+		}
+	}
+	return &AlgExprSignedElt{items: node, operator: OPERATOR_SUB}
+}
+
+func algAdd(a AlgebraicExpressionNode, b AlgebraicExpressionNode) AlgebraicExpressionNode {
+	return algAddSub(a, b, OPERATOR_ADD)
+}
+
+func algSub(a AlgebraicExpressionNode, b AlgebraicExpressionNode) AlgebraicExpressionNode {
+	return algAddSub(a, b, OPERATOR_SUB)
+}
+
+func algAddSub(a AlgebraicExpressionNode, b AlgebraicExpressionNode, operator int) AlgebraicExpressionNode {
+	a, b = algUnwrap(a), algUnwrap(b)
+	valueA, isNumberA := algNumberValue(a)
+	valueB, isNumberB := algNumberValue(b)
+	switch {
+	case isNumberA && isNumberB:
+		if operator == OPERATOR_SUB {
+			return algNumber(valueA.Sub(valueB))
+		}
+		return algNumber(valueA.Add(valueB))
+	case isNumberB && valueB.IsZero():
+		return a
+	case isNumberA && valueA.IsZero():
+		if operator == OPERATOR_SUB {
+			return algNeg(b)
+		}
+		return b
+	}
+	// a + -b is a - b and a - -b is a + b
+	if negated, ok := algNegated(b); ok {
+		b = negated
+		if operator == OPERATOR_SUB {
+			operator = OPERATOR_ADD
+		} else {
+			operator = OPERATOR_SUB
+		}
+	}
+	items := []AlgebraicExpressionNode{a}
+	var operators []int
+	if sum, ok := a.(*AlgExprAddSub); ok {
+		items = slices.Clone(sum.items)
+		operators = slices.Clone(sum.operators)
+	}
+	if sum, ok := b.(*AlgExprAddSub); ok && operator == OPERATOR_ADD {
+		items = append(items, sum.items...)
+		operators = append(append(operators, OPERATOR_ADD), sum.operators...)
+	} else {
+		items = append(items, b)
+		operators = append(operators, operator)
+	}
+	return &AlgExprAddSub{items: items, operators: operators}
+}
+
+func algMul(a AlgebraicExpressionNode, b AlgebraicExpressionNode) AlgebraicExpressionNode {
+	return algMulDiv(a, b, OPERATOR_MUL)
+}
+
+func algDiv(a AlgebraicExpressionNode, b AlgebraicExpressionNode) AlgebraicExpressionNode {
+	return algMulDiv(a, b, OPERATOR_DIV)
+}
+
+func algMulDiv(a AlgebraicExpressionNode, b AlgebraicExpressionNode, operator int) AlgebraicExpressionNode {
+	a, b = algUnwrap(a), algUnwrap(b)
+	if operator == OPERATOR_MUL {
+		if _, isNumberB := algNumberValue(b); isNumberB {
+			// the numbers are put in front: x*2 is 2*x
+			a, b = b, a
+		}
+	}
+	valueA, isNumberA := algNumberValue(a)
+	valueB, isNumberB := algNumberValue(b)
+	switch {
+	case isNumberA && valueA.IsZero():
+		return a
+	case isNumberB && valueB.IsZero() && operator == OPERATOR_MUL:
+		return b
+	case algIsNumber(b, 1):
+		return a
+	case isNumberA && isNumberB && operator == OPERATOR_MUL:
+		return algNumber(valueA.Mul(valueB))
+	case isNumberA && isNumberB && !valueB.IsZero() && valueA.Mod(valueB).IsZero():
+		// only exact divisions are computed, 1/3 stays a fraction
+		return algNumber(valueA.Div(valueB))
+	case algIsNumber(a, 1) && operator == OPERATOR_MUL:
+		return b
+	case algIsNumber(a, -1) && operator == OPERATOR_MUL:
+		return algNeg(b)
+	}
+	// the signs are moved in front: a*-b is -(a*b)
+	if negated, ok := algNegated(b); ok {
+		return algNeg(algMulDiv(a, negated, operator))
+	}
+	if negated, ok := algNegated(a); ok && !isNumberA {
+		return algNeg(algMulDiv(negated, b, operator))
+	}
+	if product, ok := b.(*AlgExprMulDiv); ok && isNumberA && operator == OPERATOR_MUL {
+		// 2*(3*x) is 6*x
+		if first, isNumber := algNumberValue(product.items[0]); isNumber {
+			return algWithFirstFactor(product, valueA.Mul(first))
+		}
+	}
+	items := []AlgebraicExpressionNode{a}
+	var operators []int
+	if product, ok := a.(*AlgExprMulDiv); ok {
+		items = slices.Clone(product.items)
+		operators = slices.Clone(product.operators)
+	}
+	if product, ok := b.(*AlgExprMulDiv); ok && operator == OPERATOR_MUL {
+		items = append(items, product.items...)
+		operators = append(append(operators, OPERATOR_MUL), product.operators...)
+	} else {
+		items = append(items, b)
+		operators = append(operators, operator)
+	}
+	return &AlgExprMulDiv{items: items, operators: operators}
+}
+
+func algPow(base AlgebraicExpressionNode, exponent AlgebraicExpressionNode) AlgebraicExpressionNode {
+	base, exponent = algUnwrap(base), algUnwrap(exponent)
+	valueB, isNumberB := algNumberValue(base)
+	valueE, isNumberE := algNumberValue(exponent)
+	switch {
+	case isNumberE && valueE.IsZero():
+		return algNumberFromInt(1)
+	case isNumberE && valueE.Equal(decimal.NewFromInt(1)):
+		return base
+	case isNumberB && isNumberE && valueE.IsInteger() && !valueE.IsNegative():
+		return algNumber(valueB.Pow(valueE))
+	}
+	return &AlgExprPow{items: []AlgebraicExpressionNode{base, exponent}}
+}
+
+func algFunctionCall(reg *ActionRegistry, name string, args ...AlgebraicExpressionNode) (AlgebraicExpressionNode, error) {
+	desc, ok := reg.getAlgebraicFunctionDesc(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	return &AlgExprFunctionElt{functionName: name, fn: desc.fn, arguments: args}, nil
+}
+
+// algDependsOn tells if the variable varName appears in node
+func algDependsOn(node AlgebraicExpressionNode, varName string) bool {
+	anyDependsOn := func(items []AlgebraicExpressionNode) bool {
+		return slices.ContainsFunc(items, func(item AlgebraicExpressionNode) bool {
+			return algDependsOn(item, varName)
+		})
+	}
+	switch n := node.(type) {
+	case *AlgExprVariable:
+		return n.value == varName
+	case *AlgExprSignedElt:
+		return algDependsOn(n.items, varName)
+	case *AlgExprAddSub:
+		return anyDependsOn(n.items)
+	case *AlgExprMulDiv:
+		return anyDependsOn(n.items)
+	case *AlgExprPow:
+		return anyDependsOn(n.items)
+	case *AlgExprFunctionElt:
+		return anyDependsOn(n.arguments)
+	case AlgExprFunctionElt:
+		return anyDependsOn(n.arguments)
+	}
+	return false
+}
+
+// derive derivative of node with respect to the variable varName, the other variables are constants
+func derive(reg *ActionRegistry, modes *Modes, node AlgebraicExpressionNode, varName string) (AlgebraicExpressionNode, error) {
+	switch n := node.(type) {
+	case *AlgExprNumber:
+		return algNumberFromInt(0), nil
+	case *AlgExprVariable:
+		if n.value == varName {
+			return algNumberFromInt(1), nil
+		}
+		return algNumberFromInt(0), nil
+	case *AlgExprSignedElt:
+		derivative, err := derive(reg, modes, n.items, varName)
+		if err != nil {
+			return nil, err
+		}
+		if n.operator == OPERATOR_SUB {
+			return algNeg(derivative), nil
+		}
+		return derivative, nil
+	case *AlgExprAddSub:
+		return deriveSum(reg, modes, n, varName)
+	case *AlgExprMulDiv:
+		return deriveProduct(reg, modes, n, varName)
+	case *AlgExprPow:
+		return derivePow(reg, modes, n, varName)
+	case *AlgExprFunctionElt:
+		return deriveFunction(reg, modes, n, varName)
+	case AlgExprFunctionElt:
+		return deriveFunction(reg, modes, &n, varName)
+	case nil:
+		return nil, fmt.Errorf("cannot derive an empty expression")
+	}
+	return nil, fmt.Errorf("cannot derive %s", formatAlgExpr(node))
+}
+
+func deriveSum(reg *ActionRegistry, modes *Modes, sum *AlgExprAddSub, varName string) (AlgebraicExpressionNode, error) {
+	result, err := derive(reg, modes, sum.items[0], varName)
+	if err != nil {
+		return nil, err
+	}
+	for idx, item := range sum.items[1:] {
+		derivative, err := derive(reg, modes, item, varName)
+		if err != nil {
+			return nil, err
+		}
+		result = algAddSub(result, derivative, sum.operators[idx])
+	}
+	return result, nil
+}
+
+// deriveProduct derives the product from left to right, (u*v)' is u'*v + u*v' and (u/v)' is
+// (u'*v - u*v')/v^2
+func deriveProduct(reg *ActionRegistry, modes *Modes, product *AlgExprMulDiv, varName string) (AlgebraicExpressionNode, error) {
+	left := product.items[0]
+	result, err := derive(reg, modes, left, varName)
+	if err != nil {
+		return nil, err
+	}
+	for idx, item := range product.items[1:] {
+		derivative, err := derive(reg, modes, item, varName)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case product.operators[idx] != OPERATOR_DIV:
+			result = algAdd(algMul(result, item), algMul(left, derivative))
+		case algIsNumber(derivative, 0):
+			result = algDiv(result, item)
+		default:
+			result = algDiv(algSub(algMul(result, item), algMul(left, derivative)), algPow(item, algNumberFromInt(2)))
+		}
+		left = algMulDiv(left, item, product.operators[idx])
+	}
+	return result, nil
+}
+
+// derivePow derives u^n where n does not depend on the variable: n*u^(n-1)*u'. Powers are right
+// associative, 2^3^x is 2^(3^x).
+func derivePow(reg *ActionRegistry, modes *Modes, pow *AlgExprPow, varName string) (AlgebraicExpressionNode, error) {
+	base := pow.items[0]
+	exponent := pow.items[1]
+	if len(pow.items) > 2 {
+		exponent = &AlgExprPow{items: pow.items[1:]}
+	}
+	if algDependsOn(exponent, varName) {
+		return nil, fmt.Errorf("cannot derive %s, its exponent depends on %s", formatAlgExpr(pow), varName)
+	}
+	baseDerivative, err := derive(reg, modes, base, varName)
+	if err != nil {
+		return nil, err
+	}
+	power := algPow(base, algSub(exponent, algNumberFromInt(1)))
+	return algMul(algMul(exponent, power), baseDerivative), nil
+}
+
+// deriveFunction applies the chain rule with the derivative registered with the function. The
+// derivatives of the functions of angles are the ones of functions of radians, they are refused
+// in the other angle modes.
+func deriveFunction(reg *ActionRegistry, modes *Modes, call *AlgExprFunctionElt, varName string) (AlgebraicExpressionNode, error) {
+	desc, ok := reg.getAlgebraicFunctionDesc(call.functionName)
+	if !ok || desc.derivative == nil || len(call.arguments) != 1 {
+		return nil, fmt.Errorf("cannot derive the function %s", call.functionName)
+	}
+	argDerivative, err := derive(reg, modes, call.arguments[0], varName)
+	if err != nil {
+		return nil, err
+	}
+	if algIsNumber(argDerivative, 0) {
+		return argDerivative, nil
+	}
+	if desc.angleArgument && modes.angle != ANGLE_RAD {
+		return nil, fmt.Errorf("cannot derive the function %s outside of rad mode", call.functionName)
+	}
+	derivative, err := desc.derivative(reg, call.arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	return algMul(argDerivative, derivative), nil
+}
+
+// Precedences of the nodes when they are printed, an operand whose precedence is lower than the
+// one expected by its parent is put between parentheses
+const (
+	algPrecedenceAddSub = iota + 1
+	algPrecedenceNeg
+	algPrecedenceMulDiv
+	algPrecedencePow
+	algPrecedenceAtom
+)
+
+// formatAlgExpr prints an expression as infix text which can be parsed again
+func formatAlgExpr(node AlgebraicExpressionNode) string {
+	text, _ := formatAlgNode(node)
+	return text
+}
+
+func formatAlgOperand(node AlgebraicExpressionNode, minPrecedence int) string {
+	text, precedence := formatAlgNode(node)
+	if precedence < minPrecedence {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func formatAlgNode(node AlgebraicExpressionNode) (string, int) {
+	switch n := node.(type) {
+	case *AlgExprNumber:
+		if n.value.IsNegative() {
+			return n.value.String(), algPrecedenceNeg
+		}
+		return n.value.String(), algPrecedenceAtom
+	case *AlgExprVariable:
+		return n.value, algPrecedenceAtom
+	case *AlgExprSignedElt:
+		if n.operator == OPERATOR_ADD {
+			return formatAlgNode(n.items)
+		}
+		// the sign applies to an atom, -x^2 is (-x)^2 but -x*y is -(x*y)
+		inner := algUnwrap(n.items)
+		minPrecedence := algPrecedenceAtom
+		if product, ok := inner.(*AlgExprMulDiv); ok {
+			if _, firstPrecedence := formatAlgNode(product.items[0]); firstPrecedence == algPrecedenceAtom {
+				minPrecedence = algPrecedenceMulDiv
+			}
+		}
+		return "-" + formatAlgOperand(inner, minPrecedence), algPrecedenceNeg
+	case *AlgExprAddSub:
+		var sb strings.Builder
+		sb.WriteString(formatAlgOperand(n.items[0], algPrecedenceAddSub))
+		for idx, item := range n.items[1:] {
+			if n.operators[idx] == OPERATOR_SUB {
+				sb.WriteString(" - ")
+			} else {
+				sb.WriteString(" + ")
+			}
+			sb.WriteString(formatAlgOperand(item, algPrecedenceMulDiv))
+		}
+		return sb.String(), algPrecedenceAddSub
+	case *AlgExprMulDiv:
+		var sb strings.Builder
+		sb.WriteString(formatAlgOperand(n.items[0], algPrecedenceNeg))
+		for idx, item := range n.items[1:] {
+			if n.operators[idx] == OPERATOR_DIV {
+				sb.WriteString("/")
+			} else {
+				sb.WriteString("*")
+			}
+			sb.WriteString(formatAlgOperand(item, algPrecedencePow))
+		}
+		return sb.String(), algPrecedenceMulDiv
+	case *AlgExprPow:
+		items := make([]string, len(n.items))
+		for idx, item := range n.items {
+			items[idx] = formatAlgOperand(item, algPrecedenceAtom)
+		}
+		return strings.Join(items, "^"), algPrecedencePow
+	case *AlgExprFunctionElt:
+		return formatAlgFunctionCall(n), algPrecedenceAtom
+	case AlgExprFunctionElt:
+		return formatAlgFunctionCall(&n), algPrecedenceAtom
+	}
+	return fmt.Sprintf("%v", node), algPrecedenceAtom
+}
+
+func formatAlgFunctionCall(call *AlgExprFunctionElt) string {
+	args := make([]string, len(call.arguments))
+	for idx, arg := range call.arguments {
+		args[idx] = formatAlgOperand(arg, algPrecedenceAddSub)
+	}
+	return fmt.Sprintf("%s(%s)", call.functionName, strings.Join(args, ","))
+}
+
+// algVariableName name of the variable of an expression like 'x'
+func algVariableName(v Variable) (string, bool) {
+	variable, ok := algUnwrap(v.asIdentifierVar().rootNode).(*AlgExprVariable)
+	if !ok {
+		return "", false
+	}
+	return variable.value, true
+}
+
+// derivArgs the expression and the name of the variable given to deriv
+func derivArgs(elts []Variable) (AlgebraicExpressionNode, string, error) {
+	if elts[0].getType() != TYPE_ALG_EXPR {
+		return nil, "", fmt.Errorf("deriv expects an algebraic expression, found: %v", elts[0].getType())
+	}
+	if elts[1].getType() != TYPE_ALG_EXPR {
+		return nil, "", fmt.Errorf("deriv expects a variable name, found: %v", elts[1].getType())
+	}
+	varName, ok := algVariableName(elts[1])
+	if !ok {
+		return nil, "", fmt.Errorf("deriv expects a variable name, found: %s", elts[1].display(NewModes()))
+	}
+	return elts[0].asIdentifierVar().rootNode, varName, nil
+}
+
+// derivOp derivative of an algebraic expression with respect to a variable: 'x^2' 'x' deriv
+var derivOp = NewActionDesc("deriv", 2, CheckNoop, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(2)
+	if err != nil {
+		return err
+	}
+	expression, varName, err := derivArgs(elts)
+	if err != nil {
+		return err
+	}
+	derivative, err := derive(system.Registry(), system.Modes(), expression, varName)
+	if err != nil {
+		return err
+	}
+	_, _ = stack.PopN(2)
+	stack.Push(CreateAlgebraicExpressionVariable(formatAlgExpr(derivative), derivative))
+	return nil
+})
+
+var AlgebraPackage = ActionPackage{
+	name: "algebra",
+	staticActions: []Action{
+		&derivOp,
+	},
+	docs: map[string]ActionDoc{
+		"deriv": {
+			Summary: "derivative of an algebraic expression with respect to a variable, the other variables are constants",
+			Stack:   "'expr' 'x' -> 'd(expr)/dx'",
+			Types:   "algebraic expressions, a variable name",
+			Examples: []DocExample{
+				{"'x^2*sin(x)' 'x' deriv", "'2*x*sin(x) + x^2*cos(x)'"},
+				{"'3*x^2 - 2*x + 1' 'x' deriv", "'6*x - 2'"},
+			},
+		},
+	},
+}
@@ -0,0 +1,178 @@
+package rcalc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func algVar(name string) AlgebraicExpressionNode {
+	return &AlgExprVariable{value: name}
+}
+
+func algCall(name string, arg AlgebraicExpressionNode) AlgebraicExpressionNode {
+	call, err := algFunctionCall(Registry, name, arg)
+	if err != nil {
+		panic(err)
+	}
+	return call
+}
+
+// algParsed wraps a node in a positive sign like the parser does for the atoms
+func algParsed(node AlgebraicExpressionNode) AlgebraicExpressionNode {
+	return &AlgExprSignedElt{items: node, operator: OPERATOR_ADD}
+}
+
+func TestFormatAlgExpr(t *testing.T) {
+	x, y, z := algVar("x"), algVar("y"), algVar("z")
+	tests := []struct {
+		node     AlgebraicExpressionNode
+		expected string
+	}{
+		{&AlgExprAddSub{items: []AlgebraicExpressionNode{x, y, z}, operators: []int{OPERATOR_ADD, OPERATOR_SUB}}, "x + y - z"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{algAdd(x, y), z}, operators: []int{OPERATOR_MUL}}, "(x + y)*z"},
+		{&AlgExprAddSub{items: []AlgebraicExpressionNode{x, algSub(y, z)}, operators: []int{OPERATOR_SUB}}, "x - (y - z)"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{x, algMul(y, z)}, operators: []int{OPERATOR_DIV}}, "x/(y*z)"},
+		{algDiv(x, algPow(y, algNumberFromInt(2))), "x/y^2"},
+		{algPow(algNeg(x), algNumberFromInt(2)), "(-x)^2"},
+		{algNeg(algPow(x, algNumberFromInt(2))), "-(x^2)"},
+		{algNeg(algMul(x, y)), "-x*y"},
+		{algPow(x, algNumberFromInt(-1)), "x^(-1)"},
+		{algPow(algPow(x, y), z), "(x^y)^z"},
+		{&AlgExprPow{items: []AlgebraicExpressionNode{x, y, z}}, "x^y^z"},
+		{algCall("sin", algAdd(x, algNumberFromInt(1))), "sin(x + 1)"},
+		{algParsed(algNumber(decimal.RequireFromString("0.5"))), "0.5"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.expected), func(t *testing.T) {
+			assert.Equal(t, test.expected, formatAlgExpr(test.node))
+		})
+	}
+}
+
+func TestAlgSimplifications(t *testing.T) {
+	x := algVar("x")
+	assert.Equal(t, "x", formatAlgExpr(algAdd(algNumberFromInt(0), x)))
+	assert.Equal(t, "-x", formatAlgExpr(algSub(algNumberFromInt(0), x)))
+	assert.Equal(t, "x - 2", formatAlgExpr(algAdd(x, algNumberFromInt(-2))))
+	assert.Equal(t, "x + y", formatAlgExpr(algSub(x, algNeg(algVar("y")))))
+	assert.Equal(t, "0", formatAlgExpr(algMul(x, algNumberFromInt(0))))
+	assert.Equal(t, "2*x", formatAlgExpr(algMul(x, algNumberFromInt(2))))
+	assert.Equal(t, "6*x", formatAlgExpr(algMul(algNumberFromInt(3), algMul(algNumberFromInt(2), x))))
+	assert.Equal(t, "x", formatAlgExpr(algMul(algNumberFromInt(2), algMul(algNumber(decimal.RequireFromString("0.5")), x))))
+	assert.Equal(t, "-2*x", formatAlgExpr(algMul(algNumberFromInt(2), algNeg(x))))
+	assert.Equal(t, "3", formatAlgExpr(algDiv(algNumberFromInt(6), algNumberFromInt(2))))
+	assert.Equal(t, "1/3", formatAlgExpr(algDiv(algNumberFromInt(1), algNumberFromInt(3))))
+	assert.Equal(t, "1", formatAlgExpr(algPow(x, algNumberFromInt(0))))
+	assert.Equal(t, "x", formatAlgExpr(algPow(x, algNumberFromInt(1))))
+	assert.Equal(t, "8", formatAlgExpr(algPow(algNumberFromInt(2), algNumberFromInt(3))))
+}
+
+func TestDerive(t *testing.T) {
+	x, y := algVar("x"), algVar("y")
+	two, three := algNumberFromInt(2), algNumberFromInt(3)
+	tests := []struct {
+		node     AlgebraicExpressionNode
+		expected string
+	}{
+		{algParsed(x), "1"},
+		{algParsed(y), "0"},
+		{algNeg(x), "-1"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{algPow(x, two), algCall("sin", x)}, operators: []int{OPERATOR_MUL}},
+			"2*x*sin(x) + x^2*cos(x)"},
+		{&AlgExprAddSub{
+			items:     []AlgebraicExpressionNode{algMul(three, algPow(x, two)), algMul(two, x), algNumberFromInt(1)},
+			operators: []int{OPERATOR_SUB, OPERATOR_ADD},
+		}, "6*x - 2"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{y, x}, operators: []int{OPERATOR_MUL}}, "y"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{x, y}, operators: []int{OPERATOR_DIV}}, "1/y"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{y, x}, operators: []int{OPERATOR_DIV}}, "-y/x^2"},
+		{&AlgExprMulDiv{items: []AlgebraicExpressionNode{algCall("sin", x), x}, operators: []int{OPERATOR_DIV}},
+			"(cos(x)*x - sin(x))/x^2"},
+		{algPow(algAdd(x, algNumberFromInt(1)), three), "3*(x + 1)^2"},
+		{&AlgExprPow{items: []AlgebraicExpressionNode{x, y, two}}, "y^2*x^(y^2 - 1)"},
+		{algCall("sin", algMul(two, x)), "2*cos(2*x)"},
+		{algCall("sin", algPow(x, two)), "2*x*cos(x^2)"},
+		{algCall("cos", algMul(two, x)), "-2*sin(2*x)"},
+		{algCall("tan", x), "1 + tan(x)^2"},
+		{algCall("sin", y), "0"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, formatAlgExpr(test.node)), func(t *testing.T) {
+			derivative, err := derive(Registry, NewModes(), test.node, "x")
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expected, formatAlgExpr(derivative))
+			}
+		})
+	}
+}
+
+func TestDeriveErrors(t *testing.T) {
+	x := algVar("x")
+	_, err := derive(Registry, NewModes(), algPow(algNumberFromInt(2), x), "x")
+	assert.EqualError(t, err, "cannot derive 2^x, its exponent depends on x")
+
+	_, err = derive(Registry, NewModes(), &AlgExprFunctionElt{functionName: "f", arguments: []AlgebraicExpressionNode{x}}, "x")
+	assert.EqualError(t, err, "cannot derive the function f")
+
+	_, err = derive(Registry, NewModes(), nil, "x")
+	assert.EqualError(t, err, "cannot derive an empty expression")
+
+	degModes := NewModes()
+	degModes.SetAngle(ANGLE_DEG)
+	_, err = derive(Registry, degModes, algMul(algNumberFromInt(2), algCall("sin", x)), "x")
+	assert.EqualError(t, err, "cannot derive the function sin outside of rad mode")
+	derivative, err := derive(Registry, degModes, algCall("sin", algVar("y")), "x")
+	if assert.NoError(t, err, "a constant does not depend on the angle mode") {
+		assert.Equal(t, "0", formatAlgExpr(derivative))
+	}
+}
+
+// TestDerivativeValue compares the value of a derivative with the slope of the function
+func TestDerivativeValue(t *testing.T) {
+	x := algVar("x")
+	// (x^2 - 1)/(x + 2)*cos(x)
+	node := &AlgExprMulDiv{
+		items:     []AlgebraicExpressionNode{algSub(algPow(x, algNumberFromInt(2)), algNumberFromInt(1)), algAdd(x, algNumberFromInt(2)), algCall("cos", x)},
+		operators: []int{OPERATOR_DIV, OPERATOR_MUL},
+	}
+	derivative, err := derive(Registry, NewModes(), node, "x")
+	if !assert.NoError(t, err) {
+		return
+	}
+	system := CreateSystemInstance()
+	runtimeContext := CreateRuntimeContext(system, CreateStack())
+	valueAt := func(expression AlgebraicExpressionNode, at string) decimal.Decimal {
+		variable := system.memory.findVariable("x")
+		if variable == nil {
+			_, err = system.memory.createVariable("x", system.memory.getRoot(), CreateNumericVariable(decimal.RequireFromString(at)))
+			assert.NoError(t, err)
+		} else {
+			variable.value = CreateNumericVariable(decimal.RequireFromString(at))
+		}
+		value, err := expression.Evaluate(runtimeContext)
+		assert.NoError(t, err)
+		return value.value
+	}
+	h := decimal.RequireFromString("0.000001")
+	slope := valueAt(node, "0.700001").Sub(valueAt(node, "0.699999")).Div(h.Mul(decimal.NewFromInt(2)))
+	assert.InDelta(t, slope.InexactFloat64(), valueAt(derivative, "0.7").InexactFloat64(), 1e-6)
+}
+
+func TestDerivOp(t *testing.T) {
+	stack := CreateStack()
+	expression := algMul(algNumberFromInt(3), algPow(algVar("x"), algNumberFromInt(2)))
+	stack.Push(CreateAlgebraicExpressionVariable(formatAlgExpr(expression), expression))
+	stack.Push(createIdentifierVariable("x"))
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+	if assert.NoError(t, runtimeContext.RunAction(&derivOp)) {
+		assert.Equal(t, []string{"'6*x'"}, stackValues(stack))
+	}
+
+	stack.Push(CreateAlgebraicExpressionVariable("x+1", algAdd(algVar("x"), algNumberFromInt(1))))
+	err := runtimeContext.RunAction(&derivOp)
+	assert.EqualError(t, err, "deriv expects a variable name, found: 'x+1'")
+	assert.Equal(t, 2, stack.Size())
+}
@@ -0,0 +1,328 @@
+package rcalc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tooling for binary integers, results are truncated to the word size of the modes
+
+func GetEltAsBinary(modes *Modes, elts []Variable, idx int) uint64 {
+	return elts[idx].asBinaryVar().value & modes.wordMask()
+}
+
+func createBinaryResult(modes *Modes, value uint64) Variable {
+	return CreateBinaryIntegerVariable(value & modes.wordMask())
+}
+
+type A1R1BinaryFn func(b uint64, wordSize int) uint64
+
+func NewA1R1BinaryOp(opCode string, f A1R1BinaryFn) OperationDesc {
+	return NewVariantsOp(opCode, 1, 1,
+		NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+			return []Variable{createBinaryResult(modes, f(GetEltAsBinary(modes, elts, 0), modes.wordSize))}
+		}, TYPE_BINARY),
+	)
+}
+
+type A2R1BinaryFn func(b1 uint64, b2 uint64) uint64
+
+// NewA2R1BinaryVariant calls f with the same arguments order as A2R1NumericApplyFn
+func NewA2R1BinaryVariant(f A2R1BinaryFn) OperationVariant {
+	return NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		return []Variable{createBinaryResult(modes, f(GetEltAsBinary(modes, elts, 1), GetEltAsBinary(modes, elts, 0)))}
+	}, TYPE_BINARY, TYPE_BINARY)
+}
+
+func rotateLeft(b uint64, wordSize int, n int) uint64 {
+	n = n % wordSize
+	return b<<n | b>>(wordSize-n)
+}
+
+// Arithmetic variants, used by +, -, * and /
+
+var addBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	return b2 + b1
+})
+
+var subBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	return b2 - b1
+})
+
+var mulBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	return b2 * b1
+})
+
+// divBinariesVariant is an integer division. The check does not know the word size, a divisor
+// only made of bits above it is 0 once truncated and gives 0 like the other overflows.
+var divBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	if b1 == 0 {
+		return 0
+	}
+	return b2 / b1
+}).WithCheck(func(elts ...Variable) (bool, error) {
+	if elts[1].asBinaryVar().value == 0 {
+		return false, errDivisionByZero
+	}
+	return true, nil
+})
+
+// Bitwise variants, used by and, or, xor and not
+
+var andBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	return b1 & b2
+})
+
+var orBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	return b1 | b2
+})
+
+var xorBinariesVariant = NewA2R1BinaryVariant(func(b1 uint64, b2 uint64) uint64 {
+	return b1 ^ b2
+})
+
+var notBinaryVariant = NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+	return []Variable{createBinaryResult(modes, ^GetEltAsBinary(modes, elts, 0))}
+}, TYPE_BINARY)
+
+// Shifts and rotations
+
+var shiftLeftOp = NewA1R1BinaryOp("sl", func(b uint64, wordSize int) uint64 {
+	return b << 1
+})
+
+var shiftRightOp = NewA1R1BinaryOp("sr", func(b uint64, wordSize int) uint64 {
+	return b >> 1
+})
+
+// arithmeticShiftRightOp keeps the most significant bit of the word
+var arithmeticShiftRightOp = NewA1R1BinaryOp("asr", func(b uint64, wordSize int) uint64 {
+	return b>>1 | b&(1<<(wordSize-1))
+})
+
+var shiftLeftByteOp = NewA1R1BinaryOp("slb", func(b uint64, wordSize int) uint64 {
+	return b << 8
+})
+
+var shiftRightByteOp = NewA1R1BinaryOp("srb", func(b uint64, wordSize int) uint64 {
+	return b >> 8
+})
+
+var rotateLeftOp = NewA1R1BinaryOp("rl", func(b uint64, wordSize int) uint64 {
+	return rotateLeft(b, wordSize, 1)
+})
+
+var rotateRightOp = NewA1R1BinaryOp("rr", func(b uint64, wordSize int) uint64 {
+	return rotateLeft(b, wordSize, wordSize-1)
+})
+
+var rotateLeftByteOp = NewA1R1BinaryOp("rlb", func(b uint64, wordSize int) uint64 {
+	return rotateLeft(b, wordSize, 8)
+})
+
+var rotateRightByteOp = NewA1R1BinaryOp("rrb", func(b uint64, wordSize int) uint64 {
+	return rotateLeft(b, wordSize, wordSize-8%wordSize)
+})
+
+// Conversions
+
+var binaryToRealOp = NewVariantsOp("b->r", 1, 1,
+	NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		return []Variable{CreateNumericVariable(decimal.NewFromUint64(GetEltAsBinary(modes, elts, 0)))}
+	}, TYPE_BINARY),
+)
+
+// realToBinaryOp converts negative integers to their two's complement
+var realToBinaryOp = NewVariantsOp("r->b", 1, 1,
+	NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		value := GetEltAsNumeric(elts, 0)
+		if value.IsNegative() {
+			return []Variable{createBinaryResult(modes, uint64(value.IntPart()))}
+		}
+		return []Variable{createBinaryResult(modes, value.BigInt().Uint64())}
+	}, TYPE_NUMERIC).WithCheck(func(elts ...Variable) (bool, error) {
+		value := GetEltAsNumeric(elts, 0)
+		if !value.IsInteger() {
+			return false, fmt.Errorf("%v is not an integer", value)
+		}
+		if value.LessThan(decimal.NewFromInt(math.MinInt64)) || value.GreaterThan(decimal.NewFromUint64(math.MaxUint64)) {
+			return false, fmt.Errorf("%v does not fit in 64 bits", value)
+		}
+		return true, nil
+	}),
+)
+
+// Base and word size
+
+func newBaseAct(opCode string, base BinaryBase) ActionDesc {
+	return NewRawStackOpWithCheck(opCode, 0, CheckNoop, func(system System, stack *Stack) error {
+		system.Modes().SetBase(base)
+		return nil
+	})
+}
+
+var hexAct = newBaseAct("hex", BASE_HEX)
+
+var decAct = newBaseAct("dec", BASE_DEC)
+
+var octAct = newBaseAct("oct", BASE_OCT)
+
+var binAct = newBaseAct("bin", BASE_BIN)
+
+func checkWordSize(elts ...Variable) (bool, error) {
+	if elts[0].getType() != TYPE_NUMERIC {
+		return false, fmt.Errorf("word size must be a number, found: %v", elts[0].getType())
+	}
+	v := elts[0].asNumericVar().value
+	if !v.IsInteger() || v.IntPart() < 1 || v.IntPart() > maxWordSize {
+		return false, fmt.Errorf("%v is not a valid word size, expected 1..%d", v, maxWordSize)
+	}
+	return true, nil
+}
+
+var storeWordSizeAct = NewRawStackOpWithCheck("stws", 1, checkWordSize, func(system System, stack *Stack) error {
+	elts, err := stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	if _, err = checkWordSize(elts...); err != nil {
+		return err
+	}
+	wordSize, _ := stack.Pop()
+	system.Modes().SetWordSize(int(wordSize.asNumericVar().value.IntPart()))
+	return nil
+})
+
+var recallWordSizeAct = NewRawStackOpWithCheck("rcws", 0, CheckNoop, func(system System, stack *Stack) error {
+	stack.Push(CreateNumericVariableFromInt(system.Modes().wordSize))
+	return nil
+})
+
+var BinaryPackage = ActionPackage{
+	name: "binary",
+	staticActions: []Action{
+		&shiftLeftOp,
+		&shiftRightOp,
+		&arithmeticShiftRightOp,
+		&shiftLeftByteOp,
+		&shiftRightByteOp,
+		&rotateLeftOp,
+		&rotateRightOp,
+		&rotateLeftByteOp,
+		&rotateRightByteOp,
+		&binaryToRealOp,
+		&realToBinaryOp,
+		&hexAct,
+		&decAct,
+		&octAct,
+		&binAct,
+		&storeWordSizeAct,
+		&recallWordSizeAct,
+	},
+	docs: map[string]ActionDoc{
+		"sl": {
+			Summary:  "shifts a binary integer one bit left",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"#3d sl", "#6d"}},
+		},
+		"sr": {
+			Summary:  "shifts a binary integer one bit right",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"#6d sr", "#3d"}},
+		},
+		"asr": {
+			Summary:  "shifts a binary integer one bit right keeping its most significant bit",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"8 stws #80h asr hex", "#C0h"}},
+		},
+		"slb": {
+			Summary:  "shifts a binary integer one byte left",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"#1h slb hex", "#100h"}},
+		},
+		"srb": {
+			Summary:  "shifts a binary integer one byte right",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"#100h srb hex", "#1h"}},
+		},
+		"rl": {
+			Summary:  "rotates a binary integer one bit left inside the word size",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"8 stws #80h rl", "#1d"}},
+		},
+		"rr": {
+			Summary:  "rotates a binary integer one bit right inside the word size",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"8 stws #1h rr hex", "#80h"}},
+		},
+		"rlb": {
+			Summary:  "rotates a binary integer one byte left inside the word size",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"16 stws #1234h rlb hex", "#3412h"}},
+		},
+		"rrb": {
+			Summary:  "rotates a binary integer one byte right inside the word size",
+			Stack:    "#b -> #b",
+			Types:    "binary integers",
+			Examples: []DocExample{{"16 stws #1234h rrb hex", "#3412h"}},
+		},
+		"b->r": {
+			Summary:  "converts a binary integer to a number",
+			Stack:    "#b -> x",
+			Types:    "binary integers",
+			Examples: []DocExample{{"#FFh b->r", "255"}},
+		},
+		"r->b": {
+			Summary:  "converts an integer to a binary integer, negative ones to their two's complement",
+			Stack:    "x -> #b",
+			Types:    "integers",
+			Examples: []DocExample{{"255 r->b hex", "#FFh"}},
+		},
+		"hex": {
+			Summary:  "displays the binary integers in base 16",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"#255d hex", "#FFh"}},
+		},
+		"dec": {
+			Summary:  "displays the binary integers in base 10",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"#FFh dec", "#255d"}},
+		},
+		"oct": {
+			Summary:  "displays the binary integers in base 8",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"#8d oct", "#10o"}},
+		},
+		"bin": {
+			Summary:  "displays the binary integers in base 2",
+			Stack:    "->",
+			Types:    "none",
+			Examples: []DocExample{{"#5d bin", "#101b"}},
+		},
+		"stws": {
+			Summary:  "sets the word size of the binary integers, from 1 to 64 bits",
+			Stack:    "n ->",
+			Types:    "an integer",
+			Examples: []DocExample{{"8 stws #1FFh", "#255d"}},
+		},
+		"rcws": {
+			Summary:  "pushes the word size of the binary integers",
+			Stack:    "-> n",
+			Types:    "none",
+			Examples: []DocExample{{"16 stws rcws", "16"}},
+		},
+	},
+}
@@ -0,0 +1,159 @@
+package rcalc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func binVar(value uint64) Variable {
+	return CreateBinaryIntegerVariable(value)
+}
+
+func TestParseBinaryInteger(t *testing.T) {
+	tests := []struct {
+		literal  string
+		base     BinaryBase
+		expected uint64
+	}{
+		{"#FFh", BASE_DEC, 255},
+		{"#1011b", BASE_HEX, 11},
+		{"#17o", BASE_DEC, 15},
+		{"#255d", BASE_HEX, 255},
+		{"#10", BASE_DEC, 10},
+		{"#1B", BASE_HEX, 27},
+		{"#1Bh", BASE_DEC, 27},
+		{"#1b", BASE_HEX, 1},
+		{"#ABCDh", BASE_DEC, 0xABCD},
+		{"#ABCD", BASE_HEX, 0xABCD},
+		{"#FDh", BASE_DEC, 0xFD},
+		{"#11d", BASE_HEX, 11},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.literal), func(t *testing.T) {
+			value, err := ParseBinaryInteger(test.literal, test.base)
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expected, value)
+			}
+		})
+	}
+	_, err := ParseBinaryInteger("#102b", BASE_DEC)
+	assert.EqualError(t, err, "invalid binary integer #102b")
+	_, err = ParseBinaryInteger("#FFd", BASE_HEX)
+	assert.EqualError(t, err, "invalid binary integer #FFd")
+	_, err = ParseBinaryInteger("#ffh", BASE_DEC)
+	assert.EqualError(t, err, "invalid binary integer #ffh, hex digits must be uppercase")
+	_, err = ParseBinaryInteger("#abcd", BASE_HEX)
+	assert.EqualError(t, err, "invalid binary integer #abcd, hex digits must be uppercase")
+}
+
+func TestDisplayBinaryInteger(t *testing.T) {
+	tests := []struct {
+		base     BinaryBase
+		wordSize int
+		value    uint64
+		expected string
+	}{
+		{BASE_DEC, 64, 255, "#255d"},
+		{BASE_HEX, 64, 255, "#FFh"},
+		{BASE_OCT, 64, 8, "#10o"},
+		{BASE_BIN, 64, 5, "#101b"},
+		{BASE_HEX, 8, 0x1FF, "#FFh"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.expected), func(t *testing.T) {
+			modes := NewModes()
+			modes.SetBase(test.base)
+			modes.SetWordSize(test.wordSize)
+			assert.Equal(t, test.expected, binVar(test.value).display(modes))
+		})
+	}
+}
+
+func TestBinaryOperations(t *testing.T) {
+	tests := []struct {
+		wordSize int
+		action   Action
+		inputs   []Variable
+		expected Variable
+	}{
+		{64, &andOp, []Variable{binVar(0b1100), binVar(0b1010)}, binVar(0b1000)},
+		{64, &orOp, []Variable{binVar(0b1100), binVar(0b1010)}, binVar(0b1110)},
+		{64, &xorOp, []Variable{binVar(0b1100), binVar(0b1010)}, binVar(0b0110)},
+		{8, &notOp, []Variable{binVar(0b1100)}, binVar(0xF3)},
+		{64, &andOp, []Variable{CreateBooleanVariable(true), CreateBooleanVariable(false)}, CreateBooleanVariable(false)},
+		{64, &notOp, []Variable{CreateBooleanVariable(true)}, CreateBooleanVariable(false)},
+		{8, &shiftLeftOp, []Variable{binVar(0x81)}, binVar(0x02)},
+		{8, &shiftRightOp, []Variable{binVar(0x81)}, binVar(0x40)},
+		{8, &arithmeticShiftRightOp, []Variable{binVar(0x81)}, binVar(0xC0)},
+		{16, &shiftLeftByteOp, []Variable{binVar(0x1234)}, binVar(0x3400)},
+		{16, &shiftRightByteOp, []Variable{binVar(0x1234)}, binVar(0x0012)},
+		{8, &rotateLeftOp, []Variable{binVar(0x81)}, binVar(0x03)},
+		{8, &rotateRightOp, []Variable{binVar(0x81)}, binVar(0xC0)},
+		{16, &rotateLeftByteOp, []Variable{binVar(0x1234)}, binVar(0x3412)},
+		{16, &rotateRightByteOp, []Variable{binVar(0x1200)}, binVar(0x0012)},
+		{8, &addOp, []Variable{binVar(0xFF), binVar(2)}, binVar(1)},
+		{8, &subOp, []Variable{binVar(1), binVar(2)}, binVar(0xFF)},
+		{64, &mulOp, []Variable{binVar(6), binVar(7)}, binVar(42)},
+		{64, &divOp, []Variable{binVar(7), binVar(2)}, binVar(3)},
+		{64, &binaryToRealOp, []Variable{binVar(255)}, numVar(255)},
+		{64, &realToBinaryOp, []Variable{numVar(255)}, binVar(255)},
+		{8, &realToBinaryOp, []Variable{numVar(-1)}, binVar(0xFF)},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			system := CreateSystemInstance()
+			system.Modes().SetWordSize(test.wordSize)
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(system, stack)
+			if assert.NoError(t, runtimeContext.RunAction(test.action)) {
+				result, err := stack.Pop()
+				if assert.NoError(t, err) {
+					assertSameVariable(t, test.expected, result)
+					assert.Equal(t, 0, stack.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestBinaryOperationsErrors(t *testing.T) {
+	tests := []struct {
+		action        Action
+		inputs        []Variable
+		expectedError string
+	}{
+		{&divOp, []Variable{binVar(1), binVar(0)}, "division by zero"},
+		{&andOp, []Variable{binVar(1), CreateBooleanVariable(true)}, "unsupported argument types (binary integer, boolean) for and"},
+		{&realToBinaryOp, []Variable{createNumericVariableFromFloat(1.5)}, "1.5 is not an integer"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			assert.EqualError(t, runtimeContext.RunAction(test.action), test.expectedError)
+			assert.Equal(t, len(test.inputs), stack.Size())
+		})
+	}
+}
+
+func TestWordSizeActions(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	assert.NoError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("16 stws hex rcws")))
+	assert.Equal(t, 16, system.Modes().wordSize)
+	assert.Equal(t, BASE_HEX, system.Modes().base)
+	result, err := stack.Pop()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "16", result.display(NewModes()))
+	}
+	assert.EqualError(t, RunActionsInTransaction(system, stack, splitCommandLineForTest("65 stws")),
+		"action 2 (stws) failed: 65 is not a valid word size, expected 1..64")
+}
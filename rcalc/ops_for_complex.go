@@ -0,0 +1,170 @@
+package rcalc
+
+import (
+	"math/cmplx"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tooling for complex functions, numbers are promoted to complex numbers with a null imaginary part
+
+func GetEltAsComplex(elts []Variable, idx int) complex128 {
+	if isOfType(elts[idx], TYPE_NUMERIC) {
+		return complex(GetEltAsNumeric(elts, idx).InexactFloat64(), 0)
+	}
+	return elts[idx].asComplexVar().value
+}
+
+type A1R1ComplexFn func(c complex128) complex128
+
+func A1R1ComplexApplyFn(f A1R1ComplexFn) PureOperationApplyFn {
+	return func(elts ...Variable) []Variable {
+		return []Variable{CreateComplexVariable(f(GetEltAsComplex(elts, 0)))}
+	}
+}
+
+func NewA1R1ComplexVariant(f A1R1ComplexFn) OperationVariant {
+	return NewOperationVariant(A1R1ComplexApplyFn(f), TYPE_COMPLEX)
+}
+
+type A2R1ComplexFn func(c1 complex128, c2 complex128) complex128
+
+// A2R1ComplexApplyFn calls f with the same arguments order as A2R1NumericApplyFn
+func A2R1ComplexApplyFn(f A2R1ComplexFn) PureOperationApplyFn {
+	return func(elts ...Variable) []Variable {
+		elt1 := GetEltAsComplex(elts, 1)
+		elt2 := GetEltAsComplex(elts, 0)
+		return []Variable{CreateComplexVariable(f(elt1, elt2))}
+	}
+}
+
+// NewA2R1ComplexVariants returns the variants of a function of 2 arguments for which one of them
+// at least is complex
+func NewA2R1ComplexVariants(f A2R1ComplexFn) []OperationVariant {
+	applyFn := A2R1ComplexApplyFn(f)
+	return []OperationVariant{
+		NewOperationVariant(applyFn, TYPE_COMPLEX, TYPE_COMPLEX),
+		NewOperationVariant(applyFn, TYPE_COMPLEX, TYPE_NUMERIC),
+		NewOperationVariant(applyFn, TYPE_NUMERIC, TYPE_COMPLEX),
+	}
+}
+
+// NewA1R1RealOrComplexVariant applies realFn to numbers inside the domain of the real function
+// and complexFn to the other ones, the result being complex
+func NewA1R1RealOrComplexVariant(inDomain func(num decimal.Decimal) bool, realFn A1R1NumericFn, complexFn A1R1ComplexFn) OperationVariant {
+	return NewOperationVariant(func(elts ...Variable) []Variable {
+		num := GetEltAsNumeric(elts, 0)
+		if inDomain(num) {
+			return []Variable{CreateNumericVariable(realFn(num))}
+		}
+		return []Variable{CreateComplexVariable(complexFn(complex(num.InexactFloat64(), 0)))}
+	}, TYPE_NUMERIC)
+}
+
+func isBetweenMinusOneAndOne(num decimal.Decimal) bool {
+	return num.Abs().LessThanOrEqual(decimal.NewFromInt(1))
+}
+
+// Complex package
+
+var rectToComplexOp = NewOperationDesc("r->c", 2, CheckAllNumerics, 1, OpToActionFn(func(elts ...Variable) []Variable {
+	re := GetEltAsNumeric(elts, 0).InexactFloat64()
+	im := GetEltAsNumeric(elts, 1).InexactFloat64()
+	return []Variable{CreateComplexVariable(complex(re, im))}
+}))
+
+var polarToComplexOp = NewOperationDesc("p->c", 2, CheckAllNumerics, 1, OpToActionFn(func(elts ...Variable) []Variable {
+	modulus := GetEltAsNumeric(elts, 0).InexactFloat64()
+	angle := GetEltAsNumeric(elts, 1).InexactFloat64()
+	return []Variable{CreateComplexVariable(cmplx.Rect(modulus, angle))}
+}))
+
+var complexToRectOp = NewVariantsOp("c->r", 1, 2,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		c := GetEltAsComplex(elts, 0)
+		return []Variable{createNumericVariableFromFloat(real(c)), createNumericVariableFromFloat(imag(c))}
+	}, TYPE_COMPLEX),
+)
+
+var absOp = NewVariantsOp("abs", 1, 1,
+	NewOperationVariant(A1R1NumericApplyFn(func(num decimal.Decimal) decimal.Decimal {
+		return num.Abs()
+	}), TYPE_NUMERIC),
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{createNumericVariableFromFloat(cmplx.Abs(GetEltAsComplex(elts, 0)))}
+	}, TYPE_COMPLEX),
+)
+
+var argOp = NewVariantsOp("arg", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{createNumericVariableFromFloat(cmplx.Phase(GetEltAsComplex(elts, 0)))}
+	}, TYPE_NUMERIC),
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{createNumericVariableFromFloat(cmplx.Phase(GetEltAsComplex(elts, 0)))}
+	}, TYPE_COMPLEX),
+)
+
+var conjOp = NewVariantsOp("conj", 1, 1,
+	NewA1R1ComplexVariant(cmplx.Conj),
+)
+
+var ComplexPackage = ActionPackage{
+	name: "complex",
+	staticActions: []Action{
+		&rectToComplexOp,
+		&polarToComplexOp,
+		&complexToRectOp,
+		&absOp,
+		&argOp,
+		&conjOp,
+	},
+	docs: map[string]ActionDoc{
+		"r->c": {
+			Summary:  "creates a complex number from its real and imaginary parts",
+			Stack:    "re im -> (re, im)",
+			Types:    "numbers",
+			Examples: []DocExample{{"1 2 r->c", "(1, 2)"}},
+		},
+		"p->c": {
+			Summary:  "creates a complex number from its modulus and its angle in radians",
+			Stack:    "r angle -> (re, im)",
+			Types:    "numbers",
+			Examples: []DocExample{{"2 0 p->c", "(2, 0)"}},
+		},
+		"c->r": {
+			Summary:  "splits a complex number into its real and imaginary parts",
+			Stack:    "(re, im) -> re im",
+			Types:    "complex numbers",
+			Examples: []DocExample{{"(3, 4) c->r", "3 4"}},
+		},
+		"abs": {
+			Summary:  "absolute value of a number, modulus of a complex number",
+			Stack:    "x -> |x|",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"-3 abs", "3"}, {"(3, 4) abs", "5"}},
+		},
+		"arg": {
+			Summary:  "angle of a complex number in radians",
+			Stack:    "z -> arg(z)",
+			Types:    "numbers, complex numbers",
+			Examples: []DocExample{{"(1, 0) arg", "0"}},
+		},
+		"conj": {
+			Summary:  "conjugate of a complex number",
+			Stack:    "(re, im) -> (re, -im)",
+			Types:    "complex numbers",
+			Examples: []DocExample{{"(1, 2) conj", "(1, -2)"}},
+		},
+	},
+}
+
+// sqrtOp returns a complex result for negative numbers
+var sqrtOp = NewVariantsOp("sqrt", 1, 1,
+	NewA1R1RealOrComplexVariant(
+		func(num decimal.Decimal) bool { return !num.IsNegative() },
+		func(num decimal.Decimal) decimal.Decimal {
+			return num.Pow(decimal.New(5, -1))
+		},
+		cmplx.Sqrt),
+	NewA1R1ComplexVariant(cmplx.Sqrt),
+)
@@ -0,0 +1,61 @@
+package rcalc
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cplxVar(re float64, im float64) Variable {
+	return CreateComplexVariable(complex(re, im))
+}
+
+func TestComplexOperations(t *testing.T) {
+	tests := []struct {
+		action   Action
+		inputs   []Variable
+		expected []Variable
+	}{
+		{&addOp, []Variable{cplxVar(1, 2), cplxVar(3, 4)}, []Variable{cplxVar(4, 6)}},
+		{&addOp, []Variable{numVar(1), cplxVar(3, 4)}, []Variable{cplxVar(4, 4)}},
+		{&subOp, []Variable{cplxVar(1, 2), numVar(3)}, []Variable{cplxVar(-2, 2)}},
+		{&mulOp, []Variable{cplxVar(1, 2), cplxVar(3, 4)}, []Variable{cplxVar(-5, 10)}},
+		{&divOp, []Variable{cplxVar(-5, 10), cplxVar(3, 4)}, []Variable{cplxVar(1, 2)}},
+		{&powOp, []Variable{cplxVar(0, 1), numVar(2)}, []Variable{cplxVar(-1, 0)}},
+		{&powOp, []Variable{numVar(-4), createNumericVariableFromFloat(0.5)}, []Variable{cplxVar(0, 2)}},
+		{&powOp, []Variable{numVar(-8), ratVar(1, 3)}, []Variable{cplxVar(1, math.Sqrt(3))}},
+		{&powOp, []Variable{numVar(-2), numVar(3)}, []Variable{numVar(-8)}},
+		{&sqrtOp, []Variable{numVar(-4)}, []Variable{cplxVar(0, 2)}},
+		{&sqrtOp, []Variable{numVar(9)}, []Variable{numVar(3)}},
+		{&arcSinOp, []Variable{numVar(1)}, []Variable{createNumericVariableFromFloat(math.Pi / 2)}},
+		{&arcSinOp, []Variable{numVar(2)}, []Variable{cplxVar(math.Pi/2, 1.3169578969248166)}},
+		{&cosOp, []Variable{cplxVar(0, 0)}, []Variable{cplxVar(1, 0)}},
+		{&rectToComplexOp, []Variable{numVar(3), numVar(4)}, []Variable{cplxVar(3, 4)}},
+		{&polarToComplexOp, []Variable{numVar(2), createNumericVariableFromFloat(math.Pi / 2)}, []Variable{cplxVar(0, 2)}},
+		{&complexToRectOp, []Variable{cplxVar(3, 4)}, []Variable{numVar(3), numVar(4)}},
+		{&absOp, []Variable{cplxVar(3, 4)}, []Variable{numVar(5)}},
+		{&absOp, []Variable{numVar(-3)}, []Variable{numVar(3)}},
+		{&argOp, []Variable{cplxVar(0, 2)}, []Variable{createNumericVariableFromFloat(math.Pi / 2)}},
+		{&conjOp, []Variable{cplxVar(3, 4)}, []Variable{cplxVar(3, -4)}},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			if assert.NoError(t, runtimeContext.RunAction(test.action)) {
+				results, err := stack.PopN(len(test.expected))
+				if assert.NoError(t, err) {
+					for i, expected := range test.expected {
+						assertSameVariable(t, expected, results[i])
+					}
+					assert.Equal(t, 0, stack.Size())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package rcalc
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Tooling for vectors and matrices
+
+func GetEltAsVector(elts []Variable, idx int) *mat.VecDense {
+	return elts[idx].asVectorVar().value
+}
+
+func GetEltAsMatrix(elts []Variable, idx int) *mat.Dense {
+	return elts[idx].asMatrixVar().value
+}
+
+func createNumericVariableFromFloat(value float64) Variable {
+	return CreateNumericVariable(decimal.NewFromFloat(value))
+}
+
+func checkSameVectorSizes(elts ...Variable) (bool, error) {
+	size1, size2 := GetEltAsVector(elts, 0).Len(), GetEltAsVector(elts, 1).Len()
+	if size1 != size2 {
+		return false, fmt.Errorf("vectors must have the same size, found %d and %d", size1, size2)
+	}
+	return true, nil
+}
+
+func checkSameMatrixDims(elts ...Variable) (bool, error) {
+	rows1, cols1 := GetEltAsMatrix(elts, 0).Dims()
+	rows2, cols2 := GetEltAsMatrix(elts, 1).Dims()
+	if rows1 != rows2 || cols1 != cols2 {
+		return false, fmt.Errorf("matrices must have the same dimensions, found %dx%d and %dx%d", rows1, cols1, rows2, cols2)
+	}
+	return true, nil
+}
+
+// checkProductDims checks the number of columns of the matrix at index 0 matches the number of rows
+// of the matrix or vector at index 1
+func checkProductDims(elts ...Variable) (bool, error) {
+	_, cols := GetEltAsMatrix(elts, 0).Dims()
+	var rows int
+	if elts[1].getType() == TYPE_VECTOR {
+		rows = GetEltAsVector(elts, 1).Len()
+	} else {
+		rows, _ = GetEltAsMatrix(elts, 1).Dims()
+	}
+	if cols != rows {
+		return false, fmt.Errorf("dimensions mismatch, %d columns for %d rows", cols, rows)
+	}
+	return true, nil
+}
+
+func checkSquareMatrix(elts ...Variable) (bool, error) {
+	rows, cols := GetEltAsMatrix(elts, 0).Dims()
+	if rows != cols {
+		return false, fmt.Errorf("matrix must be square, found %dx%d", rows, cols)
+	}
+	return true, nil
+}
+
+// checkInvertibleMatrix rejects the singular matrices, and the near-singular ones whose inverse
+// would be meaningless: the limit on the condition number is the one of gonum
+func checkInvertibleMatrix(elts ...Variable) (bool, error) {
+	if ok, err := checkSquareMatrix(elts...); !ok {
+		return ok, err
+	}
+	var lu mat.LU
+	lu.Factorize(GetEltAsMatrix(elts, 0))
+	if lu.Det() == 0 {
+		return false, fmt.Errorf("matrix is singular")
+	}
+	if condition := lu.Cond(); !(condition <= mat.ConditionTolerance) {
+		return false, fmt.Errorf("matrix is near-singular, condition number %.4g", condition)
+	}
+	return true, nil
+}
+
+// checkDimension checks the numbers at the given indexes are strictly positive integers
+func checkDimension(indexes ...int) CheckTypeFn {
+	return func(elts ...Variable) (bool, error) {
+		for _, idx := range indexes {
+			if elts[idx].getType() != TYPE_NUMERIC {
+				return false, fmt.Errorf("dimension must be a number, found: %v", elts[idx].getType())
+			}
+			v := GetEltAsNumeric(elts, idx)
+			if !v.IsInteger() || !v.IsPositive() {
+				return false, fmt.Errorf("%v is not a valid dimension", v)
+			}
+		}
+		return true, nil
+	}
+}
+
+// checkPosition checks a 1 based position is an integer between 1 and size
+func checkPosition(position Variable, size int) error {
+	if position.getType() != TYPE_NUMERIC {
+		return fmt.Errorf("position must be a number, found: %v", position.getType())
+	}
+	v := position.asNumericVar().value
+	if !v.IsInteger() || v.IntPart() < 1 || v.IntPart() > int64(size) {
+		return fmt.Errorf("position %v is out of range 1..%d", v, size)
+	}
+	return nil
+}
+
+// Arithmetic variants, used by +, - and *
+
+var addVectorsVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	var result mat.VecDense
+	result.AddVec(GetEltAsVector(elts, 0), GetEltAsVector(elts, 1))
+	return []Variable{CreateVectorVariable(&result)}
+}, TYPE_VECTOR, TYPE_VECTOR).WithCheck(checkSameVectorSizes)
+
+var addMatricesVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	var result mat.Dense
+	result.Add(GetEltAsMatrix(elts, 0), GetEltAsMatrix(elts, 1))
+	return []Variable{CreateMatrixVariable(&result)}
+}, TYPE_MATRIX, TYPE_MATRIX).WithCheck(checkSameMatrixDims)
+
+var subVectorsVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	var result mat.VecDense
+	result.SubVec(GetEltAsVector(elts, 0), GetEltAsVector(elts, 1))
+	return []Variable{CreateVectorVariable(&result)}
+}, TYPE_VECTOR, TYPE_VECTOR).WithCheck(checkSameVectorSizes)
+
+var subMatricesVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	var result mat.Dense
+	result.Sub(GetEltAsMatrix(elts, 0), GetEltAsMatrix(elts, 1))
+	return []Variable{CreateMatrixVariable(&result)}
+}, TYPE_MATRIX, TYPE_MATRIX).WithCheck(checkSameMatrixDims)
+
+// scaleFn multiplies the vector or matrix at index vIdx by the number at index numIdx
+func scaleFn(numIdx int, vIdx int) PureOperationApplyFn {
+	return func(elts ...Variable) []Variable {
+		factor := GetEltAsNumeric(elts, numIdx).InexactFloat64()
+		if elts[vIdx].getType() == TYPE_VECTOR {
+			var result mat.VecDense
+			result.ScaleVec(factor, GetEltAsVector(elts, vIdx))
+			return []Variable{CreateVectorVariable(&result)}
+		}
+		var result mat.Dense
+		result.Scale(factor, GetEltAsMatrix(elts, vIdx))
+		return []Variable{CreateMatrixVariable(&result)}
+	}
+}
+
+var scaleVariants = []OperationVariant{
+	NewOperationVariant(scaleFn(0, 1), TYPE_NUMERIC, TYPE_VECTOR),
+	NewOperationVariant(scaleFn(1, 0), TYPE_VECTOR, TYPE_NUMERIC),
+	NewOperationVariant(scaleFn(0, 1), TYPE_NUMERIC, TYPE_MATRIX),
+	NewOperationVariant(scaleFn(1, 0), TYPE_MATRIX, TYPE_NUMERIC),
+}
+
+var mulMatricesVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	var result mat.Dense
+	result.Mul(GetEltAsMatrix(elts, 0), GetEltAsMatrix(elts, 1))
+	return []Variable{CreateMatrixVariable(&result)}
+}, TYPE_MATRIX, TYPE_MATRIX).WithCheck(checkProductDims)
+
+var mulMatrixVectorVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	var result mat.VecDense
+	result.MulVec(GetEltAsMatrix(elts, 0), GetEltAsVector(elts, 1))
+	return []Variable{CreateVectorVariable(&result)}
+}, TYPE_MATRIX, TYPE_VECTOR).WithCheck(checkProductDims)
+
+// Linear algebra operations
+
+var dotOp = NewVariantsOp("dot", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{createNumericVariableFromFloat(mat.Dot(GetEltAsVector(elts, 0), GetEltAsVector(elts, 1)))}
+	}, TYPE_VECTOR, TYPE_VECTOR).WithCheck(checkSameVectorSizes),
+)
+
+var crossOp = NewVariantsOp("cross", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		u, v := GetEltAsVector(elts, 0), GetEltAsVector(elts, 1)
+		return []Variable{CreateVectorVariableFromValues([]float64{
+			u.AtVec(1)*v.AtVec(2) - u.AtVec(2)*v.AtVec(1),
+			u.AtVec(2)*v.AtVec(0) - u.AtVec(0)*v.AtVec(2),
+			u.AtVec(0)*v.AtVec(1) - u.AtVec(1)*v.AtVec(0),
+		})}
+	}, TYPE_VECTOR, TYPE_VECTOR).WithCheck(func(elts ...Variable) (bool, error) {
+		if GetEltAsVector(elts, 0).Len() != 3 || GetEltAsVector(elts, 1).Len() != 3 {
+			return false, fmt.Errorf("cross product is only defined for vectors of size 3")
+		}
+		return true, nil
+	}),
+)
+
+var transposeOp = NewVariantsOp("trn", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		var result mat.Dense
+		result.CloneFrom(GetEltAsMatrix(elts, 0).T())
+		return []Variable{CreateMatrixVariable(&result)}
+	}, TYPE_MATRIX),
+)
+
+var detOp = NewVariantsOp("det", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{createNumericVariableFromFloat(mat.Det(GetEltAsMatrix(elts, 0)))}
+	}, TYPE_MATRIX).WithCheck(checkSquareMatrix),
+)
+
+var invOp = NewVariantsOp("inv", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		var result mat.Dense
+		// singular and near-singular matrices are rejected by the check
+		_ = result.Inverse(GetEltAsMatrix(elts, 0))
+		return []Variable{CreateMatrixVariable(&result)}
+	}, TYPE_MATRIX).WithCheck(checkInvertibleMatrix),
+)
+
+// solveOp solves A.X = B with A at level 2 and B, a vector or a matrix, at level 1
+var solveOp = NewVariantsOp("solve", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		var result mat.VecDense
+		_ = result.SolveVec(GetEltAsMatrix(elts, 0), GetEltAsVector(elts, 1))
+		return []Variable{CreateVectorVariable(&result)}
+	}, TYPE_MATRIX, TYPE_VECTOR).WithCheck(checkSolveArgs),
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		var result mat.Dense
+		_ = result.Solve(GetEltAsMatrix(elts, 0), GetEltAsMatrix(elts, 1))
+		return []Variable{CreateMatrixVariable(&result)}
+	}, TYPE_MATRIX, TYPE_MATRIX).WithCheck(checkSolveArgs),
+)
+
+func checkSolveArgs(elts ...Variable) (bool, error) {
+	if ok, err := checkInvertibleMatrix(elts...); !ok {
+		return ok, err
+	}
+	return checkProductDims(elts...)
+}
+
+var identityOp = NewOperationDesc("idn", 1, checkDimension(0), 1, OpToActionFn(func(elts ...Variable) []Variable {
+	size := int(GetEltAsNumeric(elts, 0).IntPart())
+	result := mat.NewDense(size, size, nil)
+	for i := 0; i < size; i++ {
+		result.Set(i, i, 1)
+	}
+	return []Variable{CreateMatrixVariable(result)}
+}))
+
+var zerosOp = NewOperationDesc("zeros", 2, checkDimension(0, 1), 1, OpToActionFn(func(elts ...Variable) []Variable {
+	rows := int(GetEltAsNumeric(elts, 0).IntPart())
+	cols := int(GetEltAsNumeric(elts, 1).IntPart())
+	return []Variable{CreateMatrixVariable(mat.NewDense(rows, cols, nil))}
+}))
+
+// getOp returns an element of a vector from its position or of a matrix from a list { row col },
+// positions start at 1
+var getOp = NewVariantsOp("get", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		position := int(GetEltAsNumeric(elts, 1).IntPart())
+		return []Variable{createNumericVariableFromFloat(GetEltAsVector(elts, 0).AtVec(position - 1))}
+	}, TYPE_VECTOR, TYPE_NUMERIC).WithCheck(func(elts ...Variable) (bool, error) {
+		if err := checkPosition(elts[1], GetEltAsVector(elts, 0).Len()); err != nil {
+			return false, err
+		}
+		return true, nil
+	}),
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		positions := elts[1].asListVar().items
+		row := int(positions[0].asNumericVar().value.IntPart())
+		col := int(positions[1].asNumericVar().value.IntPart())
+		return []Variable{createNumericVariableFromFloat(GetEltAsMatrix(elts, 0).At(row-1, col-1))}
+	}, TYPE_MATRIX, TYPE_LIST).WithCheck(func(elts ...Variable) (bool, error) {
+		positions := elts[1].asListVar().items
+		if len(positions) != 2 {
+			return false, fmt.Errorf("matrix element position must be a list { row col }")
+		}
+		rows, cols := GetEltAsMatrix(elts, 0).Dims()
+		if err := checkPosition(positions[0], rows); err != nil {
+			return false, err
+		}
+		if err := checkPosition(positions[1], cols); err != nil {
+			return false, err
+		}
+		return true, nil
+	}),
+)
+
+var LinearAlgebraPackage = ActionPackage{
+	name: "linear algebra",
+	staticActions: []Action{
+		&dotOp,
+		&crossOp,
+		&transposeOp,
+		&detOp,
+		&invOp,
+		&solveOp,
+		&identityOp,
+		&zerosOp,
+		&getOp,
+	},
+	docs: map[string]ActionDoc{
+		"dot": {
+			Summary:  "dot product of two vectors of the same size",
+			Stack:    "u v -> u.v",
+			Types:    "vectors",
+			Examples: []DocExample{{"[ 1 2 ] [ 3 4 ] dot", "11"}},
+		},
+		"cross": {
+			Summary:  "cross product of two vectors of size 3",
+			Stack:    "u v -> u^v",
+			Types:    "vectors",
+			Examples: []DocExample{{"[ 1 0 0 ] [ 0 1 0 ] cross", "[ 0 0 1 ]"}},
+		},
+		"trn": {
+			Summary:  "transpose of a matrix",
+			Stack:    "M -> tM",
+			Types:    "matrices",
+			Examples: []DocExample{{"[ [ 1 2 ] [ 3 4 ] ] trn", "[ [ 1 3 ] [ 2 4 ] ]"}},
+		},
+		"det": {
+			Summary:  "determinant of a square matrix",
+			Stack:    "M -> det(M)",
+			Types:    "matrices",
+			Examples: []DocExample{{"[ [ 2 0 ] [ 0 3 ] ] det", "6"}},
+		},
+		"inv": {
+			Summary:  "inverse of an invertible matrix",
+			Stack:    "M -> M^-1",
+			Types:    "matrices",
+			Examples: []DocExample{{"[ [ 2 0 ] [ 0 4 ] ] inv", "[ [ 0.5 0 ] [ 0 0.25 ] ]"}},
+		},
+		"solve": {
+			Summary:  "solves the linear system A.X = B",
+			Stack:    "A B -> X",
+			Types:    "an invertible matrix, then a vector or a matrix",
+			Examples: []DocExample{{"[ [ 2 0 ] [ 0 4 ] ] [ 2 8 ] solve", "[ 1 2 ]"}},
+		},
+		"idn": {
+			Summary:  "identity matrix of size n",
+			Stack:    "n -> I",
+			Types:    "a positive integer",
+			Examples: []DocExample{{"2 idn", "[ [ 1 0 ] [ 0 1 ] ]"}},
+		},
+		"zeros": {
+			Summary:  "null matrix of rows x cols",
+			Stack:    "rows cols -> M",
+			Types:    "positive integers",
+			Examples: []DocExample{{"1 2 zeros", "[ [ 0 0 ] ]"}},
+		},
+		"get": {
+			Summary:  "element of a vector at a position, or of a matrix at { row col }, positions start at 1",
+			Stack:    "v i -> v(i)",
+			Types:    "a vector and an integer, a matrix and a list of 2 integers",
+			Examples: []DocExample{{"[ 5 6 7 ] 2 get", "6"}, {"[ [ 1 2 ] [ 3 4 ] ] { 2 1 } get", "3"}},
+		},
+	},
+}
@@ -0,0 +1,114 @@
+package rcalc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func vecVar(values ...float64) Variable {
+	return CreateVectorVariableFromValues(values)
+}
+
+func matVar(rows int, cols int, values ...float64) Variable {
+	return CreateMatrixVariable(mat.NewDense(rows, cols, values))
+}
+
+func assertSameVariable(t *testing.T, expected Variable, actual Variable) {
+	if !assert.Equal(t, expected.getType(), actual.getType()) {
+		return
+	}
+	switch expected.getType() {
+	case TYPE_NUMERIC:
+		assert.InDelta(t, expected.asNumericVar().value.InexactFloat64(), actual.asNumericVar().value.InexactFloat64(), 1e-9)
+	case TYPE_COMPLEX:
+		assert.InDelta(t, real(expected.asComplexVar().value), real(actual.asComplexVar().value), 1e-9)
+		assert.InDelta(t, imag(expected.asComplexVar().value), imag(actual.asComplexVar().value), 1e-9)
+	case TYPE_QUANTITY:
+		assert.Equal(t, expected.asQuantityVar().unit.String(), actual.asQuantityVar().unit.String())
+		assert.InDelta(t, expected.asQuantityVar().value.InexactFloat64(), actual.asQuantityVar().value.InexactFloat64(), 1e-9)
+	case TYPE_VECTOR:
+		assert.True(t, mat.EqualApprox(expected.asVectorVar().value, actual.asVectorVar().value, 1e-9),
+			"Expected %s, got %s", expected.display(NewModes()), actual.display(NewModes()))
+	case TYPE_MATRIX:
+		assert.True(t, mat.EqualApprox(expected.asMatrixVar().value, actual.asMatrixVar().value, 1e-9),
+			"Expected %s, got %s", expected.display(NewModes()), actual.display(NewModes()))
+	default:
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestLinearAlgebraOperations(t *testing.T) {
+	tests := []struct {
+		action   Action
+		inputs   []Variable
+		expected Variable
+	}{
+		{&addOp, []Variable{vecVar(1, 2), vecVar(3, 4)}, vecVar(4, 6)},
+		{&subOp, []Variable{vecVar(1, 2), vecVar(3, 5)}, vecVar(-2, -3)},
+		{&addOp, []Variable{matVar(1, 2, 1, 2), matVar(1, 2, 3, 4)}, matVar(1, 2, 4, 6)},
+		{&subOp, []Variable{matVar(1, 2, 1, 2), matVar(1, 2, 3, 4)}, matVar(1, 2, -2, -2)},
+		{&mulOp, []Variable{numVar(2), vecVar(1, 2)}, vecVar(2, 4)},
+		{&mulOp, []Variable{matVar(1, 2, 1, 2), numVar(3)}, matVar(1, 2, 3, 6)},
+		{&mulOp, []Variable{matVar(2, 2, 1, 2, 3, 4), matVar(2, 1, 5, 6)}, matVar(2, 1, 17, 39)},
+		{&mulOp, []Variable{matVar(2, 2, 1, 2, 3, 4), vecVar(5, 6)}, vecVar(17, 39)},
+		{&dotOp, []Variable{vecVar(1, 2, 3), vecVar(4, 5, 6)}, numVar(32)},
+		{&crossOp, []Variable{vecVar(1, 0, 0), vecVar(0, 1, 0)}, vecVar(0, 0, 1)},
+		{&transposeOp, []Variable{matVar(2, 3, 1, 2, 3, 4, 5, 6)}, matVar(3, 2, 1, 4, 2, 5, 3, 6)},
+		{&detOp, []Variable{matVar(2, 2, 1, 2, 3, 4)}, numVar(-2)},
+		{&invOp, []Variable{matVar(2, 2, 2, 0, 0, 4)}, matVar(2, 2, 0.5, 0, 0, 0.25)},
+		{&solveOp, []Variable{matVar(2, 2, 2, 1, 1, 3), vecVar(3, 5)}, vecVar(0.8, 1.4)},
+		{&identityOp, []Variable{numVar(2)}, matVar(2, 2, 1, 0, 0, 1)},
+		{&zerosOp, []Variable{numVar(1), numVar(2)}, matVar(1, 2, 0, 0)},
+		{&getOp, []Variable{vecVar(7, 8, 9), numVar(2)}, numVar(8)},
+		{&getOp, []Variable{matVar(2, 2, 1, 2, 3, 4), CreateListVariable([]Variable{numVar(2), numVar(1)})}, numVar(3)},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			if assert.NoError(t, runtimeContext.RunAction(test.action)) {
+				result, err := stack.Pop()
+				if assert.NoError(t, err) {
+					assertSameVariable(t, test.expected, result)
+					assert.Equal(t, 0, stack.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestLinearAlgebraOperationsErrors(t *testing.T) {
+	tests := []struct {
+		action        Action
+		inputs        []Variable
+		expectedError string
+	}{
+		{&addOp, []Variable{vecVar(1, 2), vecVar(3)}, "vectors must have the same size, found 2 and 1"},
+		{&mulOp, []Variable{matVar(1, 2, 1, 2), matVar(1, 2, 1, 2)}, "dimensions mismatch, 2 columns for 1 rows"},
+		{&addOp, []Variable{vecVar(1, 2), matVar(1, 2, 1, 2)}, "unsupported argument types (vector, matrix) for +"},
+		{&detOp, []Variable{matVar(1, 2, 1, 2)}, "matrix must be square, found 1x2"},
+		{&invOp, []Variable{matVar(2, 2, 1, 2, 2, 4)}, "matrix is singular"},
+		{&invOp, []Variable{matVar(3, 3, 1, 2, 3, 4, 5, 6, 7, 8, 9)}, "matrix is near-singular, condition number 8.647e+17"},
+		{&solveOp, []Variable{matVar(3, 3, 1, 2, 3, 4, 5, 6, 7, 8, 9), vecVar(1, 2, 3)}, "matrix is near-singular, condition number 8.647e+17"},
+		{&crossOp, []Variable{vecVar(1, 2), vecVar(3, 4)}, "cross product is only defined for vectors of size 3"},
+		{&getOp, []Variable{vecVar(1, 2), numVar(3)}, "position 3 is out of range 1..2"},
+		{&identityOp, []Variable{numVar(0)}, "0 is not a valid dimension"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			assert.EqualError(t, runtimeContext.RunAction(test.action), test.expectedError)
+			assert.Equal(t, len(test.inputs), stack.Size())
+		})
+	}
+}
@@ -26,9 +26,25 @@ var expandListOp = NewRawStackOpWithCheck("expandlist", 1, CheckFirstInt, func(s
 })
 
 var ListPackage = ActionPackage{
+	name: "lists",
 	staticActions: []Action{
 		&toListOp,
 		&expandListOp,
 	},
 	dynamicActions: []Action{},
+	aliases:        map[string]string{"->list": "tolist"},
+	docs: map[string]ActionDoc{
+		"tolist": {
+			Summary:  "creates a list from the n levels above n",
+			Stack:    "x1 ... xn n -> { x1 ... xn }",
+			Types:    "any, an integer for n",
+			Examples: []DocExample{{"1 2 3 2 tolist", "1 { 2 3 }"}},
+		},
+		"expandlist": {
+			Summary:  "pushes the items of a list",
+			Stack:    "{ x1 ... xn } -> x1 ... xn",
+			Types:    "lists",
+			Examples: []DocExample{{"{ 1 2 } expandlist", "1 2"}},
+		},
+	},
 }
@@ -0,0 +1,227 @@
+package rcalc
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringValue returns the content of a string variable and the displayed form of other variables
+func stringValue(modes *Modes, v Variable) string {
+	if v.getType() == TYPE_STR {
+		return v.asStringVar().value
+	}
+	return v.display(modes)
+}
+
+func concatStringsApplyFn(modes *Modes, elts ...Variable) []Variable {
+	return []Variable{CreateStringVariable(stringValue(modes, elts[0]) + stringValue(modes, elts[1]))}
+}
+
+// checkIntegers checks that the arguments at the given indexes are integers, types must have been checked before
+func checkIntegers(checkFn CheckTypeFn, indexes ...int) CheckTypeFn {
+	return func(elts ...Variable) (bool, error) {
+		ok, err := checkFn(elts...)
+		if !ok || err != nil {
+			return ok, err
+		}
+		for _, idx := range indexes {
+			v := GetEltAsNumeric(elts, idx)
+			if !v.IsInteger() {
+				return false, fmt.Errorf("%v is not an integer", v)
+			}
+		}
+		return true, nil
+	}
+}
+
+var sizeOp = NewVariantsOp("size", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{CreateNumericVariableFromInt(utf8.RuneCountInString(elts[0].asStringVar().value))}
+	}, TYPE_STR),
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{CreateNumericVariableFromInt(elts[0].asListVar().Size())}
+	}, TYPE_LIST),
+)
+
+var subStrVariants = []OperationVariant{
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		str := []rune(elts[0].asStringVar().value)
+		// positions start at 1 and the end position is included, out of bounds positions are clamped
+		start := max(int(GetEltAsNumeric(elts, 1).IntPart()), 1)
+		end := min(int(GetEltAsNumeric(elts, 2).IntPart()), len(str))
+		if start > end {
+			return []Variable{CreateStringVariable("")}
+		}
+		return []Variable{CreateStringVariable(string(str[start-1 : end]))}
+	}, TYPE_STR, TYPE_NUMERIC, TYPE_NUMERIC),
+}
+
+var subStrOp = NewOperationDesc("sub", 3,
+	checkIntegers(CheckVariants("sub", subStrVariants), 1, 2),
+	1,
+	VariantsApplyFn(subStrVariants))
+
+// posOp returns the position of the first occurrence of a string in another one, 0 if not found
+var posOp = NewVariantsOp("pos", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		str := elts[0].asStringVar().value
+		idx := strings.Index(str, elts[1].asStringVar().value)
+		if idx < 0 {
+			return []Variable{CreateNumericVariableFromInt(0)}
+		}
+		return []Variable{CreateNumericVariableFromInt(utf8.RuneCountInString(str[:idx]) + 1)}
+	}, TYPE_STR, TYPE_STR),
+)
+
+var toStrOp = NewOperationDesc("->str", 1, CheckNoop, 1, func(system System, elts ...Variable) []Variable {
+	return []Variable{CreateStringVariable(stringValue(system.Modes(), elts[0]))}
+})
+
+// FromStrAction parses the string on the stack and evaluates its content
+type FromStrAction struct {
+	ActionCommonDesc
+}
+
+var _ Action = (*FromStrAction)(nil)
+
+func (a *FromStrAction) NbArgs() int {
+	return 1
+}
+
+func (a *FromStrAction) CheckTypes(elts ...Variable) (bool, error) {
+	if elts[0].getType() != TYPE_STR {
+		return false, fmt.Errorf("%s expects a string, found: %v", a.OpCode(), elts[0].getType())
+	}
+	return true, nil
+}
+
+func (a *FromStrAction) Apply(runtimeContext *RuntimeContext) error {
+	str, err := runtimeContext.stack.Pop()
+	if err != nil {
+		return err
+	}
+	actions, err := ParseToActions(str.asStringVar().value, "StringEvaluation", Registry, runtimeContext.system.Modes())
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		if err := runtimeContext.RunAction(action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *FromStrAction) Display() string {
+	return a.OpCode()
+}
+
+var fromStrAct = FromStrAction{ActionCommonDesc{opCode: "str->"}}
+
+var upperOp = NewVariantsOp("upper", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{CreateStringVariable(strings.ToUpper(elts[0].asStringVar().value))}
+	}, TYPE_STR),
+)
+
+var lowerOp = NewVariantsOp("lower", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{CreateStringVariable(strings.ToLower(elts[0].asStringVar().value))}
+	}, TYPE_STR),
+)
+
+// splitOp splits a string into a list of strings, an empty separator splits each character
+var splitOp = NewVariantsOp("split", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		parts := strings.Split(elts[0].asStringVar().value, elts[1].asStringVar().value)
+		items := make([]Variable, len(parts))
+		for idx, part := range parts {
+			items[idx] = CreateStringVariable(part)
+		}
+		return []Variable{CreateListVariable(items)}
+	}, TYPE_STR, TYPE_STR),
+)
+
+// joinOp joins the items of a list with a separator, items which are not strings are displayed
+var joinOp = NewVariantsOp("join", 2, 1,
+	NewModesOperationVariant(func(modes *Modes, elts ...Variable) []Variable {
+		items := elts[0].asListVar().items
+		parts := make([]string, len(items))
+		for idx, item := range items {
+			parts[idx] = stringValue(modes, item)
+		}
+		return []Variable{CreateStringVariable(strings.Join(parts, elts[1].asStringVar().value))}
+	}, TYPE_LIST, TYPE_STR),
+)
+
+var StringPackage = ActionPackage{
+	name: "strings",
+	staticActions: []Action{
+		&sizeOp,
+		&subStrOp,
+		&posOp,
+		&toStrOp,
+		&fromStrAct,
+		&upperOp,
+		&lowerOp,
+		&splitOp,
+		&joinOp,
+	},
+	docs: map[string]ActionDoc{
+		"size": {
+			Summary:  "number of characters of a string or of items of a list",
+			Stack:    "s -> n",
+			Types:    "strings, lists",
+			Examples: []DocExample{{"\"hello\" size", "5"}, {"{ 1 2 3 } size", "3"}},
+		},
+		"sub": {
+			Summary:  "part of a string between two positions, positions start at 1 and the end is included",
+			Stack:    "s start end -> s",
+			Types:    "a string and integers",
+			Examples: []DocExample{{"\"hello\" 2 4 sub", "\"ell\""}},
+		},
+		"pos": {
+			Summary:  "position of the first occurrence of a string in another one, 0 if not found",
+			Stack:    "s searched -> n",
+			Types:    "strings",
+			Examples: []DocExample{{"\"hello\" \"l\" pos", "3"}},
+		},
+		"->str": {
+			Summary:  "converts a value to its displayed string",
+			Stack:    "x -> s",
+			Types:    "any",
+			Examples: []DocExample{{"1/2 ->str", "\"1/2\""}},
+		},
+		"str->": {
+			Summary:  "parses a string and evaluates its content",
+			Stack:    "s -> ...",
+			Types:    "strings",
+			Examples: []DocExample{{"\"2 3 +\" str->", "5"}},
+		},
+		"upper": {
+			Summary:  "converts a string to upper case",
+			Stack:    "s -> S",
+			Types:    "strings",
+			Examples: []DocExample{{"\"abc\" upper", "\"ABC\""}},
+		},
+		"lower": {
+			Summary:  "converts a string to lower case",
+			Stack:    "S -> s",
+			Types:    "strings",
+			Examples: []DocExample{{"\"ABC\" lower", "\"abc\""}},
+		},
+		"split": {
+			Summary:  "splits a string into a list of strings, an empty separator splits each character",
+			Stack:    "s separator -> { s ... }",
+			Types:    "strings",
+			Examples: []DocExample{{"\"a,b\" \",\" split", "{ \"a\" \"b\" }"}},
+		},
+		"join": {
+			Summary:  "joins the items of a list with a separator",
+			Stack:    "{ x ... } separator -> s",
+			Types:    "a list, then a string",
+			Examples: []DocExample{{"{ 1 2 3 } \"-\" join", "\"1-2-3\""}},
+		},
+	},
+}
@@ -0,0 +1,96 @@
+package rcalc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func strVar(value string) Variable {
+	return CreateStringVariable(value)
+}
+
+func numVar(value int) Variable {
+	return CreateNumericVariableFromInt(value)
+}
+
+func TestStringOperations(t *testing.T) {
+	tests := []struct {
+		action   Action
+		inputs   []Variable
+		expected []Variable
+	}{
+		{&addOp, []Variable{strVar("ab"), strVar("cd")}, []Variable{strVar("abcd")}},
+		{&addOp, []Variable{strVar("x="), numVar(12)}, []Variable{strVar("x=12")}},
+		{&addOp, []Variable{CreateBooleanVariable(true), strVar("!")}, []Variable{strVar("true!")}},
+		{&addOp, []Variable{numVar(1), numVar(2)}, []Variable{numVar(3)}},
+		{&sizeOp, []Variable{strVar("héllo")}, []Variable{numVar(5)}},
+		{&sizeOp, []Variable{CreateListVariable([]Variable{numVar(1), numVar(2)})}, []Variable{numVar(2)}},
+		{&subStrOp, []Variable{strVar("abcdef"), numVar(2), numVar(4)}, []Variable{strVar("bcd")}},
+		{&subStrOp, []Variable{strVar("abcdef"), numVar(0), numVar(10)}, []Variable{strVar("abcdef")}},
+		{&subStrOp, []Variable{strVar("abcdef"), numVar(4), numVar(2)}, []Variable{strVar("")}},
+		{&posOp, []Variable{strVar("abcdef"), strVar("cd")}, []Variable{numVar(3)}},
+		{&posOp, []Variable{strVar("abcdef"), strVar("x")}, []Variable{numVar(0)}},
+		{&toStrOp, []Variable{numVar(42)}, []Variable{strVar("42")}},
+		{&toStrOp, []Variable{strVar("abc")}, []Variable{strVar("abc")}},
+		{&upperOp, []Variable{strVar("abc")}, []Variable{strVar("ABC")}},
+		{&lowerOp, []Variable{strVar("ABC")}, []Variable{strVar("abc")}},
+		{&splitOp, []Variable{strVar("a,b,c"), strVar(",")},
+			[]Variable{CreateListVariable([]Variable{strVar("a"), strVar("b"), strVar("c")})}},
+		{&joinOp, []Variable{CreateListVariable([]Variable{strVar("a"), numVar(2)}), strVar("-")}, []Variable{strVar("a-2")}},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			if assert.NoError(t, runtimeContext.RunAction(test.action)) {
+				results, err := stack.PopN(len(test.expected))
+				if assert.NoError(t, err) {
+					assert.Equal(t, test.expected, results)
+					assert.Equal(t, 0, stack.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestStringOperationsTypeErrors(t *testing.T) {
+	tests := []struct {
+		action Action
+		inputs []Variable
+	}{
+		{&addOp, []Variable{CreateBooleanVariable(true), numVar(1)}},
+		{&subStrOp, []Variable{strVar("abc"), numVar(1), CreateNumericVariable(decimal.RequireFromString("1.5"))}},
+		{&subStrOp, []Variable{strVar("abc"), ratVar(1, 2), numVar(3)}},
+		{&upperOp, []Variable{numVar(1)}},
+		{&fromStrAct, []Variable{numVar(1)}},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			assert.Error(t, runtimeContext.RunAction(test.action))
+			assert.Equal(t, len(test.inputs), stack.Size())
+		})
+	}
+}
+
+func TestFromStrEvaluatesContent(t *testing.T) {
+	stack := CreateStack()
+	stack.Push(strVar(`1 2 + "a" swap`))
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+	if assert.NoError(t, runtimeContext.RunAction(&fromStrAct)) {
+		results, err := stack.PopN(2)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []Variable{strVar("a"), numVar(3)}, results)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package rcalc
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tooling for quantities, numbers with a unit of measure
+
+func GetEltAsQuantity(elts []Variable, idx int) *QuantityVariable {
+	return elts[idx].asQuantityVar()
+}
+
+// targetUnit returns the unit of a quantity or the unit given as a string like "km/h"
+func targetUnit(v Variable) (Unit, error) {
+	if v.getType() == TYPE_STR {
+		return ParseUnit(v.asStringVar().value)
+	}
+	return v.asQuantityVar().unit, nil
+}
+
+// checkConvertible checks the quantity at index 0 can be expressed in the unit of the element at index 1
+func checkConvertible(elts ...Variable) (bool, error) {
+	unit, err := targetUnit(elts[1])
+	if err != nil {
+		return false, err
+	}
+	if _, err = convertUnits(GetEltAsQuantity(elts, 0).value, GetEltAsQuantity(elts, 0).unit, unit); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func checkNonZeroDivisor(elts ...Variable) (bool, error) {
+	var divisor decimal.Decimal
+	if elts[1].getType() == TYPE_QUANTITY {
+		divisor = GetEltAsQuantity(elts, 1).value
+	} else {
+		divisor = GetEltAsNumeric(elts, 1)
+	}
+	if divisor.IsZero() {
+		return false, errDivisionByZero
+	}
+	return true, nil
+}
+
+func checkIntegerExponent(elts ...Variable) (bool, error) {
+	exponent := GetEltAsNumeric(elts, 1)
+	if !exponent.IsInteger() {
+		return false, fmt.Errorf("quantities can only be raised to integer powers, found %v", exponent)
+	}
+	return true, nil
+}
+
+// Arithmetic variants, used by +, -, *, / and ^
+
+// addQuantitiesFn adds or subtracts quantities, the result is expressed in the unit of the quantity at index 0
+func addQuantitiesFn(sign int64) PureOperationApplyFn {
+	return func(elts ...Variable) []Variable {
+		q1, q2 := GetEltAsQuantity(elts, 0), GetEltAsQuantity(elts, 1)
+		value2, _ := convertUnits(q2.value, q2.unit, q1.unit)
+		return []Variable{CreateQuantityVariable(q1.value.Add(value2.Mul(decimal.NewFromInt(sign))), q1.unit)}
+	}
+}
+
+var addQuantitiesVariant = NewOperationVariant(addQuantitiesFn(1), TYPE_QUANTITY, TYPE_QUANTITY).WithCheck(checkConvertible)
+
+var subQuantitiesVariant = NewOperationVariant(addQuantitiesFn(-1), TYPE_QUANTITY, TYPE_QUANTITY).WithCheck(checkConvertible)
+
+// quantityParts returns the value and the unit of a quantity or a number
+func quantityParts(elts []Variable, idx int) (decimal.Decimal, Unit) {
+	if elts[idx].getType() == TYPE_QUANTITY {
+		return GetEltAsQuantity(elts, idx).value, GetEltAsQuantity(elts, idx).unit
+	}
+	return GetEltAsNumeric(elts, idx), Unit{}
+}
+
+func mulQuantitiesFn(elts ...Variable) []Variable {
+	value1, unit1 := quantityParts(elts, 0)
+	value2, unit2 := quantityParts(elts, 1)
+	return []Variable{CreateQuantityOrNumericVariable(value1.Mul(value2), unit1.Mul(unit2))}
+}
+
+func divQuantitiesFn(elts ...Variable) []Variable {
+	value1, unit1 := quantityParts(elts, 0)
+	value2, unit2 := quantityParts(elts, 1)
+	return []Variable{CreateQuantityOrNumericVariable(value1.Div(value2), unit1.Mul(unit2.Pow(-1)))}
+}
+
+var mulQuantitiesVariants = []OperationVariant{
+	NewOperationVariant(mulQuantitiesFn, TYPE_QUANTITY, TYPE_QUANTITY),
+	NewOperationVariant(mulQuantitiesFn, TYPE_QUANTITY, TYPE_NUMERIC),
+	NewOperationVariant(mulQuantitiesFn, TYPE_NUMERIC, TYPE_QUANTITY),
+}
+
+var divQuantitiesVariants = []OperationVariant{
+	NewOperationVariant(divQuantitiesFn, TYPE_QUANTITY, TYPE_QUANTITY).WithCheck(checkNonZeroDivisor),
+	NewOperationVariant(divQuantitiesFn, TYPE_QUANTITY, TYPE_NUMERIC).WithCheck(checkNonZeroDivisor),
+	NewOperationVariant(divQuantitiesFn, TYPE_NUMERIC, TYPE_QUANTITY).WithCheck(checkNonZeroDivisor),
+}
+
+var powQuantityVariant = NewOperationVariant(func(elts ...Variable) []Variable {
+	q := GetEltAsQuantity(elts, 0)
+	exponent := GetEltAsNumeric(elts, 1)
+	return []Variable{CreateQuantityOrNumericVariable(q.value.Pow(exponent), q.unit.Pow(int(exponent.IntPart())))}
+}, TYPE_QUANTITY, TYPE_NUMERIC).WithCheck(checkIntegerExponent)
+
+// Units operations
+
+// convertOp converts the quantity at level 2 to the unit of the quantity or the unit string at level 1
+var convertOp = NewVariantsOp("convert", 2, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		q := GetEltAsQuantity(elts, 0)
+		unit, _ := targetUnit(elts[1])
+		value, _ := convertUnits(q.value, q.unit, unit)
+		return []Variable{CreateQuantityVariable(value, unit)}
+	}, TYPE_QUANTITY, TYPE_GENERIC).WithCheck(func(elts ...Variable) (bool, error) {
+		if elts[1].getType() != TYPE_QUANTITY && elts[1].getType() != TYPE_STR {
+			return false, fmt.Errorf("target unit must be a quantity or a string, found: %v", elts[1].getType())
+		}
+		return checkConvertible(elts...)
+	}),
+)
+
+// ubaseOp expresses a quantity in SI base units
+var ubaseOp = NewVariantsOp("ubase", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		q := GetEltAsQuantity(elts, 0)
+		factor, dimension := q.unit.toBase()
+		return []Variable{CreateQuantityOrNumericVariable(q.value.Mul(factor), baseUnit(dimension))}
+	}, TYPE_QUANTITY),
+)
+
+// uvalOp removes the unit of a quantity
+var uvalOp = NewVariantsOp("uval", 1, 1,
+	NewOperationVariant(func(elts ...Variable) []Variable {
+		return []Variable{CreateNumericVariable(GetEltAsQuantity(elts, 0).value)}
+	}, TYPE_QUANTITY),
+)
+
+var UnitsPackage = ActionPackage{
+	name: "units",
+	staticActions: []Action{
+		&convertOp,
+		&ubaseOp,
+		&uvalOp,
+	},
+	docs: map[string]ActionDoc{
+		"convert": {
+			Summary:  "converts a quantity to the unit of another quantity or to a unit given as a string",
+			Stack:    "q unit -> q",
+			Types:    "a quantity, then a quantity or a string",
+			Examples: []DocExample{{"1_km \"m\" convert", "1000_m"}},
+		},
+		"ubase": {
+			Summary:  "expresses a quantity in SI base units",
+			Stack:    "q -> q",
+			Types:    "quantities",
+			Examples: []DocExample{{"2_km ubase", "2000_m"}},
+		},
+		"uval": {
+			Summary:  "value of a quantity without its unit",
+			Stack:    "q -> x",
+			Types:    "quantities",
+			Examples: []DocExample{{"3_m uval", "3"}},
+		},
+	},
+}
@@ -0,0 +1,116 @@
+package rcalc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func qtyVar(value float64, unit string) Variable {
+	parsedUnit, err := ParseUnit(unit)
+	if err != nil {
+		panic(err)
+	}
+	return CreateQuantityVariable(decimal.NewFromFloat(value), parsedUnit)
+}
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		unit      string
+		expected  string
+		dimension Dimension
+	}{
+		{"m", "m", Dimension{1, 0, 0, 0, 0, 0, 0}},
+		{"km/h", "km/h", Dimension{1, 0, -1, 0, 0, 0, 0}},
+		{"kg*m^2/s^2", "kg*m^2/s^2", Dimension{2, 1, -2, 0, 0, 0, 0}},
+		{"W/m^2/K", "W/m^2/K", Dimension{0, 1, -3, 0, -1, 0, 0}},
+		{"m*s/m", "s", Dimension{0, 0, 1, 0, 0, 0, 0}},
+		{"s^-1", "s^-1", Dimension{0, 0, -1, 0, 0, 0, 0}},
+		{"µm", "µm", Dimension{1, 0, 0, 0, 0, 0, 0}},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.unit), func(t *testing.T) {
+			unit, err := ParseUnit(test.unit)
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expected, unit.String())
+				assert.Equal(t, test.dimension, unit.Dimension())
+			}
+		})
+	}
+}
+
+func TestParseUnitErrors(t *testing.T) {
+	for _, unit := range []string{"", "furlong", "kft", "m//s", "*m"} {
+		_, err := ParseUnit(unit)
+		assert.Error(t, err, "unit %q", unit)
+	}
+}
+
+func TestUnitsOperations(t *testing.T) {
+	tests := []struct {
+		action   Action
+		inputs   []Variable
+		expected Variable
+	}{
+		{&addOp, []Variable{qtyVar(1, "m"), qtyVar(50, "cm")}, qtyVar(1.5, "m")},
+		{&subOp, []Variable{qtyVar(1, "km"), qtyVar(200, "m")}, qtyVar(0.8, "km")},
+		{&mulOp, []Variable{qtyVar(2, "kg"), qtyVar(9.81, "m/s^2")}, qtyVar(19.62, "kg*m/s^2")},
+		{&mulOp, []Variable{numVar(3), qtyVar(2, "m")}, qtyVar(6, "m")},
+		{&divOp, []Variable{qtyVar(100, "km"), qtyVar(2, "h")}, qtyVar(50, "km/h")},
+		{&divOp, []Variable{qtyVar(6, "m"), qtyVar(3, "m")}, numVar(2)},
+		{&divOp, []Variable{qtyVar(1, "km"), qtyVar(1, "m")}, numVar(1000)},
+		{&divOp, []Variable{numVar(1), qtyVar(4, "s")}, qtyVar(0.25, "s^-1")},
+		{&powOp, []Variable{qtyVar(3, "m"), numVar(2)}, qtyVar(9, "m^2")},
+		{&convertOp, []Variable{qtyVar(1, "mi"), CreateStringVariable("km")}, qtyVar(1.609344, "km")},
+		{&convertOp, []Variable{qtyVar(36, "km/h"), qtyVar(1, "m/s")}, qtyVar(10, "m/s")},
+		{&convertOp, []Variable{qtyVar(1, "kWh"), CreateStringVariable("J")}, qtyVar(3600000, "J")},
+		{&ubaseOp, []Variable{qtyVar(2, "kN")}, qtyVar(2000, "m*kg/s^2")},
+		{&uvalOp, []Variable{qtyVar(9.81, "m/s^2")}, createNumericVariableFromFloat(9.81)},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			if assert.NoError(t, runtimeContext.RunAction(test.action)) {
+				result, err := stack.Pop()
+				if assert.NoError(t, err) {
+					assertSameVariable(t, test.expected, result)
+					assert.Equal(t, 0, stack.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestUnitsOperationsErrors(t *testing.T) {
+	tests := []struct {
+		action        Action
+		inputs        []Variable
+		expectedError string
+	}{
+		{&addOp, []Variable{qtyVar(1, "m"), qtyVar(1, "s")}, "incompatible units m and s"},
+		{&addOp, []Variable{qtyVar(1, "m"), numVar(1)}, "1_m has a unit, use uval or ubase to get a number"},
+		{&addOp, []Variable{qtyVar(1, "m"), CreateBooleanVariable(true)}, "unsupported argument types (quantity, boolean) for +"},
+		{&convertOp, []Variable{qtyVar(1, "m"), CreateStringVariable("kg")}, "incompatible units m and kg"},
+		{&convertOp, []Variable{qtyVar(1, "m"), CreateStringVariable("parsec")}, "unknown unit parsec"},
+		{&powOp, []Variable{qtyVar(1, "m"), createNumericVariableFromFloat(0.5)}, "quantities can only be raised to integer powers, found 0.5"},
+		{&divOp, []Variable{qtyVar(1, "m"), numVar(0)}, "division by zero"},
+		{&sinOp, []Variable{qtyVar(1, "m")}, "1_m has a unit, use uval or ubase to get a number"},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("%02d %s", idx+1, test.action.OpCode()), func(t *testing.T) {
+			stack := CreateStack()
+			for _, input := range test.inputs {
+				stack.Push(input)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			assert.EqualError(t, runtimeContext.RunAction(test.action), test.expectedError)
+			assert.Equal(t, len(test.inputs), stack.Size())
+		})
+	}
+}
@@ -103,6 +103,36 @@ func TestStatsTestSuite(t *testing.T) {
 	suite.Run(t, new(StatsTestSuite))
 }
 
+func TestStackOperations(t *testing.T) {
+	tests := []struct {
+		name          string
+		actions       []Action
+		expectedStack []string
+	}{
+		{"dup", []Action{&dupOp}, []string{"1", "2", "3", "3"}},
+		{"dup2", []Action{&dup2Op}, []string{"1", "2", "3", "2", "3"}},
+		{"dupn", []Action{pushAction(2), &dupNOp}, []string{"1", "2", "3", "2", "3"}},
+		{"dupn of all levels", []Action{pushAction(3), &dupNOp}, []string{"1", "2", "3", "1", "2", "3"}},
+		{"dupn of no level", []Action{pushAction(0), &dupNOp}, []string{"1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := CreateStack()
+			stack.PushN([]Variable{numVar(1), numVar(2), numVar(3)})
+			if assert.NoError(t, RunActionsInTransaction(CreateSystemInstance(), stack, tt.actions)) {
+				assert.Equal(t, tt.expectedStack, stackValues(stack))
+			}
+		})
+	}
+}
+
+func TestDupNWithTooFewLevels(t *testing.T) {
+	stack := CreateStack()
+	stack.PushN([]Variable{numVar(1), numVar(2)})
+	assert.Error(t, RunActionsInTransaction(CreateSystemInstance(), stack, []Action{pushAction(3), &dupNOp}))
+	assert.Equal(t, []string{"1", "2"}, stackValues(stack))
+}
+
 func TestCrDirAction(t *testing.T) {
 
 	myFolderName := "MyFolder"
@@ -127,3 +157,208 @@ func TestCrDirAction(t *testing.T) {
 	assert.True(t, found)
 
 }
+
+func TestCrDirExistingName(t *testing.T) {
+	runtimeContext, memory := createMemoryTree(t)
+	folderA := memory.getRoot().subNode("A").(*MemoryFolder)
+	memory.setCurrentFolder(folderA)
+
+	for _, name := range []string{"B", "X"} {
+		err := runMemoryActions(runtimeContext, pushVar(idVar(name)), &crdirAct)
+		assert.ErrorContains(t, err, name+" already exists in folder A")
+	}
+	assert.Len(t, folderA.subFolders, 1)
+	assert.IsType(t, &MemoryVariable{}, folderA.subNode("X"))
+}
+
+func idVar(name string) Variable {
+	return CreateAlgebraicExpressionVariable(name, nil)
+}
+
+func pathVar(names ...string) Variable {
+	items := make([]Variable, len(names))
+	for i, name := range names {
+		items[i] = idVar(name)
+	}
+	return CreateListVariable(items)
+}
+
+func pushVar(v Variable) Action {
+	return &VariablePutOnStackActionDesc{value: v}
+}
+
+// createMemoryTree creates HOME/A/B with variable X in A and Y in B, the current folder is HOME
+func createMemoryTree(t *testing.T) (*RuntimeContext, Memory) {
+	system := CreateSystemInstance()
+	memory := system.Memory()
+	folderA, err := memory.createFolder("A", memory.getRoot())
+	assert.NoError(t, err)
+	folderB, err := memory.createFolder("B", folderA)
+	assert.NoError(t, err)
+	_, err = memory.createVariable("X", folderA, numVar(1))
+	assert.NoError(t, err)
+	_, err = memory.createVariable("Y", folderB, numVar(2))
+	assert.NoError(t, err)
+	return CreateRuntimeContext(system, CreateStack()), memory
+}
+
+func runMemoryActions(runtimeContext *RuntimeContext, actions ...Action) error {
+	for _, action := range actions {
+		if err := runtimeContext.RunAction(action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFolderNavigation(t *testing.T) {
+	runtimeContext, memory := createMemoryTree(t)
+
+	err := runMemoryActions(runtimeContext, &VariableEvaluationActionDesc{varName: "A"}, &VariableEvaluationActionDesc{varName: "B"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A", "B"}, memory.getPath(memory.getCurrentFolder()))
+
+	assert.NoError(t, runMemoryActions(runtimeContext, &VariableEvaluationActionDesc{varName: "Y"}, &pathAct))
+	path, _ := runtimeContext.stack.Pop()
+	assert.Equal(t, "{ 'HOME' 'A' 'B' }", path.display(NewModes()))
+	y, _ := runtimeContext.stack.Pop()
+	assert.Equal(t, int64(2), y.asNumericVar().value.IntPart())
+
+	assert.NoError(t, runMemoryActions(runtimeContext, &updirAct))
+	assert.Equal(t, []string{"A"}, memory.getPath(memory.getCurrentFolder()))
+
+	assert.NoError(t, runMemoryActions(runtimeContext, &homeAct, &updirAct))
+	assert.Equal(t, memory.getRoot(), memory.getCurrentFolder(), "updir stays in HOME")
+
+	assert.NoError(t, runMemoryActions(runtimeContext, pushVar(path), evalAct))
+	assert.Equal(t, []string{"A", "B"}, memory.getPath(memory.getCurrentFolder()), "evaluating a path goes to the folder")
+
+	err = runMemoryActions(runtimeContext, pushVar(pathVar("HOME", "C")), evalAct)
+	assert.ErrorContains(t, err, "folder C not found")
+}
+
+func TestVarsAction(t *testing.T) {
+	runtimeContext, _ := createMemoryTree(t)
+
+	assert.NoError(t, runMemoryActions(runtimeContext, &VariableEvaluationActionDesc{varName: "A"}, &varsAct))
+	vars, _ := runtimeContext.stack.Pop()
+	assert.Equal(t, "{ 'X' }", vars.display(NewModes()))
+
+	assert.NoError(t, runMemoryActions(runtimeContext, &homeAct, &varsAct))
+	vars, _ = runtimeContext.stack.Pop()
+	assert.Equal(t, "{  }", vars.display(NewModes()))
+}
+
+func TestPurgeActions(t *testing.T) {
+	tests := []struct {
+		name          string
+		actions       []Action
+		expectedError string
+		expectedNodes []string
+	}{
+		{"purge variable", []Action{pushVar(idVar("X")), &purgeAct}, "", []string{"B"}},
+		{"purge list", []Action{&VariableEvaluationActionDesc{varName: "B"}, pushVar(idVar("Y")), &purgeAct, &updirAct, pushVar(pathVar("X", "B")), &purgeAct}, "", nil},
+		{"purge non empty folder", []Action{pushVar(idVar("B")), &purgeAct}, "folder B is not empty", []string{"B", "X"}},
+		{"purge unknown", []Action{pushVar(idVar("Z")), &purgeAct}, "Z not found in folder A", []string{"B", "X"}},
+		{"pgdir", []Action{pushVar(idVar("B")), &pgdirAct}, "", []string{"X"}},
+		{"pgdir variable", []Action{pushVar(idVar("X")), &pgdirAct}, "folder X not found", []string{"B", "X"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtimeContext, memory := createMemoryTree(t)
+			folderA := memory.getRoot().subNode("A").(*MemoryFolder)
+			memory.setCurrentFolder(folderA)
+			err := runMemoryActions(runtimeContext, tt.actions...)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			var names []string
+			for _, node := range folderA.SubNodes() {
+				names = append(names, node.Name())
+			}
+			assert.Equal(t, tt.expectedNodes, names)
+		})
+	}
+}
+
+func TestPgdirCurrentFolder(t *testing.T) {
+	runtimeContext, memory := createMemoryTree(t)
+	memory.setCurrentFolder(memory.getRoot().subNode("A").(*MemoryFolder).subNode("B").(*MemoryFolder))
+
+	err := runMemoryActions(runtimeContext, pushVar(pathVar("HOME", "A")), &pgdirAct)
+	assert.ErrorContains(t, err, "contains the current folder")
+}
+
+func TestRenameAndMoveActions(t *testing.T) {
+	runtimeContext, memory := createMemoryTree(t)
+	folderA := memory.getRoot().subNode("A").(*MemoryFolder)
+	memory.setCurrentFolder(folderA)
+
+	assert.NoError(t, runMemoryActions(runtimeContext, pushVar(idVar("X")), pushVar(idVar("Z")), &renameAct))
+	assert.Nil(t, folderA.subNode("X"))
+	assert.NotNil(t, folderA.subNode("Z"))
+
+	err := runMemoryActions(runtimeContext, pushVar(idVar("Z")), pushVar(idVar("B")), &renameAct)
+	assert.ErrorContains(t, err, "B already exists in folder A")
+	_, _ = runtimeContext.stack.PopN(2)
+
+	assert.NoError(t, runMemoryActions(runtimeContext, pushVar(idVar("Z")), pushVar(idVar("B")), &moveAct))
+	assert.Nil(t, folderA.subNode("Z"))
+	movedVar := folderA.subNode("B").(*MemoryFolder).subNode("Z")
+	if assert.NotNil(t, movedVar) {
+		assert.Equal(t, []string{"A", "B", "Z"}, memory.getPath(movedVar))
+	}
+
+	assert.NoError(t, runMemoryActions(runtimeContext, pushVar(idVar("B")), pushVar(pathVar("HOME")), &moveAct))
+	assert.NotNil(t, memory.getRoot().subNode("B"))
+
+	memory.setCurrentFolder(memory.getRoot())
+	err = runMemoryActions(runtimeContext, pushVar(idVar("A")), pushVar(pathVar("HOME", "A")), &moveAct)
+	assert.ErrorContains(t, err, "cannot move folder A inside itself")
+}
+
+func TestStoreAndRecallActions(t *testing.T) {
+	tests := []struct {
+		name          string
+		actions       []Action
+		expectedError string
+		expectedStack []Variable
+		expectedX     Variable
+	}{
+		{"sto in current folder", []Action{pushVar(numVar(5)), pushVar(idVar("X")), &storeAct}, "", nil, numVar(5)},
+		{"rcl from current folder", []Action{pushVar(idVar("X")), &recallAct}, "", []Variable{numVar(1)}, numVar(1)},
+		{"rcl from parent folder", []Action{&VariableEvaluationActionDesc{varName: "B"}, pushVar(idVar("X")), &recallAct}, "", []Variable{numVar(1)}, numVar(1)},
+		{"rcl missing", []Action{pushVar(idVar("Z")), &recallAct}, "variable Z not found", []Variable{idVar("Z")}, numVar(1)},
+		{"sto+", []Action{pushVar(numVar(3)), pushVar(idVar("X")), &storeAddAct}, "", nil, numVar(4)},
+		{"sto-", []Action{pushVar(numVar(3)), pushVar(idVar("X")), &storeSubAct}, "", nil, numVar(-2)},
+		{"sto+ wrong type", []Action{pushVar(CreateBooleanVariable(true)), pushVar(idVar("X")), &storeAddAct}, "cannot update X", []Variable{CreateBooleanVariable(true), idVar("X")}, numVar(1)},
+		{"sto+ missing", []Action{pushVar(numVar(3)), pushVar(idVar("Z")), &storeAddAct}, "variable Z not found", []Variable{numVar(3), idVar("Z")}, numVar(1)},
+		{"incr", []Action{pushVar(idVar("X")), &incrAct}, "", []Variable{numVar(2)}, numVar(2)},
+		{"decr", []Action{pushVar(idVar("X")), &decrAct}, "", []Variable{numVar(0)}, numVar(0)},
+		{"sto on folder", []Action{pushVar(numVar(5)), pushVar(idVar("B")), &storeAct}, "B is a folder", []Variable{numVar(5), idVar("B")}, numVar(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtimeContext, memory := createMemoryTree(t)
+			folderA := memory.getRoot().subNode("A").(*MemoryFolder)
+			memory.setCurrentFolder(folderA)
+			err := runMemoryActions(runtimeContext, tt.actions...)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			if assert.Equal(t, len(tt.expectedStack), runtimeContext.stack.Size()) {
+				for i, expected := range tt.expectedStack {
+					actual, _ := runtimeContext.stack.Get(len(tt.expectedStack) - 1 - i)
+					assert.Equal(t, expected.display(NewModes()), actual.display(NewModes()))
+				}
+			}
+			if assert.Len(t, folderA.variables, 1, "sto must replace the existing variable") {
+				assert.Equal(t, tt.expectedX.display(NewModes()), folderA.variables[0].value.display(NewModes()))
+			}
+		})
+	}
+}
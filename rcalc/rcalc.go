@@ -1,13 +1,19 @@
 package rcalc
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 )
 
-func Run(stackDataFolder string, createFolder bool, debugMode bool) {
+// memoryFileName file of the config folder where the memory is saved
+const memoryFileName = "memory.protobuf"
+
+// Run starts the interactive calculator with the settings of rcalc.yaml. Errors of the config
+// file and of its startup program prevent the launch.
+func Run(stackDataFolder string, createFolder bool, debugMode bool, useTui bool) error {
 
 	defer func() {
 		logger := GetLogger()
@@ -18,81 +24,182 @@ func Run(stackDataFolder string, createFolder bool, debugMode bool) {
 		}
 	}()
 
+	logFilePath, err := prepareDataFolder(stackDataFolder, createFolder, debugMode)
+	if err != nil {
+		return err
+	}
+	fmt.Println(logFilePath)
+
+	GetLogger().Info("Start rcalc")
+	config, err := LoadConfig(stackDataFolder)
+	if err != nil {
+		return err
+	}
+	system, stack, err := loadConfiguredSession(stackDataFolder, false, config)
+	if err != nil {
+		return err
+	}
+	if err := config.runStartup(system, stack); err != nil {
+		return err
+	}
+
+	var frontend Frontend
+	if useTui {
+		frontend = NewTuiFrontend(config.StackLevels)
+	} else {
+		frontend = NewConsoleFrontend(os.Stdin, config.StackLevels, config.ClearScreen)
+	}
+	if err := frontend.Start(); err != nil {
+		return fmt.Errorf("error starting user interface: %w", err)
+	}
+	defer frontend.Stop()
+
+	RunRepl(frontend, system, stack)
+	return nil
+}
+
+// prepareDataFolder creates the data folder if needed and starts the logs inside it
+func prepareDataFolder(stackDataFolder string, createFolder bool, debugMode bool) (string, error) {
 	if createFolder {
 		if _, err := os.Stat(stackDataFolder); os.IsNotExist(err) {
 			err := os.Mkdir(stackDataFolder, 0755)
 			if err != nil {
-				fmt.Printf("Error creating %s : %s\n", stackDataFolder, err.Error())
-				return
+				return "", fmt.Errorf("error creating %s: %w", stackDataFolder, err)
 			}
 		}
 	}
 	logFilePath := path.Join(stackDataFolder, "rcalc-debug.log")
-	fmt.Println(logFilePath)
 	if debugMode {
 		InitDevLogger(logFilePath)
 	} else {
 		InitProdLogger(logFilePath)
 	}
+	return logFilePath, nil
+}
 
-	GetLogger().Info("Start rcalc")
-	sugaredLogger := GetLogger()
-	stackDataFilePath := path.Join(stackDataFolder, "stack.protobuf")
+// loadSession reads the stack, the memory and the modes saved in the data folder, they are saved
+// again after each command line. An ephemeral session starts with an empty stack and saves nothing.
+// A memory file that cannot be read prevents the launch, it would be replaced by an empty memory
+// at the end of the first command line.
+func loadSession(stackDataFolder string, ephemeral bool) (*SystemInstance, *Stack, error) {
+	memoryDataFilePath := path.Join(stackDataFolder, memoryFileName)
+	memory, err := ReadMemoryFromDisk(memoryDataFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading memory from %s: %w", memoryDataFilePath, err)
+	}
+	var stack *Stack
+	if ephemeral {
+		stack = CreateStack()
+	} else {
+		stack = CreateSaveOnDiskStack(path.Join(stackDataFolder, "stack.protobuf"))
+	}
+	var system = CreateSystemInstanceWithMemory(memory)
+	stack.AddSessionListener(system.UndoHistory())
 
-	var stack = CreateSaveOnDiskStack(stackDataFilePath)
+	modesDataFilePath := path.Join(stackDataFolder, "modes.protobuf")
+	ReadModesFromDisk(modesDataFilePath, system.Modes())
+	if !ephemeral {
+		stack.AddSessionListener(NewMemorySavingListener(memoryDataFilePath, system.Memory()))
+		stack.AddSessionListener(NewModesSavingListener(modesDataFilePath, system.Modes()))
+	}
+	return system, stack, nil
+}
 
+// loadConfiguredSession loads the session of the data folder then sets the modes and the aliases
+// of the config
+func loadConfiguredSession(stackDataFolder string, ephemeral bool, config *Config) (*SystemInstance, *Stack, error) {
+	system, stack, err := loadSession(stackDataFolder, ephemeral)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := config.apply(system); err != nil {
+		return nil, nil, err
+	}
+	return system, stack, nil
+}
+
+// RunRepl Reads command lines from the frontend and runs them until the user quits
+func RunRepl(frontend Frontend, system *SystemInstance, stack *Stack) {
 	var message = ""
-	var system = CreateSystemInstance()
 	for {
-		// print stack
-		DisplayStack(stack, message, 3, true)
+		frontend.Refresh(stack, system.Modes(), message)
 
-		// print prompt
-		input := bufio.NewScanner(os.Stdin)
-
-		// wait for cmd
-		input.Scan()
-
-		// interpret cmd
-		var cmds = input.Text()
+		cmds, err := frontend.ReadCommandLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				GetLogger().Errorf("Error while reading command line: %v", err)
+			}
+			return
+		}
 
-		// Message to display above (temp way of doing this)
 		message = ""
+		err = RunCommandLine(system, stack, cmds)
+		if err != nil {
+			message = err.Error()
+		}
+		if system.shouldStop() {
+			return
+		}
+	}
+}
 
-		actions, parseErr := ParseToActions(cmds, "InteractiveShell", Registry)
-		if parseErr != nil {
-			sugaredLogger.Errorf("Parsing error(s): %v", parseErr)
-			message = parseErr.Error()
-		} else {
-			runtimeContext := CreateRuntimeContext(system, stack)
-			err := stack.StartSession()
+// RunCommandLine Parses a command line and runs its actions as a transaction
+func RunCommandLine(system *SystemInstance, stack *Stack, cmds string) error {
+	actions, parseErr := ParseToActions(cmds, "InteractiveShell", Registry, system.Modes())
+	if parseErr != nil {
+		GetLogger().Errorf("Parsing error(s): %v", parseErr)
+		return parseErr
+	}
+	return RunActionsInTransaction(system, stack, actions)
+}
+
+// RunActionsInTransaction Runs the actions of a command line inside a stack session.
+// Evaluation stops at the first action in error and the stack, the memory and the modes are then
+// restored as they were before the line. The error is kept as the last error read by errm and errn.
+func RunActionsInTransaction(system *SystemInstance, stack *Stack, actions []Action) error {
+	return runInTransaction(system, stack, func(runtimeContext *RuntimeContext) error {
+		for idx, action := range actions {
+			err := runtimeContext.RunAction(action)
 			if err != nil {
-				return
+				system.setLastError(withErrorNumber(err, ERR_ACTION_FAILED))
+				return fmt.Errorf("action %d (%s) failed: %w", idx+1, action.Display(), err)
 			}
-			for _, action := range actions {
-				err := runtimeContext.RunAction(action)
-				if err != nil {
-					message = err.Error()
-					// in case of error, stop evaluation
-					break
-				} else {
-					message = ""
-				}
-				if system.shouldStop() {
-					err := stack.CloseSession()
-					if err != nil {
-						sugaredLogger.Errorf("Error while closing session")
-						return
-					}
-					return
-				}
-			}
-			err = stack.CloseSession()
-			if err != nil {
-				return
+			if system.shouldStop() {
+				break
 			}
 		}
+		return nil
+	})
+}
+
+// runInTransaction runs a function inside a stack session, the stack, the memory and the modes are
+// restored when it fails
+func runInTransaction(system *SystemInstance, stack *Stack, run func(runtimeContext *RuntimeContext) error) error {
+	err := stack.StartSession()
+	if err != nil {
+		return err
+	}
+	stackCheckpoint := stack.Checkpoint()
+	memoryCheckpoint := system.Memory().checkpoint()
+	modesCheckpoint := system.Modes().checkpoint()
+
+	runErr := run(CreateRuntimeContext(system, stack))
+	if runErr != nil {
+		GetLogger().Infof("Rollback of command line: %v", runErr)
+		stack.Restore(stackCheckpoint)
+		system.Memory().restore(memoryCheckpoint)
+		system.Modes().restore(modesCheckpoint)
+		system.UndoHistory().discardLine()
+	}
+
+	err = stack.CloseSession()
+	if err != nil {
+		GetLogger().Errorf("Error while closing session: %v", err)
+	}
+	if runErr != nil {
+		return runErr
 	}
+	return err
 }
 
 func checkTypesForAction(s *Stack, a Action) (bool, error) {
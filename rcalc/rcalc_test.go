@@ -0,0 +1,56 @@
+package rcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailingLineIsRolledBack(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	stack.AddSessionListener(system.UndoHistory())
+	stack.Push(CreateNumericVariableFromInt(1))
+
+	folderName := CreateAlgebraicExpressionVariable("DIR", nil)
+	err := RunActionsInTransaction(system, stack, []Action{
+		pushAction(2),
+		&addOp,
+		&VariablePutOnStackActionDesc{value: folderName},
+		&crdirAct,
+		&VariablePutOnStackActionDesc{value: CreateBooleanVariable(true)},
+		&addOp,
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "action 6 (+)")
+	}
+	if assert.Equal(t, 1, stack.Size()) {
+		top, _ := stack.Get(0)
+		assert.Equal(t, int64(1), top.asNumericVar().value.IntPart())
+	}
+	assert.Len(t, system.Memory().getRoot().subFolders, 0, "folder creation must be rolled back")
+	assert.False(t, system.UndoHistory().CanUndo(), "a rolled back line must not be undoable")
+}
+
+func TestStoreWithOperatorCommandLine(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	err := RunCommandLine(system, stack, "5 'X' sto 3 'X' sto+ 'X' rcl 1 'X' sto- 'X' rcl")
+	if assert.NoError(t, err) && assert.Equal(t, 2, stack.Size()) {
+		results, _ := stack.PopN(2)
+		assert.Equal(t, int64(8), results[0].asNumericVar().value.IntPart())
+		assert.Equal(t, int64(7), results[1].asNumericVar().value.IntPart())
+	}
+}
+
+func TestSuccessfulLineIsKept(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	stack.Push(CreateNumericVariableFromInt(1))
+
+	err := RunActionsInTransaction(system, stack, []Action{pushAction(2), &addOp})
+	if assert.NoError(t, err) && assert.Equal(t, 1, stack.Size()) {
+		top, _ := stack.Get(0)
+		assert.Equal(t, int64(3), top.asNumericVar().value.IntPart())
+	}
+}
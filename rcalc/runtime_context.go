@@ -11,6 +11,8 @@ type RuntimeContext struct {
 
 type VariableReader interface {
 	GetVariableValue(varName string) (Variable, error)
+	// Modes modes of the calculator, like the angle unit of the trigonometric functions
+	Modes() *Modes
 }
 
 func CreateRuntimeContext(system System, stack *Stack) *RuntimeContext {
@@ -30,16 +32,18 @@ func CreateRuntimeContext(system System, stack *Stack) *RuntimeContext {
 func (rt *RuntimeContext) RunAction(action Action) error {
 	if rt.stack.Size() < action.NbArgs() {
 		// fmt.Printf("Not enough args on stack (%d vs %d)\n", rt.stack.Size(), action.NbArgs())
-		return fmt.Errorf("not enough args on stack: only %d/%d available", action.NbArgs(), rt.stack.Size())
+		return NewRcalcError(ERR_TOO_FEW_ARGUMENTS, fmt.Sprintf("not enough args on stack: only %d/%d available", action.NbArgs(), rt.stack.Size()))
 	} else {
-		// TODO Handle error
 		typesOK, err := checkTypesForAction(rt.stack, action)
 		if !typesOK {
-			return err
+			if err == nil {
+				return NewRcalcError(ERR_BAD_ARGUMENT_TYPE, fmt.Sprintf("wrong argument types for %s", action.Display()))
+			}
+			return withErrorNumber(err, ERR_BAD_ARGUMENT_TYPE)
 		} else {
 			applyErr := action.Apply(rt)
 			if applyErr != nil {
-				return applyErr
+				return withErrorNumber(applyErr, ERR_ACTION_FAILED)
 			}
 		}
 	}
@@ -73,24 +77,24 @@ func (s *Scope) SetVariableValue(varName string, value Variable) error {
 func (rt *RuntimeContext) GetVariableValue(varName string) (Variable, error) {
 
 	value, err := rt.currentScope.GetVariableValue(varName)
-	// Let's look in main Memory
+	// Let's look in main Memory, from the current folder up to HOME
 	if err != nil {
 		memory := rt.system.Memory()
-		currentFolder := memory.getCurrentFolder()
-		varPath := append(memory.getPath(currentFolder), varName)
-		node := memory.resolvePath(varPath)
-		if node == nil {
-			value = nil
-			err = fmt.Errorf("cannot find variable %s in local variables nor in path %v", varName, varPath)
-		} else {
-			varNode := node.asMemoryVariable()
+		if varNode := memory.findVariable(varName); varNode != nil {
 			value = varNode.value
 			err = nil
+		} else {
+			value = nil
+			err = fmt.Errorf("cannot find variable %s in local variables nor in path %v", varName, memory.getPath(memory.getCurrentFolder()))
 		}
 	}
 	return value, err
 }
 
+func (rt *RuntimeContext) Modes() *Modes {
+	return rt.system.Modes()
+}
+
 func (rt *RuntimeContext) SetVariableValue(varName string, value Variable) error {
 	return rt.currentScope.SetVariableValue(varName, value)
 }
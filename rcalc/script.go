@@ -0,0 +1,107 @@
+package rcalc
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// EvalFile runs a script file without user interface and prints the resulting stack on out
+func EvalFile(stackDataFolder string, debugMode bool, options EvalOptions, scriptPath string, out io.Writer) error {
+	return evalInSession(stackDataFolder, debugMode, options, out, func(system *SystemInstance, stack *Stack) error {
+		return RunScript(system, stack, scriptPath)
+	})
+}
+
+// RunScript runs a script file as a single command line: the stack and the memory are restored
+// when one of its actions fails. The errors are located with the file name and the line.
+func RunScript(system *SystemInstance, stack *Stack, scriptPath string) error {
+	actions, err := parseScript(scriptPath, system)
+	if err != nil {
+		return err
+	}
+	return runInTransaction(system, stack, func(runtimeContext *RuntimeContext) error {
+		return runScriptActions(runtimeContext, scriptPath, actions)
+	})
+}
+
+// parseScript parses a whole script file, the comments and the line breaks are allowed everywhere
+// spaces are, including inside programs
+func parseScript(scriptPath string, system System) ([]LocatedItem[Action], error) {
+	content, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read script: %w", err)
+	}
+	return parseToLocatedActions(string(content), scriptPath, system.Registry(), system.Modes())
+}
+
+// runScriptActions runs the top level actions of a script until the first error or quit
+func runScriptActions(runtimeContext *RuntimeContext, scriptPath string, actions []LocatedItem[Action]) error {
+	for _, action := range actions {
+		err := runtimeContext.RunAction(action.item)
+		if err != nil {
+			runtimeContext.system.setLastError(withErrorNumber(err, ERR_ACTION_FAILED))
+			if action.start == nil {
+				return fmt.Errorf("%s: %s failed: %w", scriptPath, action.item.Display(), err)
+			}
+			return fmt.Errorf("%s:%d:%d: %s failed: %w", scriptPath, action.start.GetLine(), action.start.GetColumn()+1, action.item.Display(), err)
+		}
+		if system, isInternal := runtimeContext.system.(SystemInternal); isInternal && system.shouldStop() {
+			break
+		}
+	}
+	return nil
+}
+
+// LoadScriptAction runs the script file named on the stack in the current command line, its
+// programs and variables stay available afterwards
+type LoadScriptAction struct {
+	ActionCommonDesc
+}
+
+var _ Action = (*LoadScriptAction)(nil)
+
+func (a *LoadScriptAction) NbArgs() int {
+	return 1
+}
+
+func (a *LoadScriptAction) CheckTypes(elts ...Variable) (bool, error) {
+	if elts[0].getType() != TYPE_STR {
+		return false, fmt.Errorf("%s expects a file name, found: %v", a.OpCode(), elts[0].getType())
+	}
+	return true, nil
+}
+
+func (a *LoadScriptAction) Apply(runtimeContext *RuntimeContext) error {
+	elts, err := runtimeContext.stack.PeekN(1)
+	if err != nil {
+		return err
+	}
+	scriptPath := elts[0].asStringVar().value
+	actions, err := parseScript(scriptPath, runtimeContext.system)
+	if err != nil {
+		return err
+	}
+	_, _ = runtimeContext.stack.Pop()
+	return runScriptActions(runtimeContext, scriptPath, actions)
+}
+
+func (a *LoadScriptAction) Display() string {
+	return a.OpCode()
+}
+
+var loadScriptAct = LoadScriptAction{ActionCommonDesc{opCode: "load-script"}}
+
+var ScriptPackage = ActionPackage{
+	name: "scripts",
+	staticActions: []Action{
+		&loadScriptAct,
+	},
+	docs: map[string]ActionDoc{
+		"load-script": {
+			Summary: "runs a script file, its programs and variables stay available afterwards",
+			Stack:   "\"file\" -> ...",
+			Types:   "a file name",
+		},
+	},
+}
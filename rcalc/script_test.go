@@ -0,0 +1,56 @@
+package rcalc
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeScript(t *testing.T, content string) string {
+	scriptPath := path.Join(t.TempDir(), "test.rcalc")
+	assert.NoError(t, os.WriteFile(scriptPath, []byte(content), 0644))
+	return scriptPath
+}
+
+func TestRunScript(t *testing.T) {
+	system := CreateSystemInstance()
+	stack := CreateStack()
+	scriptPath := writeScript(t, "@ squares\n<<\n  dup *\n>> 'SQ' sto\n\n3 SQ\n")
+	if assert.NoError(t, RunScript(system, stack, scriptPath)) {
+		assert.Equal(t, []string{"9"}, stackValues(stack))
+	}
+
+	scriptPath = writeScript(t, "1 2\n\n0 /\n")
+	err := RunScript(system, stack, scriptPath)
+	assert.EqualError(t, err, scriptPath+":3:3: / failed: division by zero")
+	assert.Equal(t, ERR_DIVISION_BY_ZERO, ErrorNumber(err))
+	assert.Equal(t, []string{"9"}, stackValues(stack), "the script is a single transaction")
+
+	err = RunScript(system, stack, path.Join(t.TempDir(), "missing.rcalc"))
+	assert.ErrorContains(t, err, "cannot read script")
+}
+
+func TestLoadScript(t *testing.T) {
+	system := CreateSystemInstance()
+	stack := CreateStack()
+	scriptPath := writeScript(t, "<< 2 * >> 'DOUBLE' sto @ stays in memory\n")
+	stack.Push(CreateStringVariable(scriptPath))
+	runtimeContext := CreateRuntimeContext(system, stack)
+	if assert.NoError(t, runtimeContext.RunAction(&loadScriptAct)) {
+		assert.Equal(t, 0, stack.Size())
+		_, err := runtimeContext.GetVariableValue("DOUBLE")
+		assert.NoError(t, err)
+	}
+
+	stack.Push(CreateNumericVariableFromInt(1))
+	assert.Error(t, runtimeContext.RunAction(&loadScriptAct))
+	assert.Equal(t, 1, stack.Size(), "the argument must be kept on error")
+
+	stack = CreateStack()
+	if assert.NoError(t, RunCommandLine(system, stack, fmt.Sprintf("%q load-script 5 DOUBLE", scriptPath))) {
+		assert.Equal(t, []string{"10"}, stackValues(stack))
+	}
+}
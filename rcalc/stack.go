@@ -19,9 +19,37 @@ const (
 	TYPE_ALG_EXPR Type = 4
 	TYPE_PROGRAM  Type = 5
 	TYPE_LIST     Type = 6
-	// TYPE_VECTOR     Type = 7
+	TYPE_VECTOR   Type = 7
+	TYPE_MATRIX   Type = 8
+	TYPE_COMPLEX  Type = 9
+	TYPE_RATIONAL Type = 10
+	TYPE_QUANTITY Type = 11
+	TYPE_BINARY   Type = 12
 )
 
+var typeNames = map[Type]string{
+	TYPE_GENERIC:  "any",
+	TYPE_NUMERIC:  "number",
+	TYPE_BOOL:     "boolean",
+	TYPE_STR:      "string",
+	TYPE_ALG_EXPR: "algebraic expression",
+	TYPE_PROGRAM:  "program",
+	TYPE_LIST:     "list",
+	TYPE_VECTOR:   "vector",
+	TYPE_MATRIX:   "matrix",
+	TYPE_COMPLEX:  "complex",
+	TYPE_RATIONAL: "rational",
+	TYPE_QUANTITY: "quantity",
+	TYPE_BINARY:   "binary integer",
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("type(%d)", int(t))
+}
+
 type Variable interface {
 	getType() Type
 	asNumericVar() *NumericVariable
@@ -29,7 +57,15 @@ type Variable interface {
 	asIdentifierVar() *AlgebraicExpressionVariable
 	asProgramVar() *ProgramVariable
 	asListVar() *ListVariable
-	display() string
+	asStringVar() *StringVariable
+	asVectorVar() *VectorVariable
+	asMatrixVar() *MatrixVariable
+	asComplexVar() *ComplexVariable
+	asRationalVar() *RationalVariable
+	asQuantityVar() *QuantityVariable
+	asBinaryVar() *BinaryIntegerVariable
+	// display formats the value for the user with the given modes
+	display(modes *Modes) string
 	String() string
 }
 
@@ -61,6 +97,34 @@ func (se *CommonVariable) asListVar() *ListVariable {
 	panic("This is not a List variable")
 }
 
+func (se *CommonVariable) asStringVar() *StringVariable {
+	panic("This is not a String variable")
+}
+
+func (se *CommonVariable) asVectorVar() *VectorVariable {
+	panic("This is not a Vector variable")
+}
+
+func (se *CommonVariable) asMatrixVar() *MatrixVariable {
+	panic("This is not a Matrix variable")
+}
+
+func (se *CommonVariable) asComplexVar() *ComplexVariable {
+	panic("This is not a Complex variable")
+}
+
+func (se *CommonVariable) asRationalVar() *RationalVariable {
+	panic("This is not a Rational variable")
+}
+
+func (se *CommonVariable) asQuantityVar() *QuantityVariable {
+	panic("This is not a Quantity variable")
+}
+
+func (se *CommonVariable) asBinaryVar() *BinaryIntegerVariable {
+	panic("This is not a Binary integer variable")
+}
+
 func (se *CommonVariable) String() string {
 	return fmt.Sprintf("[CommonVariable] t=%d", se.fType)
 }
@@ -179,6 +243,23 @@ func (s *Stack) PushN(elts []Variable) {
 	s.elts = append(s.elts, elts...)
 }
 
+// StackCheckpoint Copy of the stack content that can be restored later
+type StackCheckpoint struct {
+	elts []Variable
+}
+
+func (s *Stack) Checkpoint() StackCheckpoint {
+	return StackCheckpoint{elts: slices.Clone(s.elts)}
+}
+
+func (s *Stack) Restore(checkpoint StackCheckpoint) {
+	s.elts = slices.Clone(checkpoint.elts)
+}
+
+func (s *Stack) AddSessionListener(listener StackSessionListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
 func (s *Stack) StartSession() error {
 
 	if s.onGoingSession {
@@ -247,6 +328,6 @@ func CreateSaveOnDiskStack(stackSavingPath string) *Stack {
 		}
 	}
 	saveStackSessionListener := &StackSavingListener{stackDataFolder: stackSavingPath}
-	stack.listeners = append(stack.listeners, saveStackSessionListener)
+	stack.AddSessionListener(saveStackSessionListener)
 	return stack
 }
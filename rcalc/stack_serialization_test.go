@@ -3,7 +3,9 @@ package rcalc
 import (
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
 	"google.golang.org/protobuf/proto"
+	"math/big"
 	"testing"
 	"troisdizaines.com/rcalc/rcalc/protostack"
 )
@@ -20,6 +22,33 @@ func getDynamicActionsForTesting() []Action {
 			varName: "n",
 			actions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(7)}},
 		},
+		&StartStepLoopActionDesc{
+			actions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(2)}},
+		},
+		&ForStepLoopActionDesc{
+			varName: "n",
+			actions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(-1)}},
+		},
+		&WhileRepeatLoopActionDesc{
+			conditionActions: []Action{&VariablePutOnStackActionDesc{value: CreateBooleanVariable(false)}},
+			bodyActions:      []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(7)}},
+		},
+		&DoUntilLoopActionDesc{
+			bodyActions:      []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(7)}},
+			conditionActions: []Action{&VariablePutOnStackActionDesc{value: CreateBooleanVariable(true)}},
+		},
+		&CaseActionDesc{
+			clauses: []CaseClause{{
+				conditionActions: []Action{&VariablePutOnStackActionDesc{value: CreateBooleanVariable(false)}},
+				bodyActions:      []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(7)}},
+			}},
+			defaultActions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(8)}},
+		},
+		&IfErrActionDesc{
+			trapActions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(7)}},
+			thenActions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(8)}},
+			elseActions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(9)}},
+		},
 		&IfThenElseActionDesc{
 			ifActions:   []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(7)}},
 			thenActions: []Action{&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(8)}},
@@ -40,7 +69,8 @@ func getDynamicActionsForTesting() []Action {
 	}
 }
 
-/**
+/*
+*
 Test that all registered dynamic items are tested
 */
 func TestAllDynamicActionsAreTested(t *testing.T) {
@@ -110,6 +140,27 @@ func TestSaveAndReadStack(t *testing.T) {
 	})
 	stack.Push(v5)
 
+	v6 := CreateStringVariable("a \"string\"")
+	stack.Push(v6)
+
+	v7 := CreateVectorVariableFromValues([]float64{1.5, -2})
+	stack.Push(v7)
+
+	v8 := CreateMatrixVariable(mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}))
+	stack.Push(v8)
+
+	v9 := CreateComplexVariable(complex(3, -4))
+	stack.Push(v9)
+
+	v10 := CreateRationalVariable(big.NewRat(2, 3))
+	stack.Push(v10)
+
+	v11 := qtyVar(9.81, "kg*m/s^2")
+	stack.Push(v11)
+
+	v12 := CreateBinaryIntegerVariable(0xFF)
+	stack.Push(v12)
+
 	protoStack, err := CreateProtoFromStack(stack)
 	if assert.NoError(t, err) {
 		out, err := proto.Marshal(protoStack)
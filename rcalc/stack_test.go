@@ -85,7 +85,7 @@ func TestDisplayStack(t *testing.T) {
 	fmt.Printf("Size after 1 Push %d / %d\n", s.Size(), len(s.elts))
 	se2 := CreateNumericVariable(decimal.NewFromInt(3))
 	s.Push(se2)
-	DisplayStack(s, "", 4, false)
+	DisplayStack(s, NewModes(), "", 4, false)
 }
 
 func TestNumericStackEltType(t *testing.T) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/shopspring/decimal"
 	"troisdizaines.com/rcalc/rcalc/protostack"
 )
 
@@ -61,8 +62,9 @@ func (a *VariablePutOnStackActionDesc) String() string {
 	return fmt.Sprintf("%s(%s)", a.OpCode(), a.value.String())
 }
 
+// Display lists the value with the default modes, the listing is parsed again without loss
 func (a *VariablePutOnStackActionDesc) Display() string {
-	return a.value.display()
+	return a.value.display(NewModes())
 }
 
 func (a *VariablePutOnStackActionDesc) MarshallFunc() ActionMarshallFunc {
@@ -94,7 +96,8 @@ func (a *VariablePutOnStackActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
 	}
 }
 
-// VariableEvaluationActionDesc Looks for a local variable named VariableEvaluationDesc.varName in the RuntimeContext and put its value on the stack.
+// VariableEvaluationActionDesc Looks for a variable named VariableEvaluationDesc.varName in the RuntimeContext and put its value on the stack.
+// Stored programs are executed, which makes them usable as commands.
 type VariableEvaluationActionDesc struct {
 	varName string
 }
@@ -111,14 +114,49 @@ func (a *VariableEvaluationActionDesc) CheckTypes(elts ...Variable) (bool, error
 }
 
 func (a *VariableEvaluationActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	// Local variables are pushed as is
+	if value, err := runtimeContext.currentScope.GetVariableValue(a.varName); err == nil {
+		runtimeContext.stack.Push(value)
+		return nil
+	}
+	// Evaluating the name of a folder of the current folder enters it
+	memory := runtimeContext.system.Memory()
+	if folder, ok := memory.getCurrentFolder().subNode(a.varName).(*MemoryFolder); ok {
+		memory.setCurrentFolder(folder)
+		return nil
+	}
 	value, err := runtimeContext.GetVariableValue(a.varName)
 	if err != nil {
-		return err
+		return undefinedNameError(runtimeContext, a.varName)
+	}
+	// Stored programs are run like commands and stored algebraic expressions are evaluated
+	switch value.getType() {
+	case TYPE_PROGRAM:
+		return executeProgram(runtimeContext, value.(*ProgramVariable), a.varName)
+	case TYPE_ALG_EXPR:
+		return evalVariable(runtimeContext, value)
+	default:
+		runtimeContext.stack.Push(value)
 	}
-	runtimeContext.stack.Push(value)
 	return nil
 }
 
+// undefinedNameError suggests the command or the variable the user may have wanted to type
+func undefinedNameError(runtimeContext *RuntimeContext, name string) error {
+	memory := runtimeContext.system.Memory()
+	candidates := runtimeContext.system.Registry().GetOpCodes()
+	for folder := memory.getCurrentFolder(); folder != nil; folder = folder.parentFolder {
+		for _, variable := range folder.variables {
+			candidates = append(candidates, variable.Name())
+		}
+	}
+	message := fmt.Sprintf("unknown command or variable %s", name)
+	if suggestion := suggestName(name, candidates); suggestion != "" {
+		message = fmt.Sprintf("%s, did you mean %s?", message, suggestion)
+	}
+	return NewRcalcError(ERR_UNDEFINED_NAME, message)
+}
+
 func (a *VariableEvaluationActionDesc) OpCode() string {
 	return "__hidden__" + "VariableEvaluation"
 }
@@ -273,6 +311,215 @@ func (a *IfThenElseActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
 	}
 }
 
+// IfErrActionDesc Action to execute iferr ... then ... else ... end structures. When an action of
+// the trap actions fails, the error becomes the last error, the stack is restored as it was
+// before the trap actions and the then actions are run. The else actions are run otherwise.
+type IfErrActionDesc struct {
+	trapActions []Action
+	thenActions []Action
+	elseActions []Action
+}
+
+var _ Action = (*IfErrActionDesc)(nil)
+
+func (a *IfErrActionDesc) OpCode() string {
+	return "__hidden__" + "IfErr"
+}
+
+func (a *IfErrActionDesc) NbArgs() int {
+	return 0
+}
+
+func (a *IfErrActionDesc) CheckTypes(elts ...Variable) (bool, error) {
+	return true, nil
+}
+
+func (a *IfErrActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	stackCheckpoint := runtimeContext.stack.Checkpoint()
+	err := runActions(runtimeContext, a.trapActions)
+	if err != nil {
+		runtimeContext.system.setLastError(withErrorNumber(err, ERR_ACTION_FAILED))
+		runtimeContext.stack.Restore(stackCheckpoint)
+		return runActions(runtimeContext, a.thenActions)
+	}
+	return runActions(runtimeContext, a.elseActions)
+}
+
+func (a *IfErrActionDesc) String() string {
+	return fmt.Sprintf("%s ()", a.OpCode())
+}
+
+func (a *IfErrActionDesc) Display() string {
+	if len(a.elseActions) == 0 {
+		return fmt.Sprintf("iferr %s then %s end", displayActions(a.trapActions), displayActions(a.thenActions))
+	}
+	return fmt.Sprintf("iferr %s then %s else %s end",
+		displayActions(a.trapActions), displayActions(a.thenActions), displayActions(a.elseActions))
+}
+
+func (a *IfErrActionDesc) MarshallFunc() ActionMarshallFunc {
+	return func(reg *ActionRegistry, action Action) (*protostack.Action, error) {
+		ifErrActionDesc := action.(*IfErrActionDesc)
+		trapProtoActions, err := MarshallActions(reg, ifErrActionDesc.trapActions)
+		if err != nil {
+			return nil, err
+		}
+		thenProtoActions, err := MarshallActions(reg, ifErrActionDesc.thenActions)
+		if err != nil {
+			return nil, err
+		}
+		elseProtoActions, err := MarshallActions(reg, ifErrActionDesc.elseActions)
+		if err != nil {
+			return nil, err
+		}
+		return &protostack.Action{
+			Type:   protostack.ActionType_IF_ERR,
+			OpCode: action.OpCode(),
+			RealAction: &protostack.Action_IfErrAction{IfErrAction: &protostack.IfErrAction{
+				TrapActions: trapProtoActions,
+				ThenActions: thenProtoActions,
+				ElseActions: elseProtoActions,
+			}},
+		}, nil
+	}
+}
+
+func (a *IfErrActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
+	return func(reg *ActionRegistry, protoAction *protostack.Action) (Action, error) {
+		ifErrAction := protoAction.GetIfErrAction()
+		trapActions, err := UnMarshallActions(reg, ifErrAction.GetTrapActions())
+		if err != nil {
+			return nil, err
+		}
+		thenActions, err := UnMarshallActions(reg, ifErrAction.GetThenActions())
+		if err != nil {
+			return nil, err
+		}
+		elseActions, err := UnMarshallActions(reg, ifErrAction.GetElseActions())
+		if err != nil {
+			return nil, err
+		}
+		return &IfErrActionDesc{
+			trapActions: trapActions,
+			thenActions: thenActions,
+			elseActions: elseActions,
+		}, nil
+	}
+}
+
+// CaseClause condition and actions of a case structure
+type CaseClause struct {
+	conditionActions []Action
+	bodyActions      []Action
+}
+
+// CaseActionDesc Action to execute case ... then ... end ... end structures: the body of the
+// first clause whose condition is true is run, or the default actions when no condition is true
+type CaseActionDesc struct {
+	clauses        []CaseClause
+	defaultActions []Action
+}
+
+var _ Action = (*CaseActionDesc)(nil)
+
+func (a *CaseActionDesc) OpCode() string {
+	return "__hidden__" + "Case"
+}
+
+func (a *CaseActionDesc) NbArgs() int {
+	return 0
+}
+
+func (a *CaseActionDesc) CheckTypes(elts ...Variable) (bool, error) {
+	return true, nil
+}
+
+func (a *CaseActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	for _, clause := range a.clauses {
+		condition, err := runCondition(runtimeContext, clause.conditionActions, "case")
+		if err != nil {
+			return err
+		}
+		if condition {
+			return runActions(runtimeContext, clause.bodyActions)
+		}
+	}
+	return runActions(runtimeContext, a.defaultActions)
+}
+
+func (a *CaseActionDesc) String() string {
+	return fmt.Sprintf("%s ()", a.OpCode())
+}
+
+func (a *CaseActionDesc) Display() string {
+	parts := []string{"case"}
+	for _, clause := range a.clauses {
+		parts = append(parts, fmt.Sprintf("%s then %s end", displayActions(clause.conditionActions), displayActions(clause.bodyActions)))
+	}
+	if len(a.defaultActions) > 0 {
+		parts = append(parts, displayActions(a.defaultActions))
+	}
+	parts = append(parts, "end")
+	return strings.Join(parts, " ")
+}
+
+func (a *CaseActionDesc) MarshallFunc() ActionMarshallFunc {
+	return func(reg *ActionRegistry, action Action) (*protostack.Action, error) {
+		caseActionDesc := action.(*CaseActionDesc)
+		protoCaseAction := &protostack.CaseAction{}
+		for _, clause := range caseActionDesc.clauses {
+			conditionProtoActions, err := MarshallActions(reg, clause.conditionActions)
+			if err != nil {
+				return nil, err
+			}
+			bodyProtoActions, err := MarshallActions(reg, clause.bodyActions)
+			if err != nil {
+				return nil, err
+			}
+			protoCaseAction.Clauses = append(protoCaseAction.Clauses, &protostack.CaseClause{
+				ConditionActions: conditionProtoActions,
+				BodyActions:      bodyProtoActions,
+			})
+		}
+		defaultProtoActions, err := MarshallActions(reg, caseActionDesc.defaultActions)
+		if err != nil {
+			return nil, err
+		}
+		protoCaseAction.DefaultActions = defaultProtoActions
+		return &protostack.Action{
+			Type:       protostack.ActionType_CASE,
+			OpCode:     action.OpCode(),
+			RealAction: &protostack.Action_CaseAction{CaseAction: protoCaseAction},
+		}, nil
+	}
+}
+
+func (a *CaseActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
+	return func(reg *ActionRegistry, protoAction *protostack.Action) (Action, error) {
+		protoCaseAction := protoAction.GetCaseAction()
+		var clauses []CaseClause
+		for _, protoClause := range protoCaseAction.GetClauses() {
+			conditionActions, err := UnMarshallActions(reg, protoClause.GetConditionActions())
+			if err != nil {
+				return nil, err
+			}
+			bodyActions, err := UnMarshallActions(reg, protoClause.GetBodyActions())
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, CaseClause{conditionActions: conditionActions, bodyActions: bodyActions})
+		}
+		defaultActions, err := UnMarshallActions(reg, protoCaseAction.GetDefaultActions())
+		if err != nil {
+			return nil, err
+		}
+		return &CaseActionDesc{
+			clauses:        clauses,
+			defaultActions: defaultActions,
+		}, nil
+	}
+}
+
 //StartNextLoopActionDesc Action to execute start ... next loops
 type StartNextLoopActionDesc struct {
 	actions []Action
@@ -287,7 +534,7 @@ var _ Action = (*StartNextLoopActionDesc)(nil)
 
 func (a *StartNextLoopActionDesc) CheckTypes(elts ...Variable) (bool, error) {
 	for i := 0; i <= 1; i++ {
-		if elts[i].getType() != TYPE_NUMERIC && elts[i].asNumericVar().value.IsInteger() {
+		if elts[i].getType() != TYPE_NUMERIC || !elts[i].asNumericVar().value.IsInteger() {
 			return false, fmt.Errorf("%s at stack level %d is not an integer", elts[i].String(), i+1)
 		}
 	}
@@ -385,7 +632,7 @@ func (a *ForNextLoopActionDesc) NbArgs() int {
 
 func (a *ForNextLoopActionDesc) CheckTypes(elts ...Variable) (bool, error) {
 	for i := 0; i <= 1; i++ {
-		if elts[i].getType() != TYPE_NUMERIC && elts[i].asNumericVar().value.IsInteger() {
+		if elts[i].getType() != TYPE_NUMERIC || !elts[i].asNumericVar().value.IsInteger() {
 			return false, fmt.Errorf("%s at stack level %d is not an integer", elts[i].String(), i+1)
 		}
 	}
@@ -472,6 +719,380 @@ func (a *ForNextLoopActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
 	}
 }
 
+// runActions runs actions in sequence and stops at the first error
+func runActions(runtimeContext *RuntimeContext, actions []Action) error {
+	for _, action := range actions {
+		if err := runtimeContext.RunAction(action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func displayActions(actions []Action) string {
+	actionsStr := []string{}
+	for _, action := range actions {
+		actionsStr = append(actionsStr, action.Display())
+	}
+	return strings.Join(actionsStr, " ")
+}
+
+// checkLoopBoundaries checks the start and end values of a loop are numbers
+func checkLoopBoundaries(elts ...Variable) (bool, error) {
+	for i := 0; i <= 1; i++ {
+		if !isOfType(elts[i], TYPE_NUMERIC) {
+			return false, fmt.Errorf("%s at stack level %d is not a number", elts[i].display(NewModes()), 2-i)
+		}
+	}
+	return true, nil
+}
+
+// runStepLoop runs actions from the start value to the end value popped from the stack, the
+// increment being popped from the stack after each iteration. Like on HP calculators, actions
+// are run at least once.
+func runStepLoop(runtimeContext *RuntimeContext, actions []Action, setCounter func(counter decimal.Decimal) error) error {
+	boundaries, err := runtimeContext.stack.PopN(2)
+	if err != nil {
+		return err
+	}
+	if _, err = checkLoopBoundaries(boundaries...); err != nil {
+		return err
+	}
+	counter := GetEltAsNumeric(boundaries, 0)
+	end := GetEltAsNumeric(boundaries, 1)
+	for {
+		if err = setCounter(counter); err != nil {
+			return err
+		}
+		if err = runActions(runtimeContext, actions); err != nil {
+			return err
+		}
+		incrementVar, err := runtimeContext.stack.Pop()
+		if err != nil {
+			return fmt.Errorf("step expects an increment on the stack")
+		}
+		if !isOfType(incrementVar, TYPE_NUMERIC) {
+			return fmt.Errorf("step increment must be a number, found: %v", incrementVar.getType())
+		}
+		increment := GetEltAsNumeric([]Variable{incrementVar}, 0)
+		if increment.IsZero() {
+			return fmt.Errorf("step increment cannot be zero")
+		}
+		counter = counter.Add(increment)
+		if (increment.IsPositive() && counter.GreaterThan(end)) || (increment.IsNegative() && counter.LessThan(end)) {
+			return nil
+		}
+	}
+}
+
+// StartStepLoopActionDesc Action to execute start ... step loops
+type StartStepLoopActionDesc struct {
+	actions []Action
+}
+
+// StartStepLoopActionDesc implements Action
+var _ Action = (*StartStepLoopActionDesc)(nil)
+
+func (a *StartStepLoopActionDesc) OpCode() string {
+	return "__hidden__" + "StartStepLoop"
+}
+
+func (a *StartStepLoopActionDesc) NbArgs() int {
+	return 2
+}
+
+func (a *StartStepLoopActionDesc) CheckTypes(elts ...Variable) (bool, error) {
+	return checkLoopBoundaries(elts...)
+}
+
+func (a *StartStepLoopActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	return runStepLoop(runtimeContext, a.actions, func(counter decimal.Decimal) error {
+		return nil
+	})
+}
+
+func (a *StartStepLoopActionDesc) String() string {
+	return fmt.Sprintf("%s ()", a.OpCode())
+}
+
+func (a *StartStepLoopActionDesc) Display() string {
+	return fmt.Sprintf("start %s step", displayActions(a.actions))
+}
+
+func (a *StartStepLoopActionDesc) MarshallFunc() ActionMarshallFunc {
+	return func(reg *ActionRegistry, action Action) (*protostack.Action, error) {
+		protoActions, err := MarshallActions(reg, action.(*StartStepLoopActionDesc).actions)
+		if err != nil {
+			return nil, err
+		}
+		return &protostack.Action{
+			Type:       protostack.ActionType_START_STEP,
+			OpCode:     action.OpCode(),
+			RealAction: &protostack.Action_StartStepLoopAction{StartStepLoopAction: &protostack.StartStepLoopAction{Actions: protoActions}},
+		}, nil
+	}
+}
+
+func (a *StartStepLoopActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
+	return func(reg *ActionRegistry, protoAction *protostack.Action) (Action, error) {
+		actions, err := UnMarshallActions(reg, protoAction.GetStartStepLoopAction().GetActions())
+		if err != nil {
+			return nil, err
+		}
+		return &StartStepLoopActionDesc{actions: actions}, nil
+	}
+}
+
+// ForStepLoopActionDesc Action to execute for x ... step loops, x being a local variable
+type ForStepLoopActionDesc struct {
+	varName string
+	actions []Action
+}
+
+// ForStepLoopActionDesc implements Action
+var _ Action = (*ForStepLoopActionDesc)(nil)
+
+func (a *ForStepLoopActionDesc) OpCode() string {
+	return "__hidden__" + "ForStepLoop"
+}
+
+func (a *ForStepLoopActionDesc) NbArgs() int {
+	return 2
+}
+
+func (a *ForStepLoopActionDesc) CheckTypes(elts ...Variable) (bool, error) {
+	return checkLoopBoundaries(elts...)
+}
+
+func (a *ForStepLoopActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	runtimeContext.EnterNewScope()
+	defer runtimeContext.LeaveScope()
+
+	return runStepLoop(runtimeContext, a.actions, func(counter decimal.Decimal) error {
+		return runtimeContext.SetVariableValue(a.varName, CreateNumericVariable(counter))
+	})
+}
+
+func (a *ForStepLoopActionDesc) String() string {
+	return fmt.Sprintf("%s (%s)", a.OpCode(), a.varName)
+}
+
+func (a *ForStepLoopActionDesc) Display() string {
+	return fmt.Sprintf("for %s %s step", a.varName, displayActions(a.actions))
+}
+
+func (a *ForStepLoopActionDesc) MarshallFunc() ActionMarshallFunc {
+	return func(reg *ActionRegistry, action Action) (*protostack.Action, error) {
+		forStepLoopActionDesc := action.(*ForStepLoopActionDesc)
+		protoActions, err := MarshallActions(reg, forStepLoopActionDesc.actions)
+		if err != nil {
+			return nil, err
+		}
+		protoForStepLoopAction := &protostack.ForStepLoopAction{
+			VarName: forStepLoopActionDesc.varName,
+			Actions: protoActions,
+		}
+		return &protostack.Action{
+			Type:       protostack.ActionType_FOR_STEP,
+			OpCode:     action.OpCode(),
+			RealAction: &protostack.Action_ForStepLoopAction{ForStepLoopAction: protoForStepLoopAction},
+		}, nil
+	}
+}
+
+func (a *ForStepLoopActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
+	return func(reg *ActionRegistry, protoAction *protostack.Action) (Action, error) {
+		protoForStepLoopAction := protoAction.GetForStepLoopAction()
+		actions, err := UnMarshallActions(reg, protoForStepLoopAction.GetActions())
+		if err != nil {
+			return nil, err
+		}
+		return &ForStepLoopActionDesc{
+			varName: protoForStepLoopAction.GetVarName(),
+			actions: actions,
+		}, nil
+	}
+}
+
+// runCondition runs the condition actions of a loop or of a case clause and pops their boolean result
+func runCondition(runtimeContext *RuntimeContext, conditionActions []Action, keyword string) (bool, error) {
+	if err := runActions(runtimeContext, conditionActions); err != nil {
+		return false, err
+	}
+	condition, err := runtimeContext.stack.Pop()
+	if err != nil {
+		return false, fmt.Errorf("%s expects a condition on the stack", keyword)
+	}
+	if condition.getType() != TYPE_BOOL {
+		return false, fmt.Errorf("%s condition must be a boolean, found: %v", keyword, condition.getType())
+	}
+	return condition.asBooleanVar().value, nil
+}
+
+// WhileRepeatLoopActionDesc Action to execute while ... repeat ... end loops
+type WhileRepeatLoopActionDesc struct {
+	conditionActions []Action
+	bodyActions      []Action
+}
+
+// WhileRepeatLoopActionDesc implements Action
+var _ Action = (*WhileRepeatLoopActionDesc)(nil)
+
+func (a *WhileRepeatLoopActionDesc) OpCode() string {
+	return "__hidden__" + "WhileRepeatLoop"
+}
+
+func (a *WhileRepeatLoopActionDesc) NbArgs() int {
+	return 0
+}
+
+func (a *WhileRepeatLoopActionDesc) CheckTypes(elts ...Variable) (bool, error) {
+	return true, nil
+}
+
+func (a *WhileRepeatLoopActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	for {
+		condition, err := runCondition(runtimeContext, a.conditionActions, "while")
+		if err != nil || !condition {
+			return err
+		}
+		if err = runActions(runtimeContext, a.bodyActions); err != nil {
+			return err
+		}
+	}
+}
+
+func (a *WhileRepeatLoopActionDesc) String() string {
+	return fmt.Sprintf("%s ()", a.OpCode())
+}
+
+func (a *WhileRepeatLoopActionDesc) Display() string {
+	return fmt.Sprintf("while %s repeat %s end", displayActions(a.conditionActions), displayActions(a.bodyActions))
+}
+
+func (a *WhileRepeatLoopActionDesc) MarshallFunc() ActionMarshallFunc {
+	return func(reg *ActionRegistry, action Action) (*protostack.Action, error) {
+		whileRepeatLoopActionDesc := action.(*WhileRepeatLoopActionDesc)
+		conditionProtoActions, err := MarshallActions(reg, whileRepeatLoopActionDesc.conditionActions)
+		if err != nil {
+			return nil, err
+		}
+		bodyProtoActions, err := MarshallActions(reg, whileRepeatLoopActionDesc.bodyActions)
+		if err != nil {
+			return nil, err
+		}
+		protoWhileRepeatLoopAction := &protostack.WhileRepeatLoopAction{
+			ConditionActions: conditionProtoActions,
+			BodyActions:      bodyProtoActions,
+		}
+		return &protostack.Action{
+			Type:       protostack.ActionType_WHILE_REPEAT,
+			OpCode:     action.OpCode(),
+			RealAction: &protostack.Action_WhileRepeatLoopAction{WhileRepeatLoopAction: protoWhileRepeatLoopAction},
+		}, nil
+	}
+}
+
+func (a *WhileRepeatLoopActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
+	return func(reg *ActionRegistry, protoAction *protostack.Action) (Action, error) {
+		protoWhileRepeatLoopAction := protoAction.GetWhileRepeatLoopAction()
+		conditionActions, err := UnMarshallActions(reg, protoWhileRepeatLoopAction.GetConditionActions())
+		if err != nil {
+			return nil, err
+		}
+		bodyActions, err := UnMarshallActions(reg, protoWhileRepeatLoopAction.GetBodyActions())
+		if err != nil {
+			return nil, err
+		}
+		return &WhileRepeatLoopActionDesc{
+			conditionActions: conditionActions,
+			bodyActions:      bodyActions,
+		}, nil
+	}
+}
+
+// DoUntilLoopActionDesc Action to execute do ... until ... end loops, the body is run at least once
+type DoUntilLoopActionDesc struct {
+	bodyActions      []Action
+	conditionActions []Action
+}
+
+// DoUntilLoopActionDesc implements Action
+var _ Action = (*DoUntilLoopActionDesc)(nil)
+
+func (a *DoUntilLoopActionDesc) OpCode() string {
+	return "__hidden__" + "DoUntilLoop"
+}
+
+func (a *DoUntilLoopActionDesc) NbArgs() int {
+	return 0
+}
+
+func (a *DoUntilLoopActionDesc) CheckTypes(elts ...Variable) (bool, error) {
+	return true, nil
+}
+
+func (a *DoUntilLoopActionDesc) Apply(runtimeContext *RuntimeContext) error {
+	for {
+		if err := runActions(runtimeContext, a.bodyActions); err != nil {
+			return err
+		}
+		condition, err := runCondition(runtimeContext, a.conditionActions, "until")
+		if err != nil || condition {
+			return err
+		}
+	}
+}
+
+func (a *DoUntilLoopActionDesc) String() string {
+	return fmt.Sprintf("%s ()", a.OpCode())
+}
+
+func (a *DoUntilLoopActionDesc) Display() string {
+	return fmt.Sprintf("do %s until %s end", displayActions(a.bodyActions), displayActions(a.conditionActions))
+}
+
+func (a *DoUntilLoopActionDesc) MarshallFunc() ActionMarshallFunc {
+	return func(reg *ActionRegistry, action Action) (*protostack.Action, error) {
+		doUntilLoopActionDesc := action.(*DoUntilLoopActionDesc)
+		bodyProtoActions, err := MarshallActions(reg, doUntilLoopActionDesc.bodyActions)
+		if err != nil {
+			return nil, err
+		}
+		conditionProtoActions, err := MarshallActions(reg, doUntilLoopActionDesc.conditionActions)
+		if err != nil {
+			return nil, err
+		}
+		protoDoUntilLoopAction := &protostack.DoUntilLoopAction{
+			BodyActions:      bodyProtoActions,
+			ConditionActions: conditionProtoActions,
+		}
+		return &protostack.Action{
+			Type:       protostack.ActionType_DO_UNTIL,
+			OpCode:     action.OpCode(),
+			RealAction: &protostack.Action_DoUntilLoopAction{DoUntilLoopAction: protoDoUntilLoopAction},
+		}, nil
+	}
+}
+
+func (a *DoUntilLoopActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
+	return func(reg *ActionRegistry, protoAction *protostack.Action) (Action, error) {
+		protoDoUntilLoopAction := protoAction.GetDoUntilLoopAction()
+		bodyActions, err := UnMarshallActions(reg, protoDoUntilLoopAction.GetBodyActions())
+		if err != nil {
+			return nil, err
+		}
+		conditionActions, err := UnMarshallActions(reg, protoDoUntilLoopAction.GetConditionActions())
+		if err != nil {
+			return nil, err
+		}
+		return &DoUntilLoopActionDesc{
+			bodyActions:      bodyActions,
+			conditionActions: conditionActions,
+		}, nil
+	}
+}
+
 type EvalFromArgActionDesc struct {
 	variable Variable
 }
@@ -480,7 +1101,7 @@ type EvalFromArgActionDesc struct {
 var _ Action = (*EvalFromArgActionDesc)(nil)
 
 func (e *EvalFromArgActionDesc) Display() string {
-	return e.variable.display()
+	return e.variable.display(NewModes())
 }
 
 func (e *EvalFromArgActionDesc) OpCode() string {
@@ -499,14 +1120,16 @@ func (e *EvalFromArgActionDesc) Apply(runtimeContext *RuntimeContext) error {
 	return evalVariable(runtimeContext, e.variable)
 }
 
-func executeProgram(runtimeContext *RuntimeContext, program *ProgramVariable) error {
+// executeProgram runs the actions of a program, errors tell which action of which program failed
+// to find them in nested programs
+func executeProgram(runtimeContext *RuntimeContext, program *ProgramVariable, programName string) error {
 	runtimeContext.EnterNewScope()
 	defer func() { runtimeContext.LeaveScope() }()
 
-	for _, action := range program.actions {
+	for idx, action := range program.actions {
 		err := runtimeContext.RunAction(action)
 		if err != nil {
-			return err
+			return fmt.Errorf("action %d (%s) of %s failed: %w", idx+1, action.Display(), programName, err)
 		}
 	}
 	return nil
@@ -624,7 +1247,7 @@ func (a *VariableDeclarationActionDesc) Apply(runtimeContext *RuntimeContext) er
 }
 
 func (a *VariableDeclarationActionDesc) Display() string {
-	return fmt.Sprintf("-> %s %s", strings.Join(a.varNames, " "), a.variableToEvaluate.display())
+	return fmt.Sprintf("-> %s %s", strings.Join(a.varNames, " "), a.variableToEvaluate.display(NewModes()))
 }
 
 type EvalActionDesc struct{}
@@ -659,10 +1282,8 @@ func (e *EvalActionDesc) Apply(runtimeContext *RuntimeContext) error {
 
 func evalVariable(runtimeContext *RuntimeContext, v Variable) error {
 	switch v.getType() {
-	case TYPE_NUMERIC | TYPE_BOOL | TYPE_STR:
-		runtimeContext.stack.Push(v)
 	case TYPE_PROGRAM:
-		return executeProgram(runtimeContext, v.(*ProgramVariable))
+		return executeProgram(runtimeContext, v.(*ProgramVariable), "program")
 	case TYPE_ALG_EXPR:
 		expression, err := evalAlgExpression(runtimeContext, v.(*AlgebraicExpressionVariable).rootNode)
 		if err != nil {
@@ -671,14 +1292,29 @@ func evalVariable(runtimeContext *RuntimeContext, v Variable) error {
 			runtimeContext.stack.Push(expression)
 			return nil
 		}
+	case TYPE_LIST:
+		// an absolute path like { 'HOME' 'DIR' } goes to the folder
+		if isMemoryPath(v) {
+			folder, err := resolveMemoryPath(runtimeContext.system.Memory(), v)
+			if err != nil {
+				return err
+			}
+			runtimeContext.system.Memory().setCurrentFolder(folder)
+			return nil
+		}
+		runtimeContext.stack.Push(v)
+	default:
+		runtimeContext.stack.Push(v)
 	}
 
 	return nil
 }
 
 func evalAlgExpression(runtimeContext *RuntimeContext, algExpreNode AlgebraicExpressionNode) (*NumericVariable, error) {
-	numericVariable, _ := algExpreNode.Evaluate(runtimeContext)
-	return numericVariable, nil
+	if algExpreNode == nil {
+		return nil, fmt.Errorf("cannot evaluate an empty expression")
+	}
+	return algExpreNode.Evaluate(runtimeContext)
 }
 
 func (e *EvalActionDesc) MarshallFunc() ActionMarshallFunc {
@@ -694,14 +1330,29 @@ func (e *EvalActionDesc) UnMarshallFunc() ActionUnMarshallFunc {
 var evalAct = &EvalActionDesc{}
 
 var StructOpsPackage = ActionPackage{
+	name: "programs",
 	staticActions: []Action{
 		evalAct,
 	},
+	docs: map[string]ActionDoc{
+		"eval": {
+			Summary:  "evaluates a program, an algebraic expression or a name",
+			Stack:    "x -> ...",
+			Types:    "any, values which are not evaluable are pushed back",
+			Examples: []DocExample{{"5 'X' sto 'X' eval", "5"}},
+		},
+	},
 	dynamicActions: []Action{
 		&EvalFromArgActionDesc{},
 		&IfThenElseActionDesc{},
 		&ForNextLoopActionDesc{},
 		&StartNextLoopActionDesc{},
+		&StartStepLoopActionDesc{},
+		&ForStepLoopActionDesc{},
+		&WhileRepeatLoopActionDesc{},
+		&DoUntilLoopActionDesc{},
+		&CaseActionDesc{},
+		&IfErrActionDesc{},
 		&VariableDeclarationActionDesc{},
 		&VariableEvaluationActionDesc{},
 		&VariablePutOnStackActionDesc{},
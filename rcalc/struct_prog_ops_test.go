@@ -1,6 +1,7 @@
 package rcalc
 
 import (
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -70,3 +71,196 @@ func TestVariableDeclarationProgram(t *testing.T) {
 		}
 	}
 }
+
+func TestStoredProgramIsRunByName(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	runtimeContext := CreateRuntimeContext(system, stack)
+	memory := system.Memory()
+
+	square := CreateProgramVariable([]Action{&dupOp, &mulOp})
+	_, err := memory.createVariable("SQ", memory.getRoot(), square)
+	assert.NoError(t, err)
+	_, err = memory.createVariable("Y", memory.getRoot(), CreateNumericVariableFromInt(5))
+	assert.NoError(t, err)
+	_, err = memory.createVariable("Z", memory.getRoot(), CreateAlgebraicExpressionVariable("Y", &AlgExprVariable{value: "Y"}))
+	assert.NoError(t, err)
+	folder, err := memory.createFolder("DIR", memory.getRoot())
+	assert.NoError(t, err)
+	memory.setCurrentFolder(folder)
+
+	stack.Push(CreateNumericVariableFromInt(3))
+	err = runtimeContext.RunAction(&VariableEvaluationActionDesc{varName: "SQ"})
+	if assert.NoError(t, err, "program stored in a parent folder should run") && assert.Equal(t, 1, stack.Size()) {
+		value, _ := stack.Pop()
+		assert.Equal(t, int64(9), value.asNumericVar().value.IntPart())
+	}
+
+	err = runtimeContext.RunAction(&VariableEvaluationActionDesc{varName: "Z"})
+	if assert.NoError(t, err, "stored expression should be evaluated") && assert.Equal(t, 1, stack.Size()) {
+		value, _ := stack.Pop()
+		assert.Equal(t, int64(5), value.asNumericVar().value.IntPart())
+	}
+
+	// a local variable holding a program is pushed, not run
+	runtimeContext.EnterNewScope()
+	defer runtimeContext.LeaveScope()
+	assert.NoError(t, runtimeContext.SetVariableValue("SQ", square))
+	err = runtimeContext.RunAction(&VariableEvaluationActionDesc{varName: "SQ"})
+	if assert.NoError(t, err) && assert.Equal(t, 1, stack.Size()) {
+		value, _ := stack.Pop()
+		assert.Equal(t, TYPE_PROGRAM, value.getType())
+	}
+}
+
+func stackValues(stack *Stack) []string {
+	return displayedStackValues(stack, NewModes())
+}
+
+// displayedStackValues displays the values of the stack with the given modes, deepest level first
+func displayedStackValues(stack *Stack, modes *Modes) []string {
+	values := make([]string, stack.Size())
+	for i := range values {
+		value, _ := stack.Get(stack.Size() - 1 - i)
+		values[i] = value.display(modes)
+	}
+	return values
+}
+
+func TestStepAndConditionLoops(t *testing.T) {
+	num := func(s string) Variable {
+		return CreateNumericVariable(decimal.RequireFromString(s))
+	}
+	counter := &VariableEvaluationActionDesc{varName: "i"}
+	tests := []struct {
+		name           string
+		initialStack   []Variable
+		loop           Action
+		expectedStack  []string
+		expectedErrMsg string
+	}{
+		{"start step", []Variable{num("1"), num("10")},
+			&StartStepLoopActionDesc{actions: []Action{pushVar(num("0")), pushVar(num("4"))}},
+			[]string{"0", "0", "0"}, ""},
+		{"for negative step", []Variable{num("3"), num("1")},
+			&ForStepLoopActionDesc{varName: "i", actions: []Action{counter, pushVar(num("-1"))}},
+			[]string{"3", "2", "1"}, ""},
+		{"for decimal step", []Variable{num("0"), num("1")},
+			&ForStepLoopActionDesc{varName: "i", actions: []Action{counter, pushVar(num("0.25"))}},
+			[]string{"0", "0.25", "0.5", "0.75", "1"}, ""},
+		{"start rational step", []Variable{num("1"), num("2")},
+			&StartStepLoopActionDesc{actions: []Action{pushVar(num("0")), pushVar(ratVar(1, 2))}},
+			[]string{"0", "0", "0"}, ""},
+		{"for rational boundaries", []Variable{ratVar(1, 2), num("1")},
+			&ForStepLoopActionDesc{varName: "i", actions: []Action{counter, pushVar(ratVar(1, 4))}},
+			[]string{"0.5", "0.75", "1"}, ""},
+		{"step runs at least once", []Variable{num("5"), num("1")},
+			&ForStepLoopActionDesc{varName: "i", actions: []Action{counter, pushVar(num("1"))}},
+			[]string{"5"}, ""},
+		{"zero step", []Variable{num("1"), num("2")},
+			&StartStepLoopActionDesc{actions: []Action{pushVar(num("0"))}},
+			nil, "step increment cannot be zero"},
+		{"step without increment", []Variable{num("1"), num("2")},
+			&StartStepLoopActionDesc{},
+			nil, "step expects an increment on the stack"},
+		{"while", []Variable{num("3")},
+			&WhileRepeatLoopActionDesc{
+				conditionActions: []Action{&dupOp, pushVar(num("0")), &gtNumOp},
+				bodyActions:      []Action{&dupOp, pushVar(num("1")), &subOp},
+			},
+			[]string{"3", "2", "1", "0"}, ""},
+		{"while false", []Variable{num("0")},
+			&WhileRepeatLoopActionDesc{
+				conditionActions: []Action{pushVar(CreateBooleanVariable(false))},
+				bodyActions:      []Action{pushVar(num("1"))},
+			},
+			[]string{"0"}, ""},
+		{"do until", []Variable{num("1")},
+			&DoUntilLoopActionDesc{
+				bodyActions:      []Action{pushVar(num("2")), &mulOp},
+				conditionActions: []Action{&dupOp, pushVar(num("100")), &gtNumOp},
+			},
+			[]string{"128"}, ""},
+		{"do until runs at least once", []Variable{num("1")},
+			&DoUntilLoopActionDesc{
+				bodyActions:      []Action{pushVar(num("2")), &mulOp},
+				conditionActions: []Action{pushVar(CreateBooleanVariable(true))},
+			},
+			[]string{"2"}, ""},
+		{"non boolean condition", nil,
+			&WhileRepeatLoopActionDesc{conditionActions: []Action{pushVar(num("1"))}},
+			nil, "while condition must be a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := CreateStack()
+			for _, v := range tt.initialStack {
+				stack.Push(v)
+			}
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			err := runtimeContext.RunAction(tt.loop)
+			if tt.expectedErrMsg != "" {
+				assert.ErrorContains(t, err, tt.expectedErrMsg)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.expectedStack, stackValues(stack))
+			}
+		})
+	}
+}
+
+func TestCaseAction(t *testing.T) {
+	isEqualTo := func(value int) []Action {
+		return []Action{&dupOp, pushAction(value), &eqNumOp}
+	}
+	clauses := []CaseClause{
+		{conditionActions: isEqualTo(1), bodyActions: []Action{pushVar(CreateStringVariable("one"))}},
+		{conditionActions: isEqualTo(2), bodyActions: []Action{pushVar(CreateStringVariable("two"))}},
+	}
+	tests := []struct {
+		name           string
+		value          Variable
+		action         *CaseActionDesc
+		expectedStack  []string
+		expectedErrMsg string
+	}{
+		{"first clause", CreateNumericVariableFromInt(1),
+			&CaseActionDesc{clauses: clauses, defaultActions: []Action{pushVar(CreateStringVariable("other"))}},
+			[]string{"1", "\"one\""}, ""},
+		{"second clause", CreateNumericVariableFromInt(2),
+			&CaseActionDesc{clauses: clauses, defaultActions: []Action{pushVar(CreateStringVariable("other"))}},
+			[]string{"2", "\"two\""}, ""},
+		{"default", CreateNumericVariableFromInt(3),
+			&CaseActionDesc{clauses: clauses, defaultActions: []Action{pushVar(CreateStringVariable("other"))}},
+			[]string{"3", "\"other\""}, ""},
+		{"no default", CreateNumericVariableFromInt(3),
+			&CaseActionDesc{clauses: clauses},
+			[]string{"3"}, ""},
+		{"non boolean condition", CreateNumericVariableFromInt(3),
+			&CaseActionDesc{clauses: []CaseClause{{conditionActions: []Action{&dupOp}}}},
+			nil, "case condition must be a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := CreateStack()
+			stack.Push(tt.value)
+			runtimeContext := CreateRuntimeContext(CreateSystemInstance(), stack)
+			err := runtimeContext.RunAction(tt.action)
+			if tt.expectedErrMsg != "" {
+				assert.ErrorContains(t, err, tt.expectedErrMsg)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.expectedStack, stackValues(stack))
+			}
+		})
+	}
+}
+
+func TestCaseDisplay(t *testing.T) {
+	action := &CaseActionDesc{
+		clauses: []CaseClause{{
+			conditionActions: []Action{&dupOp, &VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(1)}, &eqNumOp},
+			bodyActions:      []Action{&dropOp},
+		}},
+		defaultActions: []Action{&swapOp},
+	}
+	assert.Equal(t, "case dup 1 == then drop end swap end", action.Display())
+}
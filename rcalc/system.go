@@ -4,6 +4,11 @@ package rcalc
 type System interface {
 	exit()
 	Memory() Memory
+	UndoHistory() *UndoHistory
+	Modes() *Modes
+	Registry() *ActionRegistry
+	LastError() *RcalcError
+	setLastError(err *RcalcError)
 }
 
 type SystemInternal interface {
@@ -13,6 +18,10 @@ type SystemInternal interface {
 type SystemInstance struct {
 	shouldStopMarker bool
 	memory           Memory
+	undoHistory      *UndoHistory
+	modes            *Modes
+	registry         *ActionRegistry
+	lastError        *RcalcError
 }
 
 func (s *SystemInstance) shouldStop() bool {
@@ -27,10 +36,41 @@ func (s *SystemInstance) Memory() Memory {
 	return s.memory
 }
 
+func (s *SystemInstance) UndoHistory() *UndoHistory {
+	return s.undoHistory
+}
+
+func (s *SystemInstance) Modes() *Modes {
+	return s.modes
+}
+
+// Registry actions known by the system, used to parse text at runtime
+func (s *SystemInstance) Registry() *ActionRegistry {
+	return s.registry
+}
+
+// LastError last error trapped by iferr or which aborted a command line, nil if none
+func (s *SystemInstance) LastError() *RcalcError {
+	return s.lastError
+}
+
+func (s *SystemInstance) setLastError(err *RcalcError) {
+	s.lastError = err
+}
+
 func CreateSystemInstance() *SystemInstance {
+	return CreateSystemInstanceWithMemory(NewInternalMemory())
+}
+
+// CreateSystemInstanceWithMemory creates a system using a memory read from disk
+func CreateSystemInstanceWithMemory(memory *InternalMemory) *SystemInstance {
+	modes := NewModes()
 	return &SystemInstance{
 		shouldStopMarker: false,
-		memory:           NewInternalMemory(),
+		memory:           memory,
+		undoHistory:      NewUndoHistory(memory, modes),
+		modes:            modes,
+		registry:         Registry,
 	}
 }
 
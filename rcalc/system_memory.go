@@ -1,6 +1,15 @@
 package rcalc
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"troisdizaines.com/rcalc/rcalc/protostack"
+)
 
 type MemoryNode interface {
 	asMemoryVariable() *MemoryVariable
@@ -67,6 +76,34 @@ func (folder *MemoryFolder) SubVariables() []*MemoryVariable {
 	return folder.variables
 }
 
+// subNode returns the folder or variable named name directly inside folder, nil if there is none
+func (folder *MemoryFolder) subNode(name string) MemoryNode {
+	for _, subFolder := range folder.subFolders {
+		if subFolder.name == name {
+			return subFolder
+		}
+	}
+	for _, variable := range folder.variables {
+		if variable.name == name {
+			return variable
+		}
+	}
+	return nil
+}
+
+// contains tells if node is folder or one of its descendants
+func (folder *MemoryFolder) contains(node MemoryNode) bool {
+	if node == MemoryNode(folder) {
+		return true
+	}
+	for parent := node.getParent(); parent != nil; parent = parent.parentFolder {
+		if parent == folder {
+			return true
+		}
+	}
+	return false
+}
+
 func (folder *MemoryFolder) SubNodes() []MemoryNode {
 	var result []MemoryNode
 	for _, elt := range folder.SubFolders() {
@@ -87,6 +124,10 @@ func (im *InternalMemory) getCurrentFolder() *MemoryFolder {
 	return im.currentFolder
 }
 
+func (im *InternalMemory) setCurrentFolder(folder *MemoryFolder) {
+	im.currentFolder = folder
+}
+
 func (im *InternalMemory) getPath(node MemoryNode) []string {
 	var result []string
 
@@ -132,7 +173,7 @@ func (im *InternalMemory) resolvePath(path []string) MemoryNode {
 type Memory interface {
 	getRoot() *MemoryFolder
 	getCurrentFolder() *MemoryFolder
-	//setCurrentFolder(f *MemoryFolder) error
+	setCurrentFolder(folder *MemoryFolder)
 
 	getPath(node MemoryNode) []string
 	resolvePath(path []string) MemoryNode
@@ -140,11 +181,14 @@ type Memory interface {
 	createFolder(folderName string, parent *MemoryFolder) (*MemoryFolder, error)
 	createVariable(variableName string, parent *MemoryFolder, value Variable) (*MemoryVariable, error)
 	listVariables(parent *MemoryFolder) ([]*MemoryVariable, error)
-	/*
-		cd(path string)
-		currentDir() string
-		list(path string) []string
-	*/
+	findVariable(variableName string) *MemoryVariable
+
+	deleteNode(node MemoryNode) error
+	renameNode(node MemoryNode, newName string) error
+	moveNode(node MemoryNode, target *MemoryFolder) error
+
+	checkpoint() MemoryCheckpoint
+	restore(checkpoint MemoryCheckpoint)
 }
 
 func (im *InternalMemory) getRoot() *MemoryFolder {
@@ -155,6 +199,9 @@ func (im *InternalMemory) createFolder(folderName string, parent *MemoryFolder)
 	if parent == nil {
 		return nil, fmt.Errorf("parent folder is nil")
 	}
+	if parent.subNode(folderName) != nil {
+		return nil, fmt.Errorf("%s already exists in folder %s", folderName, parent.name)
+	}
 	newFolder := &MemoryFolder{
 		AbstractMemoryNode: AbstractMemoryNode{
 			parentFolder: parent,
@@ -169,8 +216,15 @@ func (im *InternalMemory) createVariable(variableName string, parent *MemoryFold
 	if parent == nil {
 		return nil, fmt.Errorf("cannot create memory variable with nil parent folder")
 	}
+	switch existing := parent.subNode(variableName).(type) {
+	case *MemoryVariable:
+		existing.value = value
+		return existing, nil
+	case *MemoryFolder:
+		return nil, fmt.Errorf("%s is a folder", variableName)
+	}
 	memVar := &MemoryVariable{
-		AbstractMemoryNode: AbstractMemoryNode{name: variableName},
+		AbstractMemoryNode: AbstractMemoryNode{parentFolder: parent, name: variableName},
 		value:              value,
 	}
 	parent.variables = append(parent.variables, memVar)
@@ -181,6 +235,125 @@ func (im *InternalMemory) listVariables(parent *MemoryFolder) ([]*MemoryVariable
 	return parent.variables[:], nil
 }
 
+// findVariable looks for a variable in the current folder, then in its parents up to HOME
+func (im *InternalMemory) findVariable(variableName string) *MemoryVariable {
+	for folder := im.currentFolder; folder != nil; folder = folder.parentFolder {
+		if variable, ok := folder.subNode(variableName).(*MemoryVariable); ok {
+			return variable
+		}
+	}
+	return nil
+}
+
+// detachNode removes node from the sub nodes of its parent folder
+func detachNode(node MemoryNode) {
+	parent := node.getParent()
+	switch n := node.(type) {
+	case *MemoryFolder:
+		parent.subFolders = slices.DeleteFunc(parent.subFolders, func(f *MemoryFolder) bool { return f == n })
+	case *MemoryVariable:
+		parent.variables = slices.DeleteFunc(parent.variables, func(v *MemoryVariable) bool { return v == n })
+	}
+}
+
+// deleteNode removes a variable or a folder with all its content
+func (im *InternalMemory) deleteNode(node MemoryNode) error {
+	if node.getParent() == nil {
+		return fmt.Errorf("cannot delete the HOME folder")
+	}
+	if folder, ok := node.(*MemoryFolder); ok && folder.contains(im.currentFolder) {
+		return fmt.Errorf("cannot delete folder %s since it contains the current folder", folder.name)
+	}
+	detachNode(node)
+	return nil
+}
+
+func (im *InternalMemory) renameNode(node MemoryNode, newName string) error {
+	parent := node.getParent()
+	if parent == nil {
+		return fmt.Errorf("cannot rename the HOME folder")
+	}
+	if existing := parent.subNode(newName); existing != nil && existing != node {
+		return fmt.Errorf("%s already exists in folder %s", newName, parent.name)
+	}
+	switch n := node.(type) {
+	case *MemoryFolder:
+		n.name = newName
+	case *MemoryVariable:
+		n.name = newName
+	}
+	return nil
+}
+
+func (im *InternalMemory) moveNode(node MemoryNode, target *MemoryFolder) error {
+	if node.getParent() == nil {
+		return fmt.Errorf("cannot move the HOME folder")
+	}
+	if folder, ok := node.(*MemoryFolder); ok && folder.contains(target) {
+		return fmt.Errorf("cannot move folder %s inside itself", folder.name)
+	}
+	if target.subNode(node.Name()) != nil {
+		return fmt.Errorf("%s already exists in folder %s", node.Name(), target.name)
+	}
+	detachNode(node)
+	switch n := node.(type) {
+	case *MemoryFolder:
+		n.parentFolder = target
+		target.subFolders = append(target.subFolders, n)
+	case *MemoryVariable:
+		n.parentFolder = target
+		target.variables = append(target.variables, n)
+	}
+	return nil
+}
+
+// MemoryCheckpoint Copy of the memory tree, variable values are shared since they are never modified in place
+type MemoryCheckpoint struct {
+	root        *MemoryFolder
+	currentPath []string
+}
+
+func copyFolder(folder *MemoryFolder, parent *MemoryFolder) *MemoryFolder {
+	folderCopy := &MemoryFolder{
+		AbstractMemoryNode: AbstractMemoryNode{
+			parentFolder: parent,
+			name:         folder.name,
+		},
+	}
+	for _, subFolder := range folder.subFolders {
+		folderCopy.subFolders = append(folderCopy.subFolders, copyFolder(subFolder, folderCopy))
+	}
+	for _, variable := range folder.variables {
+		folderCopy.variables = append(folderCopy.variables, &MemoryVariable{
+			AbstractMemoryNode: AbstractMemoryNode{
+				parentFolder: folderCopy,
+				name:         variable.name,
+			},
+			value: variable.value,
+		})
+	}
+	return folderCopy
+}
+
+func (im *InternalMemory) checkpoint() MemoryCheckpoint {
+	return MemoryCheckpoint{
+		root:        copyFolder(im.memoryRoot, nil),
+		currentPath: im.getPath(im.currentFolder),
+	}
+}
+
+func (im *InternalMemory) restore(checkpoint MemoryCheckpoint) {
+	// copy again so that the checkpoint can be restored several times
+	im.memoryRoot = copyFolder(checkpoint.root, nil)
+	im.currentFolder = im.memoryRoot
+	node := im.resolvePath(checkpoint.currentPath)
+	if node != nil {
+		if folder, ok := node.(*MemoryFolder); ok {
+			im.currentFolder = folder
+		}
+	}
+}
+
 func NewInternalMemory() *InternalMemory {
 	homeFolder := &MemoryFolder{
 		AbstractMemoryNode: AbstractMemoryNode{
@@ -194,3 +367,136 @@ func NewInternalMemory() *InternalMemory {
 		currentFolder: homeFolder,
 	}
 }
+
+// Persistence of the memory
+
+func createProtoFromFolder(folder *MemoryFolder) (*protostack.MemoryFolder, error) {
+	protoFolder := &protostack.MemoryFolder{Name: folder.name}
+	for _, subFolder := range folder.subFolders {
+		protoSubFolder, err := createProtoFromFolder(subFolder)
+		if err != nil {
+			return nil, err
+		}
+		protoFolder.Folders = append(protoFolder.Folders, protoSubFolder)
+	}
+	for _, variable := range folder.variables {
+		protoValue, err := CreateProtoFromVariable(variable.value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot save variable %s: %w", variable.name, err)
+		}
+		protoFolder.Variables = append(protoFolder.Variables, &protostack.MemoryVariable{Name: variable.name, Value: protoValue})
+	}
+	return protoFolder, nil
+}
+
+func CreateProtoFromMemory(memory Memory) (*protostack.Memory, error) {
+	protoRoot, err := createProtoFromFolder(memory.getRoot())
+	if err != nil {
+		return nil, err
+	}
+	return &protostack.Memory{
+		Root:        protoRoot,
+		CurrentPath: memory.getPath(memory.getCurrentFolder()),
+	}, nil
+}
+
+func createFolderFromProto(reg *ActionRegistry, protoFolder *protostack.MemoryFolder, parent *MemoryFolder) (*MemoryFolder, error) {
+	folder := &MemoryFolder{
+		AbstractMemoryNode: AbstractMemoryNode{
+			parentFolder: parent,
+			name:         protoFolder.GetName(),
+		},
+	}
+	for _, protoSubFolder := range protoFolder.GetFolders() {
+		subFolder, err := createFolderFromProto(reg, protoSubFolder, folder)
+		if err != nil {
+			return nil, err
+		}
+		folder.subFolders = append(folder.subFolders, subFolder)
+	}
+	for _, protoVariable := range protoFolder.GetVariables() {
+		value, err := CreateVariableFromProto(reg, protoVariable.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("cannot read variable %s: %w", protoVariable.GetName(), err)
+		}
+		folder.variables = append(folder.variables, &MemoryVariable{
+			AbstractMemoryNode: AbstractMemoryNode{
+				parentFolder: folder,
+				name:         protoVariable.GetName(),
+			},
+			value: value,
+		})
+	}
+	return folder, nil
+}
+
+func CreateMemoryFromProto(reg *ActionRegistry, protoMemory *protostack.Memory) (*InternalMemory, error) {
+	if protoMemory.GetRoot() == nil {
+		return nil, fmt.Errorf("memory has no root folder")
+	}
+	root, err := createFolderFromProto(reg, protoMemory.GetRoot(), nil)
+	if err != nil {
+		return nil, err
+	}
+	memory := &InternalMemory{
+		memoryRoot:    root,
+		currentFolder: root,
+	}
+	if node := memory.resolvePath(protoMemory.GetCurrentPath()); node != nil {
+		if folder, ok := node.(*MemoryFolder); ok {
+			memory.currentFolder = folder
+		}
+	}
+	return memory, nil
+}
+
+// ReadMemoryFromDisk reads the memory saved by a MemorySavingListener, an empty memory is
+// returned when there is no saved memory
+func ReadMemoryFromDisk(memorySavingPath string) (*InternalMemory, error) {
+	file, err := os.ReadFile(memorySavingPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewInternalMemory(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	protoMemory := &protostack.Memory{}
+	if err = proto.Unmarshal(file, protoMemory); err != nil {
+		return nil, err
+	}
+	return CreateMemoryFromProto(Registry, protoMemory)
+}
+
+// MemorySavingListener saves the memory tree at the end of each command line
+type MemorySavingListener struct {
+	memory           Memory
+	memorySavingPath string
+}
+
+var _ StackSessionListener = (*MemorySavingListener)(nil)
+
+func NewMemorySavingListener(memorySavingPath string, memory Memory) *MemorySavingListener {
+	return &MemorySavingListener{memory: memory, memorySavingPath: memorySavingPath}
+}
+
+func (ml *MemorySavingListener) SessionStart(s *Stack) {
+}
+
+func (ml *MemorySavingListener) SessionClose(s *Stack) {
+	if err := SaveMemoryToDisk(ml.memorySavingPath, ml.memory); err != nil {
+		GetLogger().Errorf("Error saving memory: %v", err)
+	}
+}
+
+// SaveMemoryToDisk writes the memory in the file read by ReadMemoryFromDisk
+func SaveMemoryToDisk(memorySavingPath string, memory Memory) error {
+	protoMemory, err := CreateProtoFromMemory(memory)
+	if err != nil {
+		return err
+	}
+	protoMemoryBytes, err := proto.Marshal(protoMemory)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(memorySavingPath, protoMemoryBytes, 0644)
+}
@@ -0,0 +1,72 @@
+package rcalc
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMemoryPersistence(t *testing.T) {
+	memory := NewInternalMemory()
+	constants, _ := memory.createFolder("CONSTANTS", memory.getRoot())
+	_, _ = memory.createFolder("EMPTY", memory.getRoot())
+	_, _ = memory.createVariable("g", constants, qtyVar(9.81, "m/s^2"))
+	_, _ = memory.createVariable("name", memory.getRoot(), CreateStringVariable("rcalc"))
+	_, _ = memory.createVariable("prog", memory.getRoot(), CreateProgramVariable([]Action{
+		&VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(2)},
+		&mulOp,
+		&storeAct,
+	}))
+	memory.currentFolder = constants
+
+	memoryPath := path.Join(t.TempDir(), "memory.protobuf")
+	stack := CreateStack()
+	stack.AddSessionListener(NewMemorySavingListener(memoryPath, memory))
+	assert.NoError(t, stack.StartSession())
+	assert.NoError(t, stack.CloseSession())
+
+	readMemory, err := ReadMemoryFromDisk(memoryPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"CONSTANTS"}, readMemory.getPath(readMemory.getCurrentFolder()))
+	g := readMemory.resolvePath([]string{"CONSTANTS", "g"})
+	if assert.NotNil(t, g) {
+		assert.Equal(t, "9.81_m/s^2", g.asMemoryVariable().Value().display(NewModes()))
+		assert.Equal(t, []string{"CONSTANTS", "g"}, readMemory.getPath(g))
+	}
+
+	expected, err := CreateProtoFromMemory(memory)
+	if assert.NoError(t, err) {
+		actual, err := CreateProtoFromMemory(readMemory)
+		if assert.NoError(t, err) {
+			assert.True(t, proto.Equal(expected, actual), "Expected %v, got %v", expected, actual)
+		}
+	}
+}
+
+func TestMemoryFromMissingFile(t *testing.T) {
+	memory, err := ReadMemoryFromDisk(path.Join(t.TempDir(), "memory.protobuf"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "HOME", memory.getRoot().Name())
+		assert.Empty(t, memory.getRoot().SubNodes())
+	}
+}
+
+func TestSessionWithUnreadableMemory(t *testing.T) {
+	folder := t.TempDir()
+	memoryPath := path.Join(folder, memoryFileName)
+	assert.NoError(t, os.WriteFile(memoryPath, []byte("not a memory"), 0644))
+
+	_, _, err := loadSession(folder, false)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "error reading memory from "+memoryPath)
+	}
+	content, err := os.ReadFile(memoryPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "not a memory", string(content), "the unreadable memory is kept")
+	}
+}
@@ -0,0 +1,322 @@
+package rcalc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// lineEditor Content of the input line with its cursor and the history of validated lines
+type lineEditor struct {
+	text   []rune
+	cursor int
+
+	history []string
+	// historyIdx is len(history) while editing a new line
+	historyIdx int
+	// draft keeps the line being edited while browsing the history
+	draft []rune
+}
+
+func newLineEditor() *lineEditor {
+	return &lineEditor{}
+}
+
+func (le *lineEditor) String() string {
+	return string(le.text)
+}
+
+func (le *lineEditor) insert(r rune) {
+	le.text = append(le.text[:le.cursor], append([]rune{r}, le.text[le.cursor:]...)...)
+	le.cursor++
+}
+
+func (le *lineEditor) backspace() {
+	if le.cursor > 0 {
+		le.text = append(le.text[:le.cursor-1], le.text[le.cursor:]...)
+		le.cursor--
+	}
+}
+
+func (le *lineEditor) delete() {
+	if le.cursor < len(le.text) {
+		le.text = append(le.text[:le.cursor], le.text[le.cursor+1:]...)
+	}
+}
+
+func (le *lineEditor) moveLeft() {
+	if le.cursor > 0 {
+		le.cursor--
+	}
+}
+
+func (le *lineEditor) moveRight() {
+	if le.cursor < len(le.text) {
+		le.cursor++
+	}
+}
+
+func (le *lineEditor) moveHome() {
+	le.cursor = 0
+}
+
+func (le *lineEditor) moveEnd() {
+	le.cursor = len(le.text)
+}
+
+func (le *lineEditor) killToEnd() {
+	le.text = le.text[:le.cursor]
+}
+
+func (le *lineEditor) killToStart() {
+	le.text = le.text[le.cursor:]
+	le.cursor = 0
+}
+
+func (le *lineEditor) setText(text []rune) {
+	le.text = append([]rune{}, text...)
+	le.cursor = len(le.text)
+}
+
+func (le *lineEditor) previous() {
+	if le.historyIdx == 0 {
+		return
+	}
+	if le.historyIdx == len(le.history) {
+		le.draft = append([]rune{}, le.text...)
+	}
+	le.historyIdx--
+	le.setText([]rune(le.history[le.historyIdx]))
+}
+
+func (le *lineEditor) next() {
+	if le.historyIdx == len(le.history) {
+		return
+	}
+	le.historyIdx++
+	if le.historyIdx == len(le.history) {
+		le.setText(le.draft)
+	} else {
+		le.setText([]rune(le.history[le.historyIdx]))
+	}
+}
+
+// validate returns the current line, records it in the history and starts a new empty line
+func (le *lineEditor) validate() string {
+	line := le.String()
+	if line != "" && (len(le.history) == 0 || le.history[len(le.history)-1] != line) {
+		le.history = append(le.history, line)
+	}
+	le.historyIdx = len(le.history)
+	le.text = nil
+	le.cursor = 0
+	le.draft = nil
+	return line
+}
+
+const tuiPrompt = "> "
+
+var (
+	tuiLevelStyle   = tcell.StyleDefault.Foreground(tcell.ColorGray)
+	tuiValueStyle   = tcell.StyleDefault
+	tuiMessageStyle = tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+	tuiPromptStyle  = tcell.StyleDefault.Bold(true)
+)
+
+// TuiFrontend Full screen frontend: a scrollable stack pane, a message line and an editable input line
+type TuiFrontend struct {
+	screen  tcell.Screen
+	editor  *lineEditor
+	stack   StackReader
+	modes   *Modes
+	message string
+	// minLevels number of stack levels numbered in the stack pane, even when empty
+	minLevels int
+	// scrollOffset number of stack levels hidden below the bottom of the stack pane
+	scrollOffset int
+}
+
+var _ Frontend = (*TuiFrontend)(nil)
+
+// NewTuiFrontend creates the full screen frontend, it always redraws the whole terminal
+func NewTuiFrontend(minLevels int) *TuiFrontend {
+	return &TuiFrontend{editor: newLineEditor(), minLevels: minLevels}
+}
+
+func newTuiFrontendWithScreen(screen tcell.Screen, minLevels int) *TuiFrontend {
+	return &TuiFrontend{screen: screen, editor: newLineEditor(), minLevels: minLevels}
+}
+
+func (tf *TuiFrontend) Start() error {
+	if tf.screen == nil {
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			return fmt.Errorf("cannot create terminal screen: %w", err)
+		}
+		tf.screen = screen
+	}
+	if err := tf.screen.Init(); err != nil {
+		return fmt.Errorf("cannot initialize terminal screen: %w", err)
+	}
+	tf.screen.Clear()
+	return nil
+}
+
+func (tf *TuiFrontend) Stop() {
+	if tf.screen != nil {
+		tf.screen.Fini()
+	}
+}
+
+func (tf *TuiFrontend) Refresh(stack StackReader, modes *Modes, message string) {
+	tf.stack = stack
+	tf.modes = modes
+	tf.message = message
+	tf.scrollOffset = 0
+	tf.draw()
+}
+
+func (tf *TuiFrontend) ReadCommandLine() (string, error) {
+	for {
+		tf.draw()
+		switch ev := tf.screen.PollEvent().(type) {
+		case nil:
+			// screen has been finalized
+			return "", io.EOF
+		case *tcell.EventResize:
+			tf.screen.Sync()
+		case *tcell.EventKey:
+			if line, done, err := tf.handleKey(ev); done {
+				return line, err
+			}
+		}
+	}
+}
+
+// handleKey applies a key event, done is true when the line is validated or the user leaves
+func (tf *TuiFrontend) handleKey(ev *tcell.EventKey) (string, bool, error) {
+	editor := tf.editor
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		return editor.validate(), true, nil
+	case tcell.KeyCtrlC:
+		return "", true, io.EOF
+	case tcell.KeyCtrlZ:
+		// the line being edited is kept
+		return undoAct.OpCode(), true, nil
+	case tcell.KeyCtrlY:
+		return redoAct.OpCode(), true, nil
+	case tcell.KeyCtrlD:
+		if len(editor.text) == 0 {
+			return "", true, io.EOF
+		}
+		editor.delete()
+	case tcell.KeyLeft, tcell.KeyCtrlB:
+		editor.moveLeft()
+	case tcell.KeyRight, tcell.KeyCtrlF:
+		editor.moveRight()
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		editor.moveHome()
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		editor.moveEnd()
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		editor.backspace()
+	case tcell.KeyDelete:
+		editor.delete()
+	case tcell.KeyCtrlK:
+		editor.killToEnd()
+	case tcell.KeyCtrlU:
+		editor.killToStart()
+	case tcell.KeyUp, tcell.KeyCtrlP:
+		editor.previous()
+	case tcell.KeyDown, tcell.KeyCtrlN:
+		editor.next()
+	case tcell.KeyPgUp:
+		tf.scroll(tf.stackPaneHeight())
+	case tcell.KeyPgDn:
+		tf.scroll(-tf.stackPaneHeight())
+	case tcell.KeyRune:
+		editor.insert(ev.Rune())
+	}
+	return "", false, nil
+}
+
+// messageLines lines of the message, parse errors show the faulty line and a caret under the error
+func (tf *TuiFrontend) messageLines() []string {
+	_, height := tf.screen.Size()
+	lines := strings.Split(strings.ReplaceAll(tf.message, "\t", " "), "\n")
+	return lines[:min(len(lines), max(height-1, 0))]
+}
+
+func (tf *TuiFrontend) stackPaneHeight() int {
+	_, height := tf.screen.Size()
+	// last lines are the message and the input line
+	return max(height-len(tf.messageLines())-1, 0)
+}
+
+func (tf *TuiFrontend) scroll(levels int) {
+	stackSize := 0
+	if tf.stack != nil {
+		stackSize = tf.stack.Size()
+	}
+	maxOffset := max(stackSize-tf.stackPaneHeight(), 0)
+	tf.scrollOffset = min(max(tf.scrollOffset+levels, 0), maxOffset)
+}
+
+func (tf *TuiFrontend) draw() {
+	screen := tf.screen
+	screen.Clear()
+	width, height := screen.Size()
+	paneHeight := tf.stackPaneHeight()
+
+	// Stack pane, level 1 is just above the message lines
+	stackSize := 0
+	if tf.stack != nil {
+		stackSize = tf.stack.Size()
+	}
+	for row := 0; row < paneHeight; row++ {
+		level := tf.scrollOffset + paneHeight - row
+		if level > max(stackSize, tf.minLevels) {
+			continue
+		}
+		levelStr := fmt.Sprintf("%2d:", level)
+		drawString(screen, 0, row, width, levelStr, tuiLevelStyle)
+		if level <= stackSize {
+			elt, err := tf.stack.Get(level - 1)
+			if err == nil {
+				value := elt.display(tf.modes)
+				start := max(width-len([]rune(value)), len(levelStr)+1)
+				drawString(screen, start, row, width, value, tuiValueStyle)
+			}
+		}
+	}
+
+	// Message lines
+	for idx, line := range tf.messageLines() {
+		drawString(screen, 0, paneHeight+idx, width, line, tuiMessageStyle)
+	}
+
+	// Input line, scrolled horizontally to keep the cursor visible
+	if height >= 1 {
+		promptLen := len([]rune(tuiPrompt))
+		drawString(screen, 0, height-1, width, tuiPrompt, tuiPromptStyle)
+		available := max(width-promptLen-1, 1)
+		viewStart := max(tf.editor.cursor-available, 0)
+		visible := tf.editor.text[viewStart:]
+		drawString(screen, promptLen, height-1, width, string(visible), tuiValueStyle)
+		screen.ShowCursor(promptLen+tf.editor.cursor-viewStart, height-1)
+	}
+	screen.Show()
+}
+
+func drawString(screen tcell.Screen, x int, y int, width int, str string, style tcell.Style) {
+	for _, r := range str {
+		if x >= width {
+			return
+		}
+		screen.SetContent(x, y, r, nil, style)
+		x++
+	}
+}
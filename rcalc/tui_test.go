@@ -0,0 +1,174 @@
+package rcalc
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineEditorEditing(t *testing.T) {
+	editor := newLineEditor()
+	for _, r := range "1 3 +" {
+		editor.insert(r)
+	}
+	editor.moveHome()
+	editor.moveRight()
+	editor.insert('2')
+	assert.Equal(t, "12 3 +", editor.String())
+
+	editor.moveEnd()
+	editor.backspace()
+	editor.insert('*')
+	assert.Equal(t, "12 3 *", editor.String())
+
+	editor.moveHome()
+	editor.delete()
+	assert.Equal(t, "2 3 *", editor.String())
+
+	editor.moveRight()
+	editor.killToEnd()
+	assert.Equal(t, "2", editor.String())
+	editor.killToStart()
+	assert.Equal(t, "", editor.String())
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	editor := newLineEditor()
+	for _, line := range []string{"1", "2", "2", "3"} {
+		editor.setText([]rune(line))
+		editor.validate()
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, editor.history)
+
+	editor.insert('d')
+	editor.previous()
+	assert.Equal(t, "3", editor.String())
+	editor.previous()
+	editor.previous()
+	editor.previous()
+	assert.Equal(t, "1", editor.String())
+	editor.next()
+	assert.Equal(t, "2", editor.String())
+	editor.next()
+	editor.next()
+	assert.Equal(t, "d", editor.String(), "draft line must be restored after browsing the history")
+}
+
+func createSimulatedTui(t *testing.T, width int, height int) (*TuiFrontend, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	frontend := newTuiFrontendWithScreen(screen, defaultStackLevels)
+	if !assert.NoError(t, frontend.Start()) {
+		t.FailNow()
+	}
+	screen.SetSize(width, height)
+	return frontend, screen
+}
+
+func screenLines(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	lines := make([]string, height)
+	for y := 0; y < height; y++ {
+		var line strings.Builder
+		for x := 0; x < width; x++ {
+			runes := cells[y*width+x].Runes
+			if len(runes) == 0 {
+				line.WriteRune(' ')
+			} else {
+				line.WriteRune(runes[0])
+			}
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return lines
+}
+
+func TestTuiRefresh(t *testing.T) {
+	frontend, screen := createSimulatedTui(t, 20, 5)
+	defer frontend.Stop()
+
+	stack := CreateStack()
+	stack.Push(CreateNumericVariableFromInt(12))
+	stack.Push(CreateBooleanVariable(true))
+	frontend.Refresh(stack, NewModes(), "an error")
+
+	lines := screenLines(screen)
+	assert.Equal(t, []string{
+		" 3:",
+		" 2:               12",
+		" 1:             true",
+		"an error",
+		">",
+	}, lines)
+}
+
+func TestTuiStackLevels(t *testing.T) {
+	frontend, screen := createSimulatedTui(t, 20, 5)
+	defer frontend.Stop()
+	frontend.minLevels = 1
+
+	frontend.Refresh(CreateStack(), NewModes(), "")
+	assert.Equal(t, []string{"", "", " 1:", "", ">"}, screenLines(screen))
+
+	stack := CreateStack()
+	stack.Push(CreateNumericVariableFromInt(1))
+	stack.Push(CreateNumericVariableFromInt(2))
+	frontend.Refresh(stack, NewModes(), "")
+	assert.Equal(t, []string{"", " 2:                1", " 1:                2", "", ">"}, screenLines(screen))
+}
+
+func TestTuiMultilineMessage(t *testing.T) {
+	frontend, screen := createSimulatedTui(t, 30, 5)
+	defer frontend.Stop()
+
+	stack := CreateStack()
+	stack.Push(CreateNumericVariableFromInt(12))
+	frontend.Refresh(stack, NewModes(), "line 1, column 3: unexpected >>\n  1\t>>\n   \t^^")
+
+	lines := screenLines(screen)
+	assert.Equal(t, []string{
+		" 1:                         12",
+		"line 1, column 3: unexpected >",
+		"  1 >>",
+		"    ^^",
+		">",
+	}, lines)
+}
+
+func TestTuiReadCommandLine(t *testing.T) {
+	frontend, screen := createSimulatedTui(t, 20, 5)
+	defer frontend.Stop()
+	frontend.Refresh(CreateStack(), NewModes(), "")
+
+	for _, r := range "2 dupp" {
+		screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+	screen.InjectKey(tcell.KeyBackspace2, 0, tcell.ModNone)
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	line, err := frontend.ReadCommandLine()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2 dup", line)
+	}
+
+	screen.InjectKey(tcell.KeyUp, 0, tcell.ModNone)
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	line, err = frontend.ReadCommandLine()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2 dup", line)
+	}
+
+	screen.InjectKey(tcell.KeyRune, '3', tcell.ModNone)
+	screen.InjectKey(tcell.KeyCtrlZ, 0, tcell.ModNone)
+	line, err = frontend.ReadCommandLine()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "undo", line)
+		assert.Equal(t, "3", frontend.editor.String())
+	}
+
+	frontend.editor.killToStart()
+	screen.InjectKey(tcell.KeyCtrlD, 0, tcell.ModNone)
+	_, err = frontend.ReadCommandLine()
+	assert.ErrorIs(t, err, io.EOF)
+}
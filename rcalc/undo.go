@@ -0,0 +1,149 @@
+package rcalc
+
+import (
+	"fmt"
+	"slices"
+)
+
+const defaultMaxUndoLevels = 100
+
+type systemCheckpoint struct {
+	stack  StackCheckpoint
+	memory MemoryCheckpoint
+	modes  Modes
+}
+
+// UndoHistory Keeps the state of the stack, of the memory and of the modes at the start of each
+// command line to undo/redo whole lines. It is registered as a StackSessionListener.
+type UndoHistory struct {
+	memory     Memory
+	modes      *Modes
+	maxLevels  int
+	undoStates []systemCheckpoint
+	redoStates []systemCheckpoint
+	// state at the start of the on going session
+	lineStart *systemCheckpoint
+	// set when undo/redo are used during the session: such lines are not recorded
+	historyUsed bool
+	// undo/redo states at the start of the on going session, used when the line is discarded
+	lineStartUndoStates []systemCheckpoint
+	lineStartRedoStates []systemCheckpoint
+}
+
+var _ StackSessionListener = (*UndoHistory)(nil)
+
+func NewUndoHistory(memory Memory, modes *Modes) *UndoHistory {
+	return &UndoHistory{
+		memory:    memory,
+		modes:     modes,
+		maxLevels: defaultMaxUndoLevels,
+	}
+}
+
+func (uh *UndoHistory) checkpoint(s *Stack) systemCheckpoint {
+	return systemCheckpoint{
+		stack:  s.Checkpoint(),
+		memory: uh.memory.checkpoint(),
+		modes:  uh.modes.checkpoint(),
+	}
+}
+
+func (uh *UndoHistory) restore(s *Stack, checkpoint systemCheckpoint) {
+	s.Restore(checkpoint.stack)
+	uh.memory.restore(checkpoint.memory)
+	uh.modes.restore(checkpoint.modes)
+}
+
+func (uh *UndoHistory) SessionStart(s *Stack) {
+	lineStart := uh.checkpoint(s)
+	uh.lineStart = &lineStart
+	uh.historyUsed = false
+	uh.lineStartUndoStates = slices.Clone(uh.undoStates)
+	uh.lineStartRedoStates = slices.Clone(uh.redoStates)
+}
+
+func (uh *UndoHistory) SessionClose(s *Stack) {
+	if uh.lineStart != nil && !uh.historyUsed {
+		uh.undoStates = append(uh.undoStates, *uh.lineStart)
+		if len(uh.undoStates) > uh.maxLevels {
+			uh.undoStates = uh.undoStates[len(uh.undoStates)-uh.maxLevels:]
+		}
+		uh.redoStates = nil
+	}
+	uh.lineStart = nil
+	uh.lineStartUndoStates = nil
+	uh.lineStartRedoStates = nil
+}
+
+// discardLine forgets the on going session: the line will not be undoable and the undo/redo
+// done during the line are cancelled. Used when the line is rolled back.
+func (uh *UndoHistory) discardLine() {
+	if uh.lineStart == nil {
+		return
+	}
+	uh.undoStates = uh.lineStartUndoStates
+	uh.redoStates = uh.lineStartRedoStates
+	uh.lineStart = nil
+}
+
+func (uh *UndoHistory) CanUndo() bool {
+	return len(uh.undoStates) > 0
+}
+
+func (uh *UndoHistory) CanRedo() bool {
+	return len(uh.redoStates) > 0
+}
+
+// Undo restores the stack, the memory and the modes as they were before the last recorded command line
+func (uh *UndoHistory) Undo(s *Stack) error {
+	if !uh.CanUndo() {
+		return fmt.Errorf("nothing to undo")
+	}
+	uh.redoStates = append(uh.redoStates, uh.checkpoint(s))
+	lastIdx := len(uh.undoStates) - 1
+	uh.restore(s, uh.undoStates[lastIdx])
+	uh.undoStates = uh.undoStates[:lastIdx]
+	uh.historyUsed = true
+	return nil
+}
+
+// Redo reapplies the last command line cancelled by Undo
+func (uh *UndoHistory) Redo(s *Stack) error {
+	if !uh.CanRedo() {
+		return fmt.Errorf("nothing to redo")
+	}
+	uh.undoStates = append(uh.undoStates, uh.checkpoint(s))
+	lastIdx := len(uh.redoStates) - 1
+	uh.restore(s, uh.redoStates[lastIdx])
+	uh.redoStates = uh.redoStates[:lastIdx]
+	uh.historyUsed = true
+	return nil
+}
+
+var undoAct = NewActionDesc("undo", 0, CheckNoop, func(system System, stack *Stack) error {
+	return system.UndoHistory().Undo(stack)
+})
+
+var redoAct = NewActionDesc("redo", 0, CheckNoop, func(system System, stack *Stack) error {
+	return system.UndoHistory().Redo(stack)
+})
+
+var UndoPackage = ActionPackage{
+	name: "undo",
+	staticActions: []Action{
+		&undoAct,
+		&redoAct,
+	},
+	docs: map[string]ActionDoc{
+		"undo": {
+			Summary: "restores the stack and the memory as they were before the last command line",
+			Stack:   "->",
+			Types:   "none",
+		},
+		"redo": {
+			Summary: "reapplies the last command line cancelled by undo",
+			Stack:   "->",
+			Types:   "none",
+		},
+	},
+}
@@ -0,0 +1,88 @@
+package rcalc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runInSession(t *testing.T, runtimeContext *RuntimeContext, actions ...Action) {
+	if !assert.NoError(t, runtimeContext.stack.StartSession()) {
+		t.FailNow()
+	}
+	for _, action := range actions {
+		_ = runtimeContext.RunAction(action)
+	}
+	assert.NoError(t, runtimeContext.stack.CloseSession())
+}
+
+func pushAction(value int) Action {
+	return &VariablePutOnStackActionDesc{value: CreateNumericVariableFromInt(value)}
+}
+
+func TestUndoRedoLines(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	stack.AddSessionListener(system.UndoHistory())
+	runtimeContext := CreateRuntimeContext(system, stack)
+
+	runInSession(t, runtimeContext, pushAction(1), pushAction(2))
+	runInSession(t, runtimeContext, &addOp)
+	assert.Equal(t, 1, stack.Size())
+
+	runInSession(t, runtimeContext, &undoAct)
+	if assert.Equal(t, 2, stack.Size()) {
+		top, _ := stack.Get(0)
+		assert.Equal(t, int64(2), top.asNumericVar().value.IntPart())
+	}
+
+	runInSession(t, runtimeContext, &undoAct)
+	assert.Equal(t, 0, stack.Size())
+	assert.Error(t, system.UndoHistory().Undo(stack), "history should be empty")
+
+	runInSession(t, runtimeContext, &redoAct, &redoAct)
+	if assert.Equal(t, 1, stack.Size()) {
+		top, _ := stack.Get(0)
+		assert.Equal(t, int64(3), top.asNumericVar().value.IntPart())
+	}
+
+	// a new line clears the redo states
+	runInSession(t, runtimeContext, &undoAct)
+	runInSession(t, runtimeContext, pushAction(7))
+	assert.False(t, system.UndoHistory().CanRedo())
+}
+
+func TestUndoRestoresMemory(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	stack.AddSessionListener(system.UndoHistory())
+	runtimeContext := CreateRuntimeContext(system, stack)
+
+	folderName := CreateAlgebraicExpressionVariable("DIR", nil)
+	runInSession(t, runtimeContext, &VariablePutOnStackActionDesc{value: folderName}, &crdirAct)
+	assert.Len(t, system.Memory().getRoot().subFolders, 1)
+
+	runInSession(t, runtimeContext, &undoAct)
+	assert.Len(t, system.Memory().getRoot().subFolders, 0)
+
+	runInSession(t, runtimeContext, &redoAct)
+	if assert.Len(t, system.Memory().getRoot().subFolders, 1) {
+		assert.Equal(t, "DIR", system.Memory().getRoot().subFolders[0].Name())
+	}
+}
+
+func TestUndoRestoresModes(t *testing.T) {
+	stack := CreateStack()
+	system := CreateSystemInstance()
+	stack.AddSessionListener(system.UndoHistory())
+	runtimeContext := CreateRuntimeContext(system, stack)
+
+	runInSession(t, runtimeContext, &degAct)
+	assert.Equal(t, ANGLE_DEG, system.Modes().angle)
+
+	runInSession(t, runtimeContext, &undoAct)
+	assert.Equal(t, ANGLE_RAD, system.Modes().angle)
+
+	runInSession(t, runtimeContext, &redoAct)
+	assert.Equal(t, ANGLE_DEG, system.Modes().angle)
+}
@@ -0,0 +1,292 @@
+package rcalc
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Units of measure: a unit is a product of named units raised to integer powers, each named
+// unit being defined by its value in SI base units and its dimension.
+
+// Dimension exponents of the SI base units, in the order of siBaseUnits
+type Dimension [7]int
+
+var siBaseUnits = [7]string{"m", "kg", "s", "A", "K", "mol", "cd"}
+
+type unitDefinition struct {
+	// factor value of the unit in SI base units
+	factor    decimal.Decimal
+	dimension Dimension
+	// prefixable tells if SI prefixes like k or m can be used with the unit
+	prefixable bool
+}
+
+func defineUnit(factor string, prefixable bool, dimension Dimension) unitDefinition {
+	return unitDefinition{
+		factor:     decimal.RequireFromString(factor),
+		dimension:  dimension,
+		prefixable: prefixable,
+	}
+}
+
+var (
+	dimLength      = Dimension{1, 0, 0, 0, 0, 0, 0}
+	dimMass        = Dimension{0, 1, 0, 0, 0, 0, 0}
+	dimTime        = Dimension{0, 0, 1, 0, 0, 0, 0}
+	dimArea        = Dimension{2, 0, 0, 0, 0, 0, 0}
+	dimVolume      = Dimension{3, 0, 0, 0, 0, 0, 0}
+	dimSpeed       = Dimension{1, 0, -1, 0, 0, 0, 0}
+	dimForce       = Dimension{1, 1, -2, 0, 0, 0, 0}
+	dimPressure    = Dimension{-1, 1, -2, 0, 0, 0, 0}
+	dimEnergy      = Dimension{2, 1, -2, 0, 0, 0, 0}
+	dimPower       = Dimension{2, 1, -3, 0, 0, 0, 0}
+	dimCharge      = Dimension{0, 0, 1, 1, 0, 0, 0}
+	dimVoltage     = Dimension{2, 1, -3, -1, 0, 0, 0}
+	dimResistance  = Dimension{2, 1, -3, -2, 0, 0, 0}
+	dimCapacitance = Dimension{-2, -1, 4, 2, 0, 0, 0}
+	dimMagnetic    = Dimension{0, 1, -2, -1, 0, 0, 0}
+)
+
+var unitTable = map[string]unitDefinition{
+	// SI base units, the kilogram is defined by prefixing the gram
+	"m":   defineUnit("1", true, dimLength),
+	"g":   defineUnit("0.001", true, dimMass),
+	"s":   defineUnit("1", true, dimTime),
+	"A":   defineUnit("1", true, Dimension{0, 0, 0, 1, 0, 0, 0}),
+	"K":   defineUnit("1", true, Dimension{0, 0, 0, 0, 1, 0, 0}),
+	"mol": defineUnit("1", true, Dimension{0, 0, 0, 0, 0, 1, 0}),
+	"cd":  defineUnit("1", true, Dimension{0, 0, 0, 0, 0, 0, 1}),
+
+	// SI derived units
+	"Hz":  defineUnit("1", true, Dimension{0, 0, -1, 0, 0, 0, 0}),
+	"N":   defineUnit("1", true, dimForce),
+	"Pa":  defineUnit("1", true, dimPressure),
+	"J":   defineUnit("1", true, dimEnergy),
+	"W":   defineUnit("1", true, dimPower),
+	"C":   defineUnit("1", true, dimCharge),
+	"V":   defineUnit("1", true, dimVoltage),
+	"ohm": defineUnit("1", true, dimResistance),
+	"F":   defineUnit("1", true, dimCapacitance),
+	"T":   defineUnit("1", true, dimMagnetic),
+
+	// Units accepted with the SI
+	"L":   defineUnit("0.001", true, dimVolume),
+	"t":   defineUnit("1000", false, dimMass),
+	"min": defineUnit("60", false, dimTime),
+	"h":   defineUnit("3600", false, dimTime),
+	"d":   defineUnit("86400", false, dimTime),
+	"yr":  defineUnit("31557600", false, dimTime),
+	"ha":  defineUnit("10000", false, dimArea),
+	"bar": defineUnit("100000", true, dimPressure),
+	"atm": defineUnit("101325", false, dimPressure),
+	"eV":  defineUnit("1.602176634e-19", true, dimEnergy),
+	"cal": defineUnit("4.184", true, dimEnergy),
+	"Wh":  defineUnit("3600", true, dimEnergy),
+
+	// Imperial and US customary units
+	"in":   defineUnit("0.0254", false, dimLength),
+	"ft":   defineUnit("0.3048", false, dimLength),
+	"yd":   defineUnit("0.9144", false, dimLength),
+	"mi":   defineUnit("1609.344", false, dimLength),
+	"nmi":  defineUnit("1852", false, dimLength),
+	"ac":   defineUnit("4046.8564224", false, dimArea),
+	"gal":  defineUnit("0.003785411784", false, dimVolume),
+	"qt":   defineUnit("0.000946352946", false, dimVolume),
+	"lb":   defineUnit("0.45359237", false, dimMass),
+	"oz":   defineUnit("0.028349523125", false, dimMass),
+	"mph":  defineUnit("0.44704", false, dimSpeed),
+	"knot": defineUnit("0.5144444444444444", false, dimSpeed),
+	"lbf":  defineUnit("4.4482216152605", false, dimForce),
+	"psi":  defineUnit("6894.757293168361", false, dimPressure),
+	"hp":   defineUnit("745.69987158227022", false, dimPower),
+	"BTU":  defineUnit("1055.05585262", false, dimEnergy),
+}
+
+var unitPrefixes = map[string]decimal.Decimal{
+	"T": decimal.New(1, 12),
+	"G": decimal.New(1, 9),
+	"M": decimal.New(1, 6),
+	"k": decimal.New(1, 3),
+	"h": decimal.New(1, 2),
+	"d": decimal.New(1, -1),
+	"c": decimal.New(1, -2),
+	"m": decimal.New(1, -3),
+	"u": decimal.New(1, -6),
+	"µ": decimal.New(1, -6),
+	"n": decimal.New(1, -9),
+	"p": decimal.New(1, -12),
+}
+
+// lookupUnit finds a unit by its name, or as a prefixed SI unit
+func lookupUnit(name string) (unitDefinition, bool) {
+	if definition, ok := unitTable[name]; ok {
+		return definition, true
+	}
+	for prefix, prefixFactor := range unitPrefixes {
+		if definition, ok := unitTable[strings.TrimPrefix(name, prefix)]; ok && strings.HasPrefix(name, prefix) && definition.prefixable {
+			return unitDefinition{
+				factor:    prefixFactor.Mul(definition.factor),
+				dimension: definition.dimension,
+			}, true
+		}
+	}
+	return unitDefinition{}, false
+}
+
+type unitTerm struct {
+	name     string
+	exponent int
+}
+
+// Unit product of named units with their exponents, a unit without terms is dimensionless
+type Unit struct {
+	terms []unitTerm
+}
+
+var unitTermRegexp = regexp.MustCompile(`^([*/]?)([a-zA-Zµ]+)(?:\^(-?[0-9]+))?`)
+
+// ParseUnit parses units like m, km/h or kg*m^2/s^2, each / only applies to the unit following it
+func ParseUnit(text string) (Unit, error) {
+	var unit Unit
+	rest := text
+	for first := true; first || len(rest) > 0; first = false {
+		match := unitTermRegexp.FindStringSubmatch(rest)
+		if match == nil || first != (match[1] == "") {
+			return Unit{}, fmt.Errorf("invalid unit %s", text)
+		}
+		if _, ok := lookupUnit(match[2]); !ok {
+			return Unit{}, fmt.Errorf("unknown unit %s", match[2])
+		}
+		exponent := 1
+		if match[3] != "" {
+			var err error
+			if exponent, err = strconv.Atoi(match[3]); err != nil {
+				return Unit{}, fmt.Errorf("invalid exponent in unit %s", text)
+			}
+		}
+		if match[1] == "/" {
+			exponent = -exponent
+		}
+		unit = unit.Mul(Unit{terms: []unitTerm{{name: match[2], exponent: exponent}}})
+		rest = rest[len(match[0]):]
+	}
+	return unit, nil
+}
+
+// Mul combines the terms of both units, terms whose exponent becomes 0 are removed
+func (u Unit) Mul(other Unit) Unit {
+	terms := slices.Clone(u.terms)
+	for _, otherTerm := range other.terms {
+		found := false
+		for idx := range terms {
+			if terms[idx].name == otherTerm.name {
+				terms[idx].exponent += otherTerm.exponent
+				found = true
+				break
+			}
+		}
+		if !found {
+			terms = append(terms, otherTerm)
+		}
+	}
+	var result Unit
+	for _, term := range terms {
+		if term.exponent != 0 {
+			result.terms = append(result.terms, term)
+		}
+	}
+	return result
+}
+
+func (u Unit) Pow(exponent int) Unit {
+	var result Unit
+	if exponent == 0 {
+		return result
+	}
+	for _, term := range u.terms {
+		result.terms = append(result.terms, unitTerm{name: term.name, exponent: term.exponent * exponent})
+	}
+	return result
+}
+
+// String displays the unit in a form accepted by ParseUnit
+func (u Unit) String() string {
+	var numerator, denominator []string
+	for _, term := range u.terms {
+		if term.exponent > 0 {
+			numerator = append(numerator, formatUnitTerm(term.name, term.exponent))
+		} else {
+			denominator = append(denominator, formatUnitTerm(term.name, -term.exponent))
+		}
+	}
+	if len(numerator) == 0 {
+		// No numerator, negative exponents are displayed
+		var terms []string
+		for _, term := range u.terms {
+			terms = append(terms, formatUnitTerm(term.name, term.exponent))
+		}
+		return strings.Join(terms, "*")
+	}
+	result := strings.Join(numerator, "*")
+	for _, term := range denominator {
+		result += "/" + term
+	}
+	return result
+}
+
+func formatUnitTerm(name string, exponent int) string {
+	if exponent == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s^%d", name, exponent)
+}
+
+// toBase returns the value of the unit in SI base units and its dimension
+func (u Unit) toBase() (decimal.Decimal, Dimension) {
+	factor := decimal.NewFromInt(1)
+	var dimension Dimension
+	for _, term := range u.terms {
+		definition, _ := lookupUnit(term.name)
+		for i := 0; i < term.exponent; i++ {
+			factor = factor.Mul(definition.factor)
+		}
+		for i := 0; i > term.exponent; i-- {
+			factor = factor.Div(definition.factor)
+		}
+		for idx := range dimension {
+			dimension[idx] += definition.dimension[idx] * term.exponent
+		}
+	}
+	return factor, dimension
+}
+
+func (u Unit) Dimension() Dimension {
+	_, dimension := u.toBase()
+	return dimension
+}
+
+// baseUnit returns the unit made of the SI base units for a dimension
+func baseUnit(dimension Dimension) Unit {
+	var result Unit
+	for idx, exponent := range dimension {
+		if exponent != 0 {
+			result.terms = append(result.terms, unitTerm{name: siBaseUnits[idx], exponent: exponent})
+		}
+	}
+	return result
+}
+
+// convertUnits converts a value expressed in unit from into unit to
+func convertUnits(value decimal.Decimal, from Unit, to Unit) (decimal.Decimal, error) {
+	fromFactor, fromDimension := from.toBase()
+	toFactor, toDimension := to.toBase()
+	if fromDimension != toDimension {
+		return decimal.Decimal{}, fmt.Errorf("incompatible units %s and %s", from, to)
+	}
+	return value.Mul(fromFactor).Div(toFactor), nil
+}
@@ -2,9 +2,13 @@ package rcalc
 
 import (
 	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/shopspring/decimal"
+	"gonum.org/v1/gonum/mat"
 	"troisdizaines.com/rcalc/rcalc/protostack"
 )
 
@@ -21,8 +25,8 @@ func (se *NumericVariable) asNumericVar() *NumericVariable {
 	return se
 }
 
-func (se *NumericVariable) display() string {
-	return se.value.String()
+func (se *NumericVariable) display(modes *Modes) string {
+	return modes.formatDecimal(se.value)
 }
 
 func CreateNumericVariable(value decimal.Decimal) Variable {
@@ -61,7 +65,7 @@ func (se *BooleanVariable) asBooleanVar() *BooleanVariable {
 	return se
 }
 
-func (se *BooleanVariable) display() string {
+func (se *BooleanVariable) display(modes *Modes) string {
 	return fmt.Sprintf("%t", se.value)
 }
 
@@ -73,6 +77,282 @@ func CreateBooleanVariable(value bool) Variable {
 	return &result
 }
 
+type StringVariable struct {
+	CommonVariable
+	value string
+}
+
+var _ Variable = (*StringVariable)(nil)
+
+func CreateStringVariable(value string) *StringVariable {
+	return &StringVariable{
+		CommonVariable: CommonVariable{fType: TYPE_STR},
+		value:          value,
+	}
+}
+
+func (s *StringVariable) String() string {
+	return fmt.Sprintf("StringVariable(%q)", s.value)
+}
+
+func (s *StringVariable) asStringVar() *StringVariable {
+	return s
+}
+
+// display returns the string as a literal which can be parsed again
+func (s *StringVariable) display(modes *Modes) string {
+	return `"` + stringEscaper.Replace(s.value) + `"`
+}
+
+var stringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+var stringUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\t`, "\t")
+
+// UnquoteString converts a string literal as written in a command line to its value
+func UnquoteString(literal string) string {
+	return stringUnescaper.Replace(literal[1 : len(literal)-1])
+}
+
+// RationalVariable exact fraction, always normalized with a denominator greater than 1,
+// integers are NumericVariable
+type RationalVariable struct {
+	CommonVariable
+	value *big.Rat
+}
+
+var _ Variable = (*RationalVariable)(nil)
+
+func CreateRationalVariable(value *big.Rat) *RationalVariable {
+	return &RationalVariable{
+		CommonVariable: CommonVariable{fType: TYPE_RATIONAL},
+		value:          value,
+	}
+}
+
+// CreateExactNumberVariable returns a NumericVariable for integers and a RationalVariable otherwise
+func CreateExactNumberVariable(value *big.Rat) Variable {
+	if value.IsInt() {
+		return CreateNumericVariable(decimal.NewFromBigInt(value.Num(), 0))
+	}
+	return CreateRationalVariable(value)
+}
+
+func (r *RationalVariable) String() string {
+	return fmt.Sprintf("RationalVariable(%s)", r.value.String())
+}
+
+func (r *RationalVariable) asRationalVar() *RationalVariable {
+	return r
+}
+
+func (r *RationalVariable) display(modes *Modes) string {
+	return r.value.String()
+}
+
+func (r *RationalVariable) decimalValue() decimal.Decimal {
+	return decimal.NewFromBigRat(r.value, int32(decimal.DivisionPrecision))
+}
+
+// QuantityVariable number with a unit of measure
+type QuantityVariable struct {
+	CommonVariable
+	value decimal.Decimal
+	unit  Unit
+}
+
+var _ Variable = (*QuantityVariable)(nil)
+
+func CreateQuantityVariable(value decimal.Decimal, unit Unit) *QuantityVariable {
+	return &QuantityVariable{
+		CommonVariable: CommonVariable{fType: TYPE_QUANTITY},
+		value:          value,
+		unit:           unit,
+	}
+}
+
+// CreateQuantityOrNumericVariable returns a plain number, converted in SI base units, when
+// the unit is dimensionless like m/km
+func CreateQuantityOrNumericVariable(value decimal.Decimal, unit Unit) Variable {
+	factor, dimension := unit.toBase()
+	if dimension == (Dimension{}) {
+		return CreateNumericVariable(value.Mul(factor))
+	}
+	return CreateQuantityVariable(value, unit)
+}
+
+func (q *QuantityVariable) String() string {
+	return fmt.Sprintf("QuantityVariable(%v_%s)", q.value, q.unit)
+}
+
+func (q *QuantityVariable) asQuantityVar() *QuantityVariable {
+	return q
+}
+
+func (q *QuantityVariable) display(modes *Modes) string {
+	return modes.formatDecimal(q.value) + "_" + q.unit.String()
+}
+
+// BinaryIntegerVariable unsigned integer displayed in the current base, only the bits inside the
+// word size are significant
+type BinaryIntegerVariable struct {
+	CommonVariable
+	value uint64
+}
+
+var _ Variable = (*BinaryIntegerVariable)(nil)
+
+func CreateBinaryIntegerVariable(value uint64) *BinaryIntegerVariable {
+	return &BinaryIntegerVariable{
+		CommonVariable: CommonVariable{fType: TYPE_BINARY},
+		value:          value,
+	}
+}
+
+func (b *BinaryIntegerVariable) String() string {
+	return fmt.Sprintf("BinaryIntegerVariable(%d)", b.value)
+}
+
+func (b *BinaryIntegerVariable) asBinaryVar() *BinaryIntegerVariable {
+	return b
+}
+
+var binaryBaseSuffixes = map[BinaryBase]string{BASE_DEC: "d", BASE_HEX: "h", BASE_OCT: "o", BASE_BIN: "b"}
+var binaryBaseRadixes = map[BinaryBase]int{BASE_DEC: 10, BASE_HEX: 16, BASE_OCT: 8, BASE_BIN: 2}
+
+// display uses the base suffix to be parsed again whatever the base mode is: #FFh
+func (b *BinaryIntegerVariable) display(modes *Modes) string {
+	value := b.value & modes.wordMask()
+	return "#" + strings.ToUpper(strconv.FormatUint(value, binaryBaseRadixes[modes.base])) + binaryBaseSuffixes[modes.base]
+}
+
+// ParseBinaryInteger parses #FFh, #1011b, #17o, #255d or #FF in the given base. Hex digits are
+// uppercase, the lowercase suffix cannot be taken for a digit: #1Bh is 27 and #1b is 1.
+func ParseBinaryInteger(literal string, base BinaryBase) (uint64, error) {
+	digits := strings.TrimPrefix(literal, "#")
+	for suffixBase, suffix := range binaryBaseSuffixes {
+		if strings.HasSuffix(digits, suffix) {
+			base = suffixBase
+			digits = strings.TrimSuffix(digits, suffix)
+			break
+		}
+	}
+	if strings.ContainsFunc(digits, unicode.IsLower) {
+		return 0, fmt.Errorf("invalid binary integer %s, hex digits must be uppercase", literal)
+	}
+	value, err := strconv.ParseUint(digits, binaryBaseRadixes[base], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid binary integer %s", literal)
+	}
+	return value, nil
+}
+
+// ComplexVariable complex number, parts are stored as float64
+type ComplexVariable struct {
+	CommonVariable
+	value complex128
+}
+
+var _ Variable = (*ComplexVariable)(nil)
+
+func CreateComplexVariable(value complex128) *ComplexVariable {
+	return &ComplexVariable{
+		CommonVariable: CommonVariable{fType: TYPE_COMPLEX},
+		value:          value,
+	}
+}
+
+func (c *ComplexVariable) String() string {
+	return fmt.Sprintf("ComplexVariable(%v)", c.value)
+}
+
+func (c *ComplexVariable) asComplexVar() *ComplexVariable {
+	return c
+}
+
+func (c *ComplexVariable) display(modes *Modes) string {
+	return fmt.Sprintf("(%s, %s)", modes.formatFloat(real(c.value)), modes.formatFloat(imag(c.value)))
+}
+
+// VectorVariable vector of real numbers, values are stored as float64 for gonum
+type VectorVariable struct {
+	CommonVariable
+	value *mat.VecDense
+}
+
+var _ Variable = (*VectorVariable)(nil)
+
+func CreateVectorVariable(value *mat.VecDense) *VectorVariable {
+	return &VectorVariable{
+		CommonVariable: CommonVariable{fType: TYPE_VECTOR},
+		value:          value,
+	}
+}
+
+func CreateVectorVariableFromValues(values []float64) *VectorVariable {
+	return CreateVectorVariable(mat.NewVecDense(len(values), values))
+}
+
+func (v *VectorVariable) String() string {
+	return fmt.Sprintf("VectorVariable(%v)", v.values())
+}
+
+func (v *VectorVariable) asVectorVar() *VectorVariable {
+	return v
+}
+
+func (v *VectorVariable) display(modes *Modes) string {
+	return displayFloats(modes, v.values())
+}
+
+// values returns a copy of the values of the vector
+func (v *VectorVariable) values() []float64 {
+	return mat.Col(nil, 0, v.value)
+}
+
+func (v *VectorVariable) Size() int {
+	return v.value.Len()
+}
+
+// MatrixVariable matrix of real numbers, values are stored as float64 for gonum
+type MatrixVariable struct {
+	CommonVariable
+	value *mat.Dense
+}
+
+var _ Variable = (*MatrixVariable)(nil)
+
+func CreateMatrixVariable(value *mat.Dense) *MatrixVariable {
+	return &MatrixVariable{
+		CommonVariable: CommonVariable{fType: TYPE_MATRIX},
+		value:          value,
+	}
+}
+
+func (m *MatrixVariable) String() string {
+	return fmt.Sprintf("MatrixVariable(%v)", mat.Formatted(m.value, mat.FormatMATLAB()))
+}
+
+func (m *MatrixVariable) asMatrixVar() *MatrixVariable {
+	return m
+}
+
+func (m *MatrixVariable) display(modes *Modes) string {
+	rows, _ := m.value.Dims()
+	displayedRows := make([]string, rows)
+	for i := 0; i < rows; i++ {
+		displayedRows[i] = displayFloats(modes, mat.Row(nil, i, m.value))
+	}
+	return fmt.Sprintf("[ %s ]", strings.Join(displayedRows, " "))
+}
+
+// displayFloats displays values as a vector which can be parsed again
+func displayFloats(modes *Modes, values []float64) string {
+	displayedValues := make([]string, len(values))
+	for i, value := range values {
+		displayedValues[i] = modes.formatFloat(value)
+	}
+	return fmt.Sprintf("[ %s ]", strings.Join(displayedValues, " "))
+}
+
 type ListVariable struct {
 	CommonVariable
 	items []Variable
@@ -87,10 +367,10 @@ func CreateListVariable(items []Variable) *ListVariable {
 	}
 }
 
-func (l *ListVariable) display() string {
+func (l *ListVariable) display(modes *Modes) string {
 	displayedItems := make([]string, len(l.items))
 	for i, item := range l.items {
-		displayedItems[i] = item.display()
+		displayedItems[i] = item.display(modes)
 	}
 	return fmt.Sprintf("{ %s }", strings.Join(displayedItems, " "))
 }
@@ -121,14 +401,21 @@ func (a *AlgExprMulDiv) Evaluate(variableReader VariableReader) (*NumericVariabl
 	}
 
 	result := decimal.NewFromInt(1)
-	for _, it := range a.items {
+	for idx, it := range a.items {
 		variable, err := it.Evaluate(variableReader)
 		if err != nil {
 			return nil, err
 		}
 		numericVar := variable.asNumericVar()
 		subExprValue := numericVar.value
-		result = result.Mul(subExprValue)
+		if idx > 0 && a.operators[idx-1] == OPERATOR_DIV {
+			if subExprValue.IsZero() {
+				return nil, errDivisionByZero
+			}
+			result = result.Div(subExprValue)
+		} else {
+			result = result.Mul(subExprValue)
+		}
 	}
 	return CreateNumericVariable(result).asNumericVar(), nil
 }
@@ -145,14 +432,13 @@ func (a *AlgExprPow) Evaluate(variableReader VariableReader) (*NumericVariable,
 		return a.items[0].Evaluate(variableReader)
 	}
 
-	fmt.Printf("Pow items(%d):\n", len(a.items))
+	GetLogger().Debugf("Pow items(%d):", len(a.items))
 	for idx, it := range a.items {
-		fmt.Printf("  - item[%d] = %v\n", idx, it)
+		GetLogger().Debugf("  - item[%d] = %v", idx, it)
 	}
 
 	result := decimal.NewFromInt(1)
 	for i := len(a.items) - 1; i >= 0; i-- {
-		fmt.Printf("i = %d\n", i)
 		variable, err := a.items[i].Evaluate(variableReader)
 		if err != nil {
 			return nil, err
@@ -173,6 +459,12 @@ const (
 	OPERATOR_SUB = 1
 )
 
+// operators of AlgExprMulDiv, positions of the tokens like the ones of AlgExprAddSub
+const (
+	OPERATOR_MUL = 0
+	OPERATOR_DIV = 1
+)
+
 type AlgExprAddSub struct {
 	items     []AlgebraicExpressionNode
 	operators []int
@@ -187,7 +479,7 @@ func (a *AlgExprAddSub) Evaluate(variableReader VariableReader) (*NumericVariabl
 	result := decimal.NewFromInt(0)
 	for idx, it := range a.items {
 		operator := OPERATOR_ADD
-		if idx > 1 {
+		if idx > 0 {
 			operator = a.operators[idx-1]
 		}
 		switch operator {
@@ -235,6 +527,8 @@ func (aev *AlgExprVariable) Evaluate(variableReader VariableReader) (*NumericVar
 	if variableValue.getType() == TYPE_NUMERIC {
 		numericVar := variableValue.asNumericVar()
 		return numericVar, nil
+	} else if variableValue.getType() == TYPE_RATIONAL {
+		return CreateNumericVariable(variableValue.asRationalVar().decimalValue()).asNumericVar(), nil
 	} else {
 		return nil, fmt.Errorf("variable %s is not of numeric type", varName)
 	}
@@ -248,9 +542,13 @@ type AlgExprSignedElt struct {
 var _ AlgebraicExpressionNode = (*AlgExprSignedElt)(nil)
 
 func (a *AlgExprSignedElt) Evaluate(variableReader VariableReader) (*NumericVariable, error) {
-	result, _ := a.items.Evaluate(variableReader)
+	result, err := a.items.Evaluate(variableReader)
+	if err != nil {
+		return nil, err
+	}
 	if a.operator == OPERATOR_SUB {
-		result.value = result.value.Neg()
+		// result can be the value of a variable of the memory, it must not be modified
+		return CreateNumericVariable(result.value.Neg()).asNumericVar(), nil
 	}
 	return result, nil
 }
@@ -276,7 +574,7 @@ func (a AlgExprFunctionElt) Evaluate(variableReader VariableReader) (*NumericVar
 		}
 	}
 
-	return CreateNumericVariable(a.fn(args...)).asNumericVar(), nil
+	return CreateNumericVariable(a.fn(variableReader.Modes(), args...)).asNumericVar(), nil
 }
 
 type AlgebraicExpressionVariable struct {
@@ -293,7 +591,7 @@ func (se *AlgebraicExpressionVariable) asIdentifierVar() *AlgebraicExpressionVar
 	return se
 }
 
-func (se *AlgebraicExpressionVariable) display() string {
+func (se *AlgebraicExpressionVariable) display(modes *Modes) string {
 	return fmt.Sprintf("'%s'", se.value)
 }
 
@@ -311,7 +609,7 @@ type ProgramVariable struct {
 	actions []Action
 }
 
-func (p *ProgramVariable) display() string {
+func (p *ProgramVariable) display(modes *Modes) string {
 
 	actionStr := []string{}
 	for _, action := range p.actions {
@@ -406,6 +704,39 @@ func CreateVariableFromProto(reg *ActionRegistry, protoVariable *protostack.Vari
 		return CreateAlgebraicExpressionVariableFromProto(reg, protoVariable.GetAlgExpr())
 	case protostack.VariableType_LIST:
 		return CreateListFromProto(reg, protoVariable.GetList())
+	case protostack.VariableType_STRING:
+		return CreateStringVariable(protoVariable.GetStr().GetValue()), nil
+	case protostack.VariableType_VECTOR:
+		return CreateVectorVariableFromValues(protoVariable.GetVector().GetValues()), nil
+	case protostack.VariableType_RATIONAL:
+		value, ok := new(big.Rat).SetString(protoVariable.GetRational().GetValue())
+		if !ok {
+			return nil, fmt.Errorf("invalid rational number %s", protoVariable.GetRational().GetValue())
+		}
+		return CreateExactNumberVariable(value), nil
+	case protostack.VariableType_QUANTITY:
+		protoQuantity := protoVariable.GetQuantity()
+		value := decimal.NewFromInt(0)
+		if err := value.UnmarshalBinary(protoQuantity.GetValue()); err != nil {
+			return nil, err
+		}
+		unit, err := ParseUnit(protoQuantity.GetUnit())
+		if err != nil {
+			return nil, err
+		}
+		return CreateQuantityVariable(value, unit), nil
+	case protostack.VariableType_BINARY_INTEGER:
+		return CreateBinaryIntegerVariable(protoVariable.GetBinary().GetValue()), nil
+	case protostack.VariableType_COMPLEX:
+		protoComplex := protoVariable.GetComplex()
+		return CreateComplexVariable(complex(protoComplex.GetReal(), protoComplex.GetImag())), nil
+	case protostack.VariableType_MATRIX:
+		protoMatrix := protoVariable.GetMatrix()
+		rows, cols := int(protoMatrix.GetRows()), int(protoMatrix.GetCols())
+		if rows*cols != len(protoMatrix.GetValues()) || rows == 0 {
+			return nil, fmt.Errorf("invalid matrix of %dx%d with %d values", rows, cols, len(protoMatrix.GetValues()))
+		}
+		return CreateMatrixVariable(mat.NewDense(rows, cols, protoMatrix.GetValues())), nil
 	default:
 		return nil, fmt.Errorf("unknown variable type")
 	}
@@ -414,7 +745,7 @@ func CreateVariableFromProto(reg *ActionRegistry, protoVariable *protostack.Vari
 func CreateAlgebraicExpressionVariableFromProto(
 	reg *ActionRegistry,
 	protoAlgExpr *protostack.AlgebraicExpressionVariable) (*AlgebraicExpressionVariable, error) {
-	actions, err := ParseToActions(fmt.Sprintf("'%s'", protoAlgExpr.FullText), "", reg)
+	actions, err := ParseToActions(fmt.Sprintf("'%s'", protoAlgExpr.FullText), "", reg, NewModes())
 	return actions[0].(*VariablePutOnStackActionDesc).value.(*AlgebraicExpressionVariable), err
 }
 
@@ -472,6 +803,57 @@ func CreateProtoFromVariable(variable Variable) (*protostack.Variable, error) {
 			Type:    protostack.VariableType_LIST,
 			RealVar: &protostack.Variable_List{List: protoListVar},
 		}, nil
+	case TYPE_STR:
+		protoStrVar := &protostack.StringVariable{Value: variable.asStringVar().value}
+		return &protostack.Variable{
+			Type:    protostack.VariableType_STRING,
+			RealVar: &protostack.Variable_Str{Str: protoStrVar},
+		}, nil
+	case TYPE_VECTOR:
+		values := variable.asVectorVar().values()
+		return &protostack.Variable{
+			Type:    protostack.VariableType_VECTOR,
+			RealVar: &protostack.Variable_Vector{Vector: &protostack.VectorVariable{Values: values}},
+		}, nil
+	case TYPE_MATRIX:
+		matrix := variable.asMatrixVar().value
+		rows, cols := matrix.Dims()
+		values := make([]float64, 0, rows*cols)
+		for i := 0; i < rows; i++ {
+			values = append(values, mat.Row(nil, i, matrix)...)
+		}
+		protoMatrixVar := &protostack.MatrixVariable{Rows: int32(rows), Cols: int32(cols), Values: values}
+		return &protostack.Variable{
+			Type:    protostack.VariableType_MATRIX,
+			RealVar: &protostack.Variable_Matrix{Matrix: protoMatrixVar},
+		}, nil
+	case TYPE_RATIONAL:
+		protoRationalVar := &protostack.RationalVariable{Value: variable.asRationalVar().value.String()}
+		return &protostack.Variable{
+			Type:    protostack.VariableType_RATIONAL,
+			RealVar: &protostack.Variable_Rational{Rational: protoRationalVar},
+		}, nil
+	case TYPE_QUANTITY:
+		quantity := variable.asQuantityVar()
+		binaryNumber, err := quantity.value.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return &protostack.Variable{
+			Type:    protostack.VariableType_QUANTITY,
+			RealVar: &protostack.Variable_Quantity{Quantity: &protostack.QuantityVariable{Value: binaryNumber, Unit: quantity.unit.String()}},
+		}, nil
+	case TYPE_BINARY:
+		return &protostack.Variable{
+			Type:    protostack.VariableType_BINARY_INTEGER,
+			RealVar: &protostack.Variable_Binary{Binary: &protostack.BinaryIntegerVariable{Value: variable.asBinaryVar().value}},
+		}, nil
+	case TYPE_COMPLEX:
+		value := variable.asComplexVar().value
+		return &protostack.Variable{
+			Type:    protostack.VariableType_COMPLEX,
+			RealVar: &protostack.Variable_Complex{Complex: &protostack.ComplexVariable{Real: real(value), Imag: imag(value)}},
+		}, nil
 	default:
 		return nil, fmt.Errorf("marshalling of variables of type %d is not implemented yet", variable.getType())
 	}
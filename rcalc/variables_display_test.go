@@ -3,6 +3,8 @@ package rcalc
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+	"math/big"
 	"testing"
 )
 
@@ -24,11 +26,43 @@ func TestDisplayVariables(t *testing.T) {
 					variableToEvaluate: CreateProgramVariable([]Action{}),
 				}}),
 			expectedDisplay: "<< -> a <<  >> >>",
+		},
+		{
+			variable:        CreateStringVariable("say \"hi\"\n"),
+			expectedDisplay: `"say \"hi\"\n"`,
+		},
+		{
+			variable:        CreateVectorVariableFromValues([]float64{1, -2.5, 3}),
+			expectedDisplay: "[ 1 -2.5 3 ]",
+		},
+		{
+			variable:        CreateMatrixVariable(mat.NewDense(2, 2, []float64{1, 2, 3, 4})),
+			expectedDisplay: "[ [ 1 2 ] [ 3 4 ] ]",
+		},
+		{
+			variable:        CreateComplexVariable(complex(3, -4.5)),
+			expectedDisplay: "(3, -4.5)",
+		},
+		{
+			variable:        CreateRationalVariable(big.NewRat(-1, 3)),
+			expectedDisplay: "-1/3",
+		},
+		{
+			variable:        qtyVar(9.81, "m/s^2"),
+			expectedDisplay: "9.81_m/s^2",
+		},
+		{
+			variable:        qtyVar(2, "s^-1"),
+			expectedDisplay: "2_s^-1",
+		},
+		{
+			variable:        CreateBinaryIntegerVariable(255),
+			expectedDisplay: "#255d",
 		}}
 	for idx, test := range tests {
 		t.Run(fmt.Sprintf("Parse %02d", idx+1), func(t *testing.T) {
 			programVariable := test.variable
-			assert.Equal(t, test.expectedDisplay, programVariable.display())
+			assert.Equal(t, test.expectedDisplay, programVariable.display(NewModes()))
 		})
 	}
 }
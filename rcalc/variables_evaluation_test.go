@@ -0,0 +1,78 @@
+package rcalc
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateAlgebraicOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     AlgebraicExpressionNode
+		expected string
+	}{
+		{"product", &AlgExprMulDiv{
+			items:     []AlgebraicExpressionNode{algNumberFromInt(6), algNumberFromInt(4)},
+			operators: []int{OPERATOR_MUL},
+		}, "24"},
+		{"quotient", &AlgExprMulDiv{
+			items:     []AlgebraicExpressionNode{algNumberFromInt(6), algNumberFromInt(4)},
+			operators: []int{OPERATOR_DIV},
+		}, "1.5"},
+		{"quotient then product", &AlgExprMulDiv{
+			items:     []AlgebraicExpressionNode{algNumberFromInt(24), algNumberFromInt(4), algNumberFromInt(2)},
+			operators: []int{OPERATOR_DIV, OPERATOR_MUL},
+		}, "12"},
+		{"difference", &AlgExprAddSub{
+			items:     []AlgebraicExpressionNode{algNumberFromInt(10), algNumberFromInt(4)},
+			operators: []int{OPERATOR_SUB},
+		}, "6"},
+		{"difference then sum", &AlgExprAddSub{
+			items:     []AlgebraicExpressionNode{algNumberFromInt(10), algNumberFromInt(4), algNumberFromInt(1)},
+			operators: []int{OPERATOR_SUB, OPERATOR_ADD},
+		}, "7"},
+		{"sum then difference", &AlgExprAddSub{
+			items:     []AlgebraicExpressionNode{algNumberFromInt(10), algNumberFromInt(4), algNumberFromInt(1)},
+			operators: []int{OPERATOR_ADD, OPERATOR_SUB},
+		}, "13"},
+	}
+	runtimeContext := CreateRuntimeContext(CreateSystemInstance(), CreateStack())
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.node.Evaluate(runtimeContext)
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.expected, result.value.String())
+			}
+		})
+	}
+}
+
+func TestEvaluateAlgebraicDivisionByZero(t *testing.T) {
+	node := &AlgExprMulDiv{
+		items:     []AlgebraicExpressionNode{algNumberFromInt(1), algNumberFromInt(0)},
+		operators: []int{OPERATOR_DIV},
+	}
+	_, err := node.Evaluate(CreateRuntimeContext(CreateSystemInstance(), CreateStack()))
+	assert.ErrorIs(t, err, errDivisionByZero)
+}
+
+func TestEvaluateNegatedVariable(t *testing.T) {
+	system := CreateSystemInstance()
+	_, err := system.memory.createVariable("a", system.memory.getRoot(), CreateNumericVariable(decimal.NewFromInt(7)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	runtimeContext := CreateRuntimeContext(system, CreateStack())
+	node := &AlgExprSignedElt{items: &AlgExprVariable{value: "a"}, operator: OPERATOR_SUB}
+	for range 2 {
+		result, err := node.Evaluate(runtimeContext)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "-7", result.value.String(), "the value of a must not be negated in the memory")
+		}
+	}
+
+	_, err = (&AlgExprSignedElt{items: &AlgExprVariable{value: "b"}, operator: OPERATOR_SUB}).Evaluate(runtimeContext)
+	assert.EqualError(t, err, "cannot find variable b")
+}